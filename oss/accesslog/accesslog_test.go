@@ -0,0 +1,183 @@
+package accesslog
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+type OssAccessLogSuite struct{}
+
+var _ = Suite(&OssAccessLogSuite{})
+
+func logLine(ip, t, request string, status int, operation, key string) string {
+	return fmt.Sprintf(`%s - - [%s] "%s" %d 368 27 "http://www.aliyun.com/product/oss" "curl/7.65.3" "examplebucket.oss-cn-hangzhou.aliyuncs.com" `+
+		`"5FF16B65F05BC932307A3C3C" "true" "16571836914537" "%s" "examplebucket" "%s" 1024 5 "-" 29 "1657183691453" 0 "-" "Standard" "-" "-" "STS.NUgYrLnoC"`,
+		ip, t, request, status, operation, key)
+}
+
+func (s *OssAccessLogSuite) TestParseLine(c *C) {
+	record, err := ParseLine(logLine("1.2.3.4", "03/Jan/2021:14:59:49 +0800", "GET /example%20dir/a+b.jpg?x-oss-process=image HTTP/1.1", 200,
+		"GetObject", "example%20dir%2Fa+b.jpg"))
+	c.Assert(err, IsNil)
+	c.Assert(record.RemoteIP, Equals, "1.2.3.4")
+	c.Assert(record.Time.Equal(time.Date(2021, 1, 3, 6, 59, 49, 0, time.UTC)), Equals, true)
+	c.Assert(record.Method, Equals, "GET")
+	c.Assert(record.RequestURI, Equals, "/example%20dir/a+b.jpg?x-oss-process=image")
+	c.Assert(record.Protocol, Equals, "HTTP/1.1")
+	c.Assert(record.Status, Equals, 200)
+	c.Assert(record.SentBytes, Equals, int64(368))
+	c.Assert(record.RequestTime, Equals, 27*time.Millisecond)
+	c.Assert(record.Referer, Equals, "http://www.aliyun.com/product/oss")
+	c.Assert(record.UserAgent, Equals, "curl/7.65.3")
+	c.Assert(record.RequestID, Equals, "5FF16B65F05BC932307A3C3C")
+	c.Assert(record.LoggingFlag, Equals, true)
+	c.Assert(record.Requester, Equals, "16571836914537")
+	c.Assert(record.Operation, Equals, "GetObject")
+	c.Assert(record.Bucket, Equals, "examplebucket")
+	c.Assert(record.Key, Equals, "example dir/a+b.jpg")
+	c.Assert(record.ObjectSize, Equals, int64(1024))
+	c.Assert(record.ServerCostTime, Equals, 5*time.Millisecond)
+	c.Assert(record.ErrorCode, Equals, "")
+	c.Assert(record.RequestLength, Equals, int64(29))
+	c.Assert(record.SyncRequest, Equals, "")
+	c.Assert(record.StorageClass, Equals, "Standard")
+	c.Assert(record.IsSTS(), Equals, true)
+	c.Assert(record.Extra, IsNil)
+
+	// The older format has less fields, the newer format has more fields, the quotes are escaped
+	record, err = ParseLine(`1.2.3.4 - - [03/Jan/2021:14:59:49 +0800] "PUT /a HTTP/1.1" 403 0 1 "-" "Go \"sdk\"" "host" "id" "true" "-" "PutObject" "b" "a" - 1 "AccessDenied"`)
+	c.Assert(err, IsNil)
+	c.Assert(record.UserAgent, Equals, `Go "sdk"`)
+	c.Assert(record.Requester, Equals, "")
+	c.Assert(record.ObjectSize, Equals, int64(0))
+	c.Assert(record.ErrorCode, Equals, "AccessDenied")
+	c.Assert(record.IsError(), Equals, true)
+	c.Assert(record.AccessKeyID, Equals, "")
+
+	record, err = ParseLine(logLine("1.2.3.4", "03/Jan/2021:14:59:49 +0800", "GET / HTTP/1.1", 200, "GetBucket", "-") + ` "new" "fields"`)
+	c.Assert(err, IsNil)
+	c.Assert(record.Key, Equals, "")
+	c.Assert(record.Extra, DeepEquals, []string{"new", "fields"})
+
+	for _, line := range []string{
+		`1.2.3.4 - - [03/Jan/2021:14:59:49 +0800] "GET / HTTP/1.1"`,
+		`1.2.3.4 - - [bad time] "GET / HTTP/1.1" 200`,
+		`1.2.3.4 - - [03/Jan/2021:14:59:49 +0800] "GET / HTTP/1.1 200`,
+		`1.2.3.4 - - [03/Jan/2021:14:59:49 +0800] "GET / HTTP/1.1" ok`,
+	} {
+		_, err = ParseLine(line)
+		c.Assert(err, NotNil, Commentf(line))
+	}
+}
+
+func (s *OssAccessLogSuite) TestBucketReader(c *C) {
+	location := time.FixedZone("CST", 8*3600)
+	objects := map[string]string{
+		"log/examplebucket2021-01-03-13-00-00-0001": logLine("1.1.1.1", "03/Jan/2021:13:59:00 +0800", "GET /a HTTP/1.1", 200, "GetObject", "a"),
+		"log/examplebucket2021-01-03-14-00-00-0001": strings.Join([]string{
+			logLine("1.1.1.1", "03/Jan/2021:14:00:00 +0800", "GET /a HTTP/1.1", 200, "GetObject", "a"),
+			"",
+			logLine("2.2.2.2", "03/Jan/2021:14:10:00 +0800", "GET /b HTTP/1.1", 404, "GetObject", "b"),
+			logLine("2.2.2.2", "03/Jan/2021:14:20:00 +0800", "PUT /c HTTP/1.1", 200, "PutObject", "c"),
+		}, "\n"),
+		"log/examplebucket2021-01-03-15-00-00-0001": strings.Join([]string{
+			logLine("2.2.2.2", "03/Jan/2021:14:59:59 +0800", "GET /a HTTP/1.1", 200, "GetObject", "a"),
+			logLine("3.3.3.3", "03/Jan/2021:15:00:00 +0800", "GET /a HTTP/1.1", 200, "GetObject", "a"),
+		}, "\n"),
+		"log/examplebucket2021-01-03-16-00-00-0001":  logLine("3.3.3.3", "03/Jan/2021:16:00:00 +0800", "GET /a HTTP/1.1", 200, "GetObject", "a"),
+		"log/examplebucket22021-01-03-14-00-00-0001": logLine("4.4.4.4", "03/Jan/2021:14:00:00 +0800", "GET /a HTTP/1.1", 200, "GetObject", "a"),
+	}
+	keys := []string{"log/examplebucket2021-01-03-13-00-00-0001", "log/examplebucket2021-01-03-14-00-00-0001",
+		"log/examplebucket2021-01-03-15-00-00-0001", "log/examplebucket2021-01-03-16-00-00-0001", "log/examplebucket22021-01-03-14-00-00-0001"}
+
+	var got, payers []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payers = append(payers, r.Header.Get(oss.HTTPHeaderOssRequester))
+		if r.URL.Path == "/target/" || r.URL.Path == "/target" {
+			query := r.URL.Query()
+			c.Assert(query.Get("prefix"), Equals, "log/examplebucket")
+			c.Assert(query.Get("start-after"), Equals, "log/examplebucket2021-01-03-14-00-00")
+			// Two pages
+			page, next := keys[1:3], "<IsTruncated>true</IsTruncated><NextContinuationToken>t</NextContinuationToken>"
+			if query.Get("continuation-token") == "t" {
+				page, next = keys[3:], "<IsTruncated>false</IsTruncated>"
+			}
+			body := "<ListBucketResult>" + next
+			for _, key := range page {
+				body += "<Contents><Key>" + key + "</Key></Contents>"
+			}
+			w.Write([]byte(body + "</ListBucketResult>"))
+			return
+		}
+		key := strings.TrimPrefix(r.URL.Path, "/target/")
+		got = append(got, key)
+		w.Write([]byte(objects[key]))
+	}))
+	defer server.Close()
+
+	client, err := oss.New(server.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("target")
+	c.Assert(err, IsNil)
+
+	start := time.Date(2021, 1, 3, 14, 0, 0, 0, location)
+	end := time.Date(2021, 1, 3, 15, 0, 0, 0, location)
+	reader := NewBucketReader(bucket, "log/", "examplebucket", start, end, []Filter{ByOperation("getobject")},
+		oss.RequestPayer(oss.Requester))
+	defer reader.Close()
+	summary := NewSummary()
+	ips := NewCounter(func(r AccessLogRecord) string { return r.RemoteIP })
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, IsNil)
+		summary.Add(record)
+		ips.Add(record)
+	}
+	// The log of 15:00 has the requests before 15:00, the log of 16:00 isn't read
+	c.Assert(got, DeepEquals, []string{"log/examplebucket2021-01-03-14-00-00-0001", "log/examplebucket2021-01-03-15-00-00-0001"})
+	// The options are sent with the list and the get requests
+	c.Assert(payers, DeepEquals, []string{"requester", "requester", "requester", "requester"})
+	c.Assert(summary.Requests, Equals, int64(3))
+	c.Assert(summary.Errors, Equals, int64(1))
+	c.Assert(summary.ByStatus, DeepEquals, map[int]int64{200: 2, 404: 1})
+	c.Assert(summary.ByOperation, DeepEquals, map[string]int64{"GetObject": 3})
+	c.Assert(summary.SentBytes, Equals, int64(3*368))
+	c.Assert(summary.AverageServerCostTime(), Equals, 5*time.Millisecond)
+	c.Assert(summary.First.Equal(start), Equals, true)
+	c.Assert(summary.Last.Equal(time.Date(2021, 1, 3, 14, 59, 59, 0, location)), Equals, true)
+	c.Assert(ips.Top(1), DeepEquals, []Count{{Key: "2.2.2.2", Count: 2}})
+	c.Assert(ips.Top(0), DeepEquals, []Count{{Key: "2.2.2.2", Count: 2}, {Key: "1.1.1.1", Count: 1}})
+}
+
+func (s *OssAccessLogSuite) TestFilters(c *C) {
+	record, err := ParseLine(logLine("1.2.3.4", "03/Jan/2021:14:59:49 +0800", "GET /dir/a HTTP/1.1", 503, "GetObject", "dir/a"))
+	c.Assert(err, IsNil)
+	c.Assert(Match(record), Equals, true)
+	c.Assert(Match(record, ByStatus(500, 599), ByKeyPrefix("dir/"), ByRemoteIP("5.6.7.8", "1.2.3.4"), UserRequests()), Equals, true)
+	c.Assert(Match(record, ByOperation("PutObject", "DeleteObject")), Equals, false)
+	c.Assert(Match(record, ByKeyPrefix("other/")), Equals, false)
+	c.Assert(Match(record, ByTime(time.Time{}, record.Time)), Equals, false)
+	c.Assert(Match(record, ByTime(record.Time, time.Time{})), Equals, true)
+
+	reader := NewReader(strings.NewReader(logLine("1.2.3.4", "03/Jan/2021:14:59:49 +0800", "GET / HTTP/1.1", 200, "GetBucket", "-") + "\nbad line\n"))
+	_, err = reader.Read()
+	c.Assert(err, IsNil)
+	_, err = reader.Read()
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "line 2"), Equals, true)
+}
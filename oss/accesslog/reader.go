@@ -0,0 +1,186 @@
+package accesslog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// The time in the names of the log objects, such as 2024-01-02-03-00-00
+const objectTimeFormat = "2006-01-02-15-04-05"
+
+const maxLineSize = 1024 * 1024
+
+// Reader reads the records of a log
+type Reader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NewReader creates the reader of a log, the empty lines are skipped
+func NewReader(r io.Reader) *Reader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	return &Reader{scanner: scanner}
+}
+
+// Read reads the next record, io.EOF is returned after the last record
+func (r *Reader) Read() (AccessLogRecord, error) {
+	for r.scanner.Scan() {
+		r.line++
+		line := strings.TrimSpace(r.scanner.Text())
+		if line == "" {
+			continue
+		}
+		record, err := ParseLine(line)
+		if err != nil {
+			return record, fmt.Errorf("%s at line %d", err.Error(), r.line)
+		}
+		return record, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return AccessLogRecord{}, err
+	}
+	return AccessLogRecord{}, io.EOF
+}
+
+// ListLogObjects lists the log objects of the source bucket for the time window by ListObjectsV2.
+// The logs are written after the hour, so the objects named in [start truncated to the hour, end + 1 hour)
+// are returned. The time in the names is parsed in the location of start.
+//
+// bucket    the target bucket of the logging.
+// prefix    the TargetPrefix of the logging.
+// sourceBucket    the bucket whose requests are logged.
+// start    the start of the window.
+// end    the end of the window, exclusive.
+//
+// []oss.ObjectProperties    the log objects in the order of the time.
+// error    it's nil if no error, otherwise it's an error object.
+func ListLogObjects(bucket *oss.Bucket, prefix, sourceBucket string, start, end time.Time, options ...oss.Option) ([]oss.ObjectProperties, error) {
+	namePrefix := prefix + sourceBucket
+	first, last := start.Truncate(time.Hour), end.Add(time.Hour)
+
+	var objects []oss.ObjectProperties
+	token := ""
+	for {
+		listOptions := append([]oss.Option{}, options...)
+		listOptions = append(listOptions, oss.Prefix(namePrefix), oss.StartAfter(namePrefix+first.Format(objectTimeFormat)))
+		if token != "" {
+			listOptions = append(listOptions, oss.ContinuationToken(token))
+		}
+		result, err := bucket.ListObjectsV2(listOptions...)
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range result.Objects {
+			t, ok := logObjectTime(object.Key[len(namePrefix):], start.Location())
+			if !ok || t.Before(first) {
+				// The logs of another bucket whose name starts with the source bucket
+				continue
+			}
+			if !t.Before(last) {
+				return objects, nil
+			}
+			objects = append(objects, object)
+		}
+		if !result.IsTruncated {
+			return objects, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// logObjectTime parses the time of the name without the prefix, such as 2024-01-02-03-00-00-0001
+func logObjectTime(name string, location *time.Location) (time.Time, bool) {
+	if len(name) < len(objectTimeFormat) {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(objectTimeFormat, name[:len(objectTimeFormat)], location)
+	return t, err == nil
+}
+
+// BucketReader reads the records of the source bucket in the time window from the log objects
+type BucketReader struct {
+	bucket       *oss.Bucket
+	prefix       string
+	sourceBucket string
+	start, end   time.Time
+	filters      []Filter
+	options      []oss.Option
+
+	listed  bool
+	objects []oss.ObjectProperties
+	next    int
+	body    io.ReadCloser
+	reader  *Reader
+}
+
+// NewBucketReader creates the reader of the logs, the objects are listed by ListLogObjects when it's read.
+// The records out of [start, end) or not matching all the filters are skipped.
+//
+// bucket    the target bucket of the logging.
+// prefix    the TargetPrefix of the logging.
+// sourceBucket    the bucket whose requests are logged.
+// start    the start of the window.
+// end    the end of the window, exclusive.
+// filters    the filters, such as ByOperation.
+// options    the options of ListObjectsV2 and GetObject, such as oss.RequestPayer and oss.WithContext.
+//
+// *BucketReader    the reader.
+func NewBucketReader(bucket *oss.Bucket, prefix, sourceBucket string, start, end time.Time, filters []Filter, options ...oss.Option) *BucketReader {
+	return &BucketReader{bucket: bucket, prefix: prefix, sourceBucket: sourceBucket, start: start, end: end,
+		filters: append([]Filter{ByTime(start, end)}, filters...), options: options}
+}
+
+// Next reads the next record, io.EOF is returned after the last record
+func (r *BucketReader) Next() (AccessLogRecord, error) {
+	if !r.listed {
+		objects, err := ListLogObjects(r.bucket, r.prefix, r.sourceBucket, r.start, r.end, r.options...)
+		if err != nil {
+			return AccessLogRecord{}, err
+		}
+		r.objects, r.listed = objects, true
+	}
+
+	for {
+		if r.reader == nil {
+			if r.next >= len(r.objects) {
+				return AccessLogRecord{}, io.EOF
+			}
+			body, err := r.bucket.GetObject(r.objects[r.next].Key, r.options...)
+			if err != nil {
+				return AccessLogRecord{}, err
+			}
+			r.next++
+			r.body, r.reader = body, NewReader(body)
+		}
+
+		record, err := r.reader.Read()
+		if err == io.EOF {
+			if err = r.Close(); err != nil {
+				return AccessLogRecord{}, err
+			}
+			continue
+		}
+		if err != nil {
+			return record, fmt.Errorf("%s of %s", err.Error(), r.objects[r.next-1].Key)
+		}
+		if Match(record, r.filters...) {
+			return record, nil
+		}
+	}
+}
+
+// Close closes the log object being read
+func (r *BucketReader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body, r.reader = nil, nil
+	return err
+}
@@ -0,0 +1,252 @@
+// Package accesslog reads the access logs written by the bucket logging, which is set by Client.SetBucketLogging.
+//
+// The logs of a bucket are the objects named <TargetPrefix><SourceBucket>YYYY-mm-DD-HH-MM-SS-<UniqueString> in the
+// target bucket, and every line of a log is a request:
+//
+//	reader := accesslog.NewBucketReader(targetBucket, "log/", "examplebucket", start, end,
+//		[]accesslog.Filter{accesslog.ByOperation("GetObject"), accesslog.ByStatus(400, 599)})
+//	defer reader.Close()
+//	summary := accesslog.NewSummary()
+//	for {
+//		record, err := reader.Next()
+//		if err == io.EOF {
+//			break
+//		}
+//		if err != nil {
+//			return err
+//		}
+//		summary.Add(record)
+//	}
+//
+// The fields are parsed by their positions. The logs written by the older versions have less fields, and the missing
+// fields are zero values, the fields added by the newer versions are kept in Extra.
+package accesslog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// TimeFormat is the format of the request time in the logs, such as 02/Jan/2006:15:04:05 +0800
+const TimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+// The positions of the fields
+const (
+	fieldRemoteIP = iota
+	fieldReserved1
+	fieldReserved2
+	fieldTime
+	fieldRequestURI
+	fieldStatus
+	fieldSentBytes
+	fieldRequestTime
+	fieldReferer
+	fieldUserAgent
+	fieldHostName
+	fieldRequestID
+	fieldLoggingFlag
+	fieldRequester
+	fieldOperation
+	fieldBucket
+	fieldKey
+	fieldObjectSize
+	fieldServerCostTime
+	fieldErrorCode
+	fieldRequestLength
+	fieldOwner
+	fieldDeltaDataSize
+	fieldSyncRequest
+	fieldStorageClass
+	fieldTargetStorageClass
+	fieldAccelerationAccessPoint
+	fieldAccessKeyID
+	fieldCount
+
+	// The logs have the time and the request at least
+	minFieldCount = fieldStatus + 1
+)
+
+// AccessLogRecord is a request in the access logs, the fields of "-" are zero values
+type AccessLogRecord struct {
+	RemoteIP                string
+	Time                    time.Time
+	Method                  string // The method of the request line, such as GET
+	RequestURI              string // The URI of the request line, such as /key?acl
+	Protocol                string // The protocol of the request line, such as HTTP/1.1
+	Status                  int
+	SentBytes               int64
+	RequestTime             time.Duration // The time to handle the request including sending the response
+	Referer                 string
+	UserAgent               string
+	HostName                string
+	RequestID               string
+	LoggingFlag             bool   // Whether the request is logged by the bucket logging
+	Requester               string // The Aliyun ID of the requester, empty for the anonymous requests
+	Operation               string // The operation, such as GetObject
+	Bucket                  string
+	Key                     string // The object key, which is URL decoded
+	ObjectSize              int64
+	ServerCostTime          time.Duration // The time OSS spent on the request
+	ErrorCode               string
+	RequestLength           int64
+	Owner                   string // The user ID of the bucket owner
+	DeltaDataSize           int64  // The change of the object size
+	SyncRequest             string // The type of the internal request, such as cdn or lifecycle, empty for the user requests
+	StorageClass            string
+	TargetStorageClass      string // The storage class transitioned to, such as by the lifecycle
+	AccelerationAccessPoint string // The access point of the transfer acceleration
+	AccessKeyID             string // The AccessKey ID, it's prefixed by STS. for the STS requests
+	Extra                   []string
+}
+
+// IsSTS checks if the request is signed by the STS credentials
+func (r AccessLogRecord) IsSTS() bool {
+	return strings.HasPrefix(r.AccessKeyID, "STS.")
+}
+
+// IsError checks if the request failed, whose status is 4xx or 5xx
+func (r AccessLogRecord) IsError() bool {
+	return r.Status >= 400
+}
+
+// ParseLine parses a line of the access logs
+//
+// line    the line without the line break.
+//
+// AccessLogRecord    the request.
+// error    it's nil if no error, otherwise it's an error object.
+func ParseLine(line string) (AccessLogRecord, error) {
+	var record AccessLogRecord
+	fields, err := splitFields(line)
+	if err != nil {
+		return record, err
+	}
+	if len(fields) < minFieldCount {
+		return record, fmt.Errorf("accesslog: expect at least %d fields, got %d", minFieldCount, len(fields))
+	}
+
+	p := fieldParser{fields: fields}
+	record.RemoteIP = p.text(fieldRemoteIP)
+	if record.Time, err = time.Parse(TimeFormat, p.text(fieldTime)); err != nil {
+		return record, fmt.Errorf("accesslog: invalid time %s", p.text(fieldTime))
+	}
+	requestLine := strings.SplitN(p.text(fieldRequestURI), " ", 3)
+	switch len(requestLine) {
+	case 3:
+		record.Method, record.RequestURI, record.Protocol = requestLine[0], requestLine[1], requestLine[2]
+	case 2:
+		record.Method, record.RequestURI = requestLine[0], requestLine[1]
+	default:
+		record.RequestURI = requestLine[0]
+	}
+	record.Status = int(p.number(fieldStatus))
+	record.SentBytes = p.number(fieldSentBytes)
+	record.RequestTime = time.Duration(p.number(fieldRequestTime)) * time.Millisecond
+	record.Referer = p.text(fieldReferer)
+	record.UserAgent = p.text(fieldUserAgent)
+	record.HostName = p.text(fieldHostName)
+	record.RequestID = p.text(fieldRequestID)
+	record.LoggingFlag = p.text(fieldLoggingFlag) == "true"
+	record.Requester = p.text(fieldRequester)
+	record.Operation = p.text(fieldOperation)
+	record.Bucket = p.text(fieldBucket)
+	record.Key = unescapeKey(p.text(fieldKey))
+	record.ObjectSize = p.number(fieldObjectSize)
+	record.ServerCostTime = time.Duration(p.number(fieldServerCostTime)) * time.Millisecond
+	record.ErrorCode = p.text(fieldErrorCode)
+	record.RequestLength = p.number(fieldRequestLength)
+	record.Owner = p.text(fieldOwner)
+	record.DeltaDataSize = p.number(fieldDeltaDataSize)
+	record.SyncRequest = p.text(fieldSyncRequest)
+	record.StorageClass = p.text(fieldStorageClass)
+	record.TargetStorageClass = p.text(fieldTargetStorageClass)
+	record.AccelerationAccessPoint = p.text(fieldAccelerationAccessPoint)
+	record.AccessKeyID = p.text(fieldAccessKeyID)
+	if len(fields) > fieldCount {
+		record.Extra = fields[fieldCount:]
+	}
+	if p.err != nil {
+		return record, p.err
+	}
+	return record, nil
+}
+
+type fieldParser struct {
+	fields []string
+	err    error
+}
+
+// text returns the field, empty for - or the missing field
+func (p *fieldParser) text(i int) string {
+	if i >= len(p.fields) || p.fields[i] == "-" {
+		return ""
+	}
+	return p.fields[i]
+}
+
+// number returns the integer field, 0 for - or the missing field
+func (p *fieldParser) number(i int) int64 {
+	value := p.text(i)
+	if value == "" {
+		return 0
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil && p.err == nil {
+		p.err = fmt.Errorf("accesslog: invalid number %s at field %d", value, i+1)
+	}
+	return n
+}
+
+// splitFields splits the line by spaces, the fields quoted by "" or [] may contain spaces
+func splitFields(line string) ([]string, error) {
+	var fields []string
+	for i := 0; i < len(line); {
+		switch line[i] {
+		case ' ':
+			i++
+		case '"':
+			var field []byte
+			j := i + 1
+			for ; j < len(line) && line[j] != '"'; j++ {
+				// The quotes in the values are escaped
+				if line[j] == '\\' && j+1 < len(line) {
+					j++
+				}
+				field = append(field, line[j])
+			}
+			if j >= len(line) {
+				return nil, fmt.Errorf("accesslog: unterminated quote at %d", i)
+			}
+			fields = append(fields, string(field))
+			i = j + 1
+		case '[':
+			j := strings.IndexByte(line[i:], ']')
+			if j == -1 {
+				return nil, fmt.Errorf("accesslog: unterminated bracket at %d", i)
+			}
+			fields = append(fields, line[i+1:i+j])
+			i += j + 1
+		default:
+			j := strings.IndexByte(line[i:], ' ')
+			if j == -1 {
+				j = len(line) - i
+			}
+			fields = append(fields, line[i:i+j])
+			i += j
+		}
+	}
+	return fields, nil
+}
+
+// unescapeKey decodes the URL encoded key, + is kept since the spaces are encoded as %20
+func unescapeKey(key string) string {
+	decoded, err := oss.UnescapeObjectKey(key)
+	if err != nil {
+		return key
+	}
+	return decoded
+}
@@ -0,0 +1,169 @@
+package accesslog
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Filter checks if a record is wanted
+type Filter func(AccessLogRecord) bool
+
+// Match checks if the record matches all the filters
+func Match(record AccessLogRecord, filters ...Filter) bool {
+	for _, filter := range filters {
+		if filter != nil && !filter(record) {
+			return false
+		}
+	}
+	return true
+}
+
+// ByTime matches the records in [start, end), the zero time means no limit
+func ByTime(start, end time.Time) Filter {
+	return func(r AccessLogRecord) bool {
+		return (start.IsZero() || !r.Time.Before(start)) && (end.IsZero() || r.Time.Before(end))
+	}
+}
+
+// ByOperation matches the records of the operations, such as GetObject
+func ByOperation(operations ...string) Filter {
+	return func(r AccessLogRecord) bool {
+		for _, operation := range operations {
+			if strings.EqualFold(r.Operation, operation) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// ByStatus matches the records whose status is in [min, max]
+func ByStatus(min, max int) Filter {
+	return func(r AccessLogRecord) bool {
+		return r.Status >= min && r.Status <= max
+	}
+}
+
+// ByKeyPrefix matches the records of the objects with the prefix
+func ByKeyPrefix(prefix string) Filter {
+	return func(r AccessLogRecord) bool {
+		return r.Key != "" && strings.HasPrefix(r.Key, prefix)
+	}
+}
+
+// ByRemoteIP matches the records from the IPs
+func ByRemoteIP(ips ...string) Filter {
+	return func(r AccessLogRecord) bool {
+		for _, ip := range ips {
+			if r.RemoteIP == ip {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// UserRequests matches the requests of the users, the internal requests such as cdn and lifecycle are skipped
+func UserRequests() Filter {
+	return func(r AccessLogRecord) bool {
+		return r.SyncRequest == ""
+	}
+}
+
+// Summary aggregates the records
+type Summary struct {
+	Requests       int64
+	Errors         int64 // The requests of 4xx and 5xx
+	SentBytes      int64
+	RequestLength  int64
+	ServerCostTime time.Duration // The total server cost time
+	First          time.Time     // The time of the first request
+	Last           time.Time     // The time of the last request
+	ByStatus       map[int]int64
+	ByOperation    map[string]int64
+	ByErrorCode    map[string]int64
+}
+
+// NewSummary creates an empty summary
+func NewSummary() *Summary {
+	return &Summary{ByStatus: map[int]int64{}, ByOperation: map[string]int64{}, ByErrorCode: map[string]int64{}}
+}
+
+// Add aggregates the record
+func (s *Summary) Add(r AccessLogRecord) {
+	s.Requests++
+	if r.IsError() {
+		s.Errors++
+	}
+	s.SentBytes += r.SentBytes
+	s.RequestLength += r.RequestLength
+	s.ServerCostTime += r.ServerCostTime
+	if s.First.IsZero() || r.Time.Before(s.First) {
+		s.First = r.Time
+	}
+	if r.Time.After(s.Last) {
+		s.Last = r.Time
+	}
+	s.ByStatus[r.Status]++
+	s.ByOperation[r.Operation]++
+	if r.ErrorCode != "" {
+		s.ByErrorCode[r.ErrorCode]++
+	}
+}
+
+// AverageServerCostTime returns the average server cost time of the requests
+func (s *Summary) AverageServerCostTime() time.Duration {
+	if s.Requests == 0 {
+		return 0
+	}
+	return s.ServerCostTime / time.Duration(s.Requests)
+}
+
+// Count is the count of a key
+type Count struct {
+	Key   string
+	Count int64
+}
+
+// Counter counts the records by a key, such as the remote IP or the object key
+type Counter struct {
+	key    func(AccessLogRecord) string
+	Counts map[string]int64
+}
+
+// NewCounter creates a counter by the key function, the records of the empty keys are not counted
+func NewCounter(key func(AccessLogRecord) string) *Counter {
+	return &Counter{key: key, Counts: map[string]int64{}}
+}
+
+// Add counts the record
+func (c *Counter) Add(r AccessLogRecord) {
+	if key := c.key(r); key != "" {
+		c.Counts[key]++
+	}
+}
+
+// Top returns the n keys of the largest counts, the keys of the same count are sorted. n <= 0 returns all.
+func (c *Counter) Top(n int) []Count {
+	counts := make([]Count, 0, len(c.Counts))
+	for key, count := range c.Counts {
+		counts = append(counts, Count{Key: key, Count: count})
+	}
+	sort.Sort(countsByCount(counts))
+	if n > 0 && n < len(counts) {
+		counts = counts[:n]
+	}
+	return counts
+}
+
+type countsByCount []Count
+
+func (c countsByCount) Len() int      { return len(c) }
+func (c countsByCount) Swap(i, j int) { c[i], c[j] = c[j], c[i] }
+func (c countsByCount) Less(i, j int) bool {
+	if c[i].Count != c[j].Count {
+		return c[i].Count > c[j].Count
+	}
+	return c[i].Key < c[j].Key
+}
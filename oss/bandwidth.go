@@ -0,0 +1,125 @@
+package oss
+
+import (
+	"context"
+)
+
+// BandwidthLimiter limits the bandwidth of the transfers. It could be shared by the clients and the transfers, such as
+// a global cap of the process, and it could be combined with another by CombineLimiters. OssLimiter is the token
+// bucket implementation, its limit could be changed while the transfers run.
+type BandwidthLimiter interface {
+	// WaitN blocks until n bytes are allowed to transfer, or the context is done
+	WaitN(ctx context.Context, n int) error
+}
+
+// combinedLimiter waits for all the limiters
+type combinedLimiter []BandwidthLimiter
+
+func (limiters combinedLimiter) WaitN(ctx context.Context, n int) error {
+	for _, limiter := range limiters {
+		if err := limiter.WaitN(ctx, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CombineLimiters combines the limiters hierarchically, the bytes are transferred when all the limiters allow them,
+// such as a global cap plus a per transfer cap. The nil limiters are ignored.
+//
+// limiters    the limiters, such as the limiter shared by the clients and the limiter of a transfer.
+//
+// BandwidthLimiter    the combined limiter, it's nil if all the limiters are nil.
+func CombineLimiters(limiters ...BandwidthLimiter) BandwidthLimiter {
+	var combined combinedLimiter
+	for _, limiter := range limiters {
+		if limiter != nil {
+			combined = append(combined, limiter)
+		}
+	}
+	switch len(combined) {
+	case 0:
+		return nil
+	case 1:
+		return combined[0]
+	}
+	return combined
+}
+
+type uploadLimiterKey struct{}
+type downloadLimiterKey struct{}
+
+// UploadLimiter is an option to limit the upload bandwidth of the operation, such as PutObject, UploadPart and
+// UploadFile, it overrides Config.UploadBandwidth. The limiter is shared by the parts of UploadFile.
+func UploadLimiter(limiter BandwidthLimiter) Option {
+	return addArg(uploadLimiterArg, limiter)
+}
+
+// DownloadLimiter is an option to limit the download bandwidth of the operation, such as GetObject and DownloadFile,
+// it overrides Config.DownloadBandwidth. The limiter is shared by the parts of DownloadFile.
+func DownloadLimiter(limiter BandwidthLimiter) Option {
+	return addArg(downloadLimiterArg, limiter)
+}
+
+// withLimiters passes the limiters of the options to the connection by the context
+func withLimiters(ctx context.Context, options []Option) context.Context {
+	upload, _ := FindOption(options, uploadLimiterArg, nil)
+	download, _ := FindOption(options, downloadLimiterArg, nil)
+	if upload == nil && download == nil {
+		return ctx
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if upload != nil {
+		ctx = context.WithValue(ctx, uploadLimiterKey{}, upload)
+	}
+	if download != nil {
+		ctx = context.WithValue(ctx, downloadLimiterKey{}, download)
+	}
+	return ctx
+}
+
+// uploadLimiter returns the limiter of the upload request, the limiter of the operation overrides the client's
+func (conn Conn) uploadLimiter(ctx context.Context) BandwidthLimiter {
+	if limiter, ok := ctx.Value(uploadLimiterKey{}).(BandwidthLimiter); ok {
+		return limiter
+	}
+	if conn.config.UploadBandwidth != nil {
+		return conn.config.UploadBandwidth
+	}
+	if limiter := conn.config.speedLimiter(true); limiter != nil {
+		return limiter
+	}
+	return nil
+}
+
+// downloadLimiter returns the limiter of the download response, the limiter of the operation overrides the client's
+func (conn Conn) downloadLimiter(ctx context.Context) BandwidthLimiter {
+	if limiter, ok := ctx.Value(downloadLimiterKey{}).(BandwidthLimiter); ok {
+		return limiter
+	}
+	if conn.config.DownloadBandwidth != nil {
+		return conn.config.DownloadBandwidth
+	}
+	if limiter := conn.config.speedLimiter(false); limiter != nil {
+		return limiter
+	}
+	return nil
+}
+
+// speedLimiter returns the limiter of LimitUploadSpeed or LimitDownloadSpeed, it's nil if the speed is 0. The limiters
+// are changed in place, so they're read under the lock while the speeds are changed.
+func (config *Config) speedLimiter(upload bool) *OssLimiter {
+	if config.limitMu != nil {
+		config.limitMu.RLock()
+		defer config.limitMu.RUnlock()
+	}
+	if upload && config.UploadLimitSpeed != 0 {
+		return config.UploadLimiter
+	}
+	if !upload && config.DownloadLimitSpeed != 0 {
+		return config.DownloadLimiter
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package oss
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type OssBandwidthSuite struct{}
+
+var _ = Suite(&OssBandwidthSuite{})
+
+// countingLimiter counts the bytes waited for
+type countingLimiter struct {
+	mu    sync.Mutex
+	bytes int
+}
+
+func (l *countingLimiter) WaitN(ctx context.Context, n int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.bytes += n
+	return nil
+}
+
+func (l *countingLimiter) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.bytes
+}
+
+func newBandwidthServer(c *C, data []byte) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PUT" {
+			body, err := ioutil.ReadAll(r.Body)
+			c.Assert(err, IsNil)
+			c.Assert(len(body), Equals, len(data))
+			return
+		}
+		w.Write(data)
+	}))
+}
+
+func (s *OssBandwidthSuite) TestLimiters(c *C) {
+	data := bytes.Repeat([]byte("0123456789abcdef"), 4096)
+	server := newBandwidthServer(c, data)
+	defer server.Close()
+
+	// The client limiters are shared by the clients
+	upload, download := &countingLimiter{}, &countingLimiter{}
+	for i := 0; i < 2; i++ {
+		client, err := New(server.URL, "ak", "sk", BandwidthLimiters(upload, download))
+		c.Assert(err, IsNil)
+		bucket, err := client.Bucket("bucket")
+		c.Assert(err, IsNil)
+		c.Assert(bucket.PutObject("a", bytes.NewReader(data)), IsNil)
+		body, err := bucket.GetObject("a")
+		c.Assert(err, IsNil)
+		_, err = ioutil.ReadAll(body)
+		c.Assert(err, IsNil)
+		body.Close()
+	}
+	c.Assert(upload.count(), Equals, 2*len(data))
+	c.Assert(download.count(), Equals, 2*len(data))
+
+	// The limiter of the operation overrides the client's, and it's combined with the global limiter
+	client, err := New(server.URL, "ak", "sk", BandwidthLimiters(upload, download))
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("bucket")
+	c.Assert(err, IsNil)
+	global, transfer := &countingLimiter{}, &countingLimiter{}
+	c.Assert(bucket.PutObject("a", bytes.NewReader(data), UploadLimiter(CombineLimiters(global, transfer, nil))), IsNil)
+	c.Assert(global.count(), Equals, len(data))
+	c.Assert(transfer.count(), Equals, len(data))
+	c.Assert(upload.count(), Equals, 2*len(data))
+
+	transfer = &countingLimiter{}
+	body, err := bucket.GetObject("a", DownloadLimiter(transfer), Range(0, 99))
+	c.Assert(err, IsNil)
+	_, err = ioutil.ReadAll(body)
+	c.Assert(err, IsNil)
+	body.Close()
+	c.Assert(transfer.count(), Equals, len(data))
+	c.Assert(download.count(), Equals, 2*len(data))
+
+	c.Assert(CombineLimiters(nil, nil), IsNil)
+	c.Assert(CombineLimiters(nil, global), Equals, global)
+}
+
+func (s *OssBandwidthSuite) TestSetLimit(c *C) {
+	data := bytes.Repeat([]byte("0123456789abcdef"), 4096)
+	server := newBandwidthServer(c, data)
+	defer server.Close()
+
+	// 64KB takes 16s at 4KB/s, the upload finishes soon after the limit is removed
+	limiter := NewBandwidthLimiter(4)
+	c.Assert(limiter.Limit(), Equals, 4)
+	client, err := New(server.URL, "ak", "sk", BandwidthLimiters(limiter, nil))
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("bucket")
+	c.Assert(err, IsNil)
+	start := time.Now()
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		limiter.SetLimit(0)
+	}()
+	c.Assert(bucket.PutObject("a", bytes.NewReader(data)), IsNil)
+	c.Assert(time.Since(start) < 2*time.Second, Equals, true)
+	c.Assert(limiter.Limit(), Equals, 0)
+
+	// The waiting is canceled by the context
+	limiter.SetLimit(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	c.Assert(limiter.WaitN(ctx, 10*1024), NotNil)
+
+	// The speed of the config is changed in place
+	config := getDefaultOssConfig()
+	c.Assert(config.LimitUploadSpeed(100), IsNil)
+	ossLimiter := config.UploadLimiter
+	c.Assert(config.LimitUploadSpeed(200), IsNil)
+	c.Assert(config.UploadLimiter == ossLimiter, Equals, true)
+	c.Assert(ossLimiter.Limit(), Equals, 200)
+	c.Assert(config.LimitUploadSpeed(0), IsNil)
+	c.Assert(config.UploadLimiter == ossLimiter, Equals, true)
+	c.Assert(config.UploadLimitSpeed, Equals, 0)
+	c.Assert(ossLimiter.Limit(), Equals, 0)
+	c.Assert(config.LimitUploadSpeed(300), IsNil)
+	c.Assert(config.UploadLimiter == ossLimiter, Equals, true)
+	c.Assert(ossLimiter.Limit(), Equals, 300)
+
+	// The speeds are changed while the transfers run
+	client, err = New(server.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+	bucket, err = client.Bucket("bucket")
+	c.Assert(err, IsNil)
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 100; i++ {
+			client.Config.LimitUploadSpeed(i % 2 * 100000)
+			client.Config.LimitDownloadSpeed(i % 2 * 100000)
+		}
+		done <- true
+	}()
+	for i := 0; i < 5; i++ {
+		c.Assert(bucket.PutObject("a", bytes.NewReader(data)), IsNil)
+		body, err := bucket.GetObject("a")
+		c.Assert(err, IsNil)
+		_, err = ioutil.ReadAll(body)
+		c.Assert(err, IsNil)
+		body.Close()
+	}
+	<-done
+}
@@ -16,7 +16,6 @@ import (
 	"os"
 	"strconv"
 	"strings"
-	"time"
 )
 
 // Bucket implements the operations of object.
@@ -671,6 +670,76 @@ func (bucket Bucket) SetObjectMeta(objectKey string, options ...Option) error {
 	return err
 }
 
+// UpdateObjectMeta updates the metadata of the object with merge semantics.
+//
+// Unlike SetObjectMeta, the existing user metadata and standard headers which are not mentioned in the patch are kept.
+// The object ACL, storage class, server side encryption and tagging are preserved as well. The copy is conditioned
+// on the ETag read before the update, so a concurrent overwrite makes the call fail with PreconditionFailed instead of
+// losing data. Objects larger than the single CopyObject limit (1GB) are updated with a multipart copy.
+//
+// objectKey    the object key.
+// patch    the changes of user metadata and standard headers.
+// options    the options for the update. The valid options are VersionId, RequestPayer, Routines and WithContext.
+//
+// error    it's nil if no error, otherwise it's an error object.
+func (bucket Bucket) UpdateObjectMeta(objectKey string, patch ObjectMetaPatch, options ...Option) error {
+	headOptions := ChoiceHeadObjectOption(options)
+	meta, err := bucket.headObject(objectKey, headOptions...)
+	if err != nil {
+		return err
+	}
+
+	patchOptions, err := patch.apply(meta)
+	if err != nil {
+		return err
+	}
+
+	aclResult, err := bucket.GetObjectACL(objectKey, headOptions...)
+	if err != nil {
+		return err
+	}
+
+	opts := append([]Option{}, options...)
+	opts = append(opts, patchOptions...)
+	if aclResult.ACL != "" && aclResult.ACL != string(ACLDefault) {
+		opts = append(opts, ObjectACL(ACLType(aclResult.ACL)))
+	}
+	if meta.StorageClass != "" {
+		opts = append(opts, ObjectStorageClass(StorageClassType(meta.StorageClass)))
+	}
+	if meta.ServerSideEncryption != "" {
+		opts = append(opts, ServerSideEncryption(meta.ServerSideEncryption))
+		if meta.ServerSideEncryptionKeyID != "" {
+			opts = append(opts, ServerSideEncryptionKeyID(meta.ServerSideEncryptionKeyID))
+		}
+		if meta.ServerSideDataEncryption != "" {
+			opts = append(opts, ServerSideDataEncryption(meta.ServerSideDataEncryption))
+		}
+	}
+	opts = append(opts, CopySourceIfMatch(meta.ETag))
+
+	if meta.ContentLength <= maxCopyObjectSize {
+		opts = append(opts, MetadataDirective(MetaReplace), TaggingDirective(TaggingCopy))
+		_, err = bucket.CopyObject(objectKey, objectKey, opts...)
+		return err
+	}
+
+	// The multipart copy does not support the tagging directive, carry the tags explicitly
+	if meta.TaggingCount > 0 {
+		tagging, err := bucket.GetObjectTagging(objectKey, headOptions...)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, SetTagging(Tagging{Tags: tagging.Tags}))
+	}
+
+	partSize := int64(defaultCopyPartSize)
+	if meta.ContentLength/partSize >= maxPartCount {
+		partSize = (meta.ContentLength + maxPartCount - 1) / maxPartCount
+	}
+	return bucket.CopyFile(bucket.BucketName, objectKey, objectKey, partSize, opts...)
+}
+
 // GetObjectDetailedMeta gets the object's detailed metadata
 //
 // objectKey    object key.
@@ -713,6 +782,30 @@ func (bucket Bucket) GetObjectMeta(objectKey string, options ...Option) (http.He
 	return resp.Headers, nil
 }
 
+// HeadObject gets the object's detailed metadata and parses it into ObjectMeta.
+//
+// objectKey    object key.
+// options    the constraints of the object, same as the options in GetObjectDetailedMeta.
+//
+// *ObjectMeta    the parsed object metadata, the raw headers are kept in ObjectMeta.Headers.
+// error    it's nil if no error, otherwise it's an error object.
+func (bucket Bucket) HeadObject(objectKey string, options ...Option) (*ObjectMeta, error) {
+	header, err := bucket.GetObjectDetailedMeta(objectKey, options...)
+	if err != nil {
+		return nil, err
+	}
+	return parseObjectMeta(header, true)
+}
+
+// headObject gets the object's metadata for the transfers, the invalid optional headers are ignored
+func (bucket Bucket) headObject(objectKey string, options ...Option) (*ObjectMeta, error) {
+	header, err := bucket.GetObjectDetailedMeta(objectKey, options...)
+	if err != nil {
+		return nil, err
+	}
+	return parseObjectMeta(header, false)
+}
+
 // SetObjectACL updates the object's ACL.
 //
 // Only the bucket's owner could update object's ACL which priority is higher than bucket's ACL.
@@ -900,7 +993,7 @@ func (bucket Bucket) SignURL(objectKey string, method HTTPMethod, expiredInSec i
 	if expiredInSec < 0 {
 		return "", fmt.Errorf("invalid expires: %d, expires must bigger than 0", expiredInSec)
 	}
-	expiration := time.Now().Unix() + expiredInSec
+	expiration := bucket.Client.Conn.now().Unix() + expiredInSec
 
 	params, err := GetRawParams(options)
 	if err != nil {
@@ -913,7 +1006,20 @@ func (bucket Bucket) SignURL(objectKey string, method HTTPMethod, expiredInSec i
 		return "", err
 	}
 
-	return bucket.Client.Conn.signURL(method, bucket.BucketName, objectKey, expiration, params, headers)
+	conn := bucket.Client.Conn
+	if endpoint, _ := FindOption(options, signEndpoint, nil); endpoint != nil {
+		// The URL targets the endpoint, the request is signed in the same way
+		config := bucket.GetConfig()
+		um := &urlMaker{}
+		err = um.InitExt(endpoint.(string), config.IsCname, config.IsUseProxy, config.IsPathStyle)
+		if err != nil {
+			return "", err
+		}
+		signConn := *conn
+		signConn.url = um
+		conn = &signConn
+	}
+	return conn.signURL(method, bucket.BucketName, objectKey, expiration, params, headers)
 }
 
 // PutObjectWithURL uploads an object with the URL. If the object exists, it will be overwritten.
@@ -1244,9 +1350,17 @@ func (bucket Bucket) doInner(method, objectName string, params map[string]interf
 
 	ctxArg, _ := FindOption(options, contextArg, nil)
 	ctx, _ := ctxArg.(context.Context)
+	ctx = withLimiters(ctx, options)
 
-	resp, err := bucket.Client.Conn.DoWithContext(ctx, method, bucket.BucketName, objectName,
-		params, headers, data, 0, listener)
+	var resp *Response
+	hedge, _ := FindOption(options, hedgeArg, nil)
+	if hedge != nil && data == nil && (method == "GET" || method == "HEAD") {
+		resp, err = bucket.Client.Conn.doHedged(ctx, hedge.(hedgeConfig), method, bucket.BucketName, objectName,
+			params, headers, listener)
+	} else {
+		resp, err = bucket.Client.Conn.DoWithContext(ctx, method, bucket.BucketName, objectName,
+			params, headers, data, 0, listener)
+	}
 
 	// get response header
 	respHeader, _ := FindOption(options, responseHeader, nil)
@@ -1282,6 +1396,7 @@ func (bucket Bucket) doURL(method HTTPMethod, signedURL string, params map[strin
 
 	ctxArg, _ := FindOption(options, contextArg, nil)
 	ctx, _ := ctxArg.(context.Context)
+	ctx = withLimiters(ctx, options)
 
 	resp, err := bucket.Client.Conn.DoURLWithContext(ctx, method, signedURL, headers, data, 0, listener)
 
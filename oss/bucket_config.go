@@ -0,0 +1,731 @@
+package oss
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// BucketConfigVersion is the version of the BucketConfig document written by ExportBucketConfig
+const BucketConfigVersion = 1
+
+const (
+	bucketSkipSections = "x-bucket-skip-sections"
+	bucketRenames      = "x-bucket-renames"
+	bucketLockWorm     = "x-bucket-lock-worm"
+)
+
+// BucketConfig is the configuration of a bucket exported by ExportBucketConfig, it's written as JSON or YAML.
+// The sections not configured are omitted, and they're not changed by ImportBucketConfig.
+type BucketConfig struct {
+	Version              int                      // The version of the document, BucketConfigVersion
+	Bucket               string                   // The bucket exported
+	ACL                  *ACLType                 `json:",omitempty"`
+	Versioning           *string                  `json:",omitempty"`
+	AccessMonitor        *bool                    `json:",omitempty"`
+	TransferAcceleration *bool                    `json:",omitempty"`
+	Encryption           *SSEDefaultRule          `json:",omitempty"`
+	Tags                 []Tag                    `json:",omitempty"`
+	Logging              *LoggingEnabled          `json:",omitempty"`
+	Referer              *RefererXML              `json:",omitempty"`
+	CORS                 *CORSXML                 `json:",omitempty"`
+	Website              *WebsiteXML              `json:",omitempty"`
+	ResponseHeader       *ResponseHeaderXml       `json:",omitempty"`
+	Inventories          []InventoryConfiguration `json:",omitempty"`
+	Lifecycle            *LifecycleConfiguration  `json:",omitempty"`
+	Policy               *PolicyDocument          `json:",omitempty"`
+	QoS                  *BucketQoSConfiguration  `json:",omitempty"`
+	Replication          []ReplicationRule        `json:",omitempty"` // The rules without the status and the progress
+	Cnames               []string                 `json:",omitempty"` // The domains, the certificates are not exported
+	Styles               []BucketStyleXml         `json:",omitempty"` // The names and the contents of the styles
+	ResourceGroup        *string                  `json:",omitempty"`
+	RequestPayment       *string                  `json:",omitempty"` // The payer
+	Worm                 *WormConfiguration       `json:",omitempty"` // The state and the retention days of the WORM
+}
+
+// SkipBucketSections is an option of ExportBucketConfig and ImportBucketConfig to skip the sections, such as BucketSectionPolicy
+func SkipBucketSections(sections ...string) Option {
+	return func(params map[string]optionValue) error {
+		skipped, _ := params[bucketSkipSections].Value.(map[string]bool)
+		if skipped == nil {
+			skipped = map[string]bool{}
+		}
+		for _, section := range sections {
+			skipped[section] = true
+		}
+		params[bucketSkipSections] = optionValue{skipped, optionArg}
+		return nil
+	}
+}
+
+// RenameBucket is an option of ImportBucketConfig to replace the bucket name in the policy resources, the inventory,
+// replication and logging destinations. The exported bucket is renamed to the imported bucket by default.
+func RenameBucket(oldName, newName string) Option {
+	return func(params map[string]optionValue) error {
+		renames, _ := params[bucketRenames].Value.(map[string]string)
+		if renames == nil {
+			renames = map[string]string{}
+		}
+		renames[oldName] = newName
+		params[bucketRenames] = optionValue{renames, optionArg}
+		return nil
+	}
+}
+
+// LockWorm is an option of ImportBucketConfig to lock the WORM if it's locked in the document. The WORM is only
+// initiated by default, since a locked WORM could not be undone.
+func LockWorm(lock bool) Option {
+	return addArg(bucketLockWorm, lock)
+}
+
+// ExportBucketConfig exports all the readable configurations of the bucket into a document.
+// The configurations not set, whose getters return 404 errors such as NoSuchLifecycle, are omitted.
+//
+// bucketName    the bucket name.
+// options    the options such as SkipBucketSections.
+//
+// BucketConfig    the configuration document.
+// error    it's nil if no error, otherwise it's an error object.
+func (client Client) ExportBucketConfig(bucketName string, options ...Option) (BucketConfig, error) {
+	skipped, err := bucketSkippedSections(options)
+	if err != nil {
+		return BucketConfig{}, err
+	}
+	config := BucketConfig{Version: BucketConfigVersion, Bucket: bucketName}
+	exporters := []struct {
+		section string
+		export  func(Client, string, *BucketConfig, []Option) error
+	}{
+		{BucketSectionACL, exportBucketACL},
+		{BucketSectionVersioning, exportBucketVersioning},
+		{BucketSectionAccessMonitor, exportBucketAccessMonitor},
+		{BucketSectionTransferAcceleration, exportBucketTransferAcceleration},
+		{BucketSectionEncryption, exportBucketEncryption},
+		{BucketSectionTags, exportBucketTags},
+		{BucketSectionLogging, exportBucketLogging},
+		{BucketSectionReferer, exportBucketReferer},
+		{BucketSectionCORS, exportBucketCORS},
+		{BucketSectionWebsite, exportBucketWebsite},
+		{BucketSectionResponseHeader, exportBucketResponseHeader},
+		{BucketSectionInventory, exportBucketInventories},
+		{BucketSectionLifecycle, exportBucketLifecycle},
+		{BucketSectionPolicy, exportBucketPolicy},
+		{BucketSectionQoS, exportBucketQoS},
+		{BucketSectionReplication, exportBucketReplication},
+		{BucketSectionCname, exportBucketCnames},
+		{BucketSectionStyle, exportBucketStyles},
+		{BucketSectionResourceGroup, exportBucketResourceGroup},
+		{BucketSectionRequestPayment, exportBucketRequestPayment},
+		{BucketSectionWorm, exportBucketWorm},
+	}
+	for _, exporter := range exporters {
+		if skipped[exporter.section] {
+			continue
+		}
+		if err := exporter.export(client, bucketName, &config, options); err != nil {
+			if isNotConfigured(err) {
+				continue
+			}
+			return BucketConfig{}, fmt.Errorf("oss: export bucket section %s failed: %s", exporter.section, err.Error())
+		}
+	}
+	return config, nil
+}
+
+// ImportBucketConfig applies the configuration document to the bucket, which may be in another region.
+// The sections of BucketSpec are planned by PlanBucket and only the changed ones are set, the replication rules, the
+// CNAMEs and the WORM which already exist are skipped, and the others such as the styles are put as they're. The CNAMEs
+// are added last since they require the ownership of the domains to be verified. The WORM is only initiated unless
+// LockWorm(true) is set.
+//
+// bucketName    the bucket name.
+// config    the configuration document.
+// options    the options such as SkipBucketSections, RenameBucket and LockWorm.
+//
+// BucketPlan    the changes applied, or planned if the error is *BucketApplyError.
+// error    it's nil if no error, otherwise it's an error object.
+func (client Client) ImportBucketConfig(bucketName string, config BucketConfig, options ...Option) (BucketPlan, error) {
+	if config.Version > BucketConfigVersion {
+		return BucketPlan{}, fmt.Errorf("oss: unsupported bucket config version %d", config.Version)
+	}
+	skipped, err := bucketSkippedSections(options)
+	if err != nil {
+		return BucketPlan{}, err
+	}
+	renames := map[string]string{}
+	if config.Bucket != "" {
+		renames[config.Bucket] = bucketName
+	}
+	value, err := FindOption(options, bucketRenames, nil)
+	if err != nil {
+		return BucketPlan{}, err
+	}
+	if value != nil {
+		for oldName, newName := range value.(map[string]string) {
+			renames[oldName] = newName
+		}
+	}
+
+	config, err = config.clone()
+	if err != nil {
+		return BucketPlan{}, err
+	}
+	config.renameBuckets(renames)
+
+	plan, err := client.PlanBucket(bucketName, config.spec(skipped), options...)
+	if err != nil {
+		return plan, err
+	}
+	changes, err := config.extraChanges(client, bucketName, skipped, options)
+	if err != nil {
+		return plan, err
+	}
+	plan.Changes = append(plan.Changes, changes...)
+	sort.Stable(bucketChangesByOrder(plan.Changes))
+	return plan, client.ApplyBucketPlan(plan, options...)
+}
+
+func bucketSkippedSections(options []Option) (map[string]bool, error) {
+	value, err := FindOption(options, bucketSkipSections, nil)
+	if err != nil || value == nil {
+		return map[string]bool{}, err
+	}
+	return value.(map[string]bool), nil
+}
+
+// JSON writes the document as the indented JSON
+func (config BucketConfig) JSON() ([]byte, error) {
+	node, err := config.node()
+	if err != nil {
+		return nil, err
+	}
+	var sb strings.Builder
+	node.writeJSON(&sb, "")
+	sb.WriteString("\n")
+	return []byte(sb.String()), nil
+}
+
+// YAML writes the document as YAML, the strings are double quoted
+func (config BucketConfig) YAML() ([]byte, error) {
+	node, err := config.node()
+	if err != nil {
+		return nil, err
+	}
+	var sb strings.Builder
+	node.writeYAML(&sb, 0, false)
+	return []byte(sb.String()), nil
+}
+
+// ParseBucketConfig parses the document written by BucketConfig.JSON or BucketConfig.YAML.
+// Only the YAML written by BucketConfig.YAML is supported: the block mappings and sequences, the flow collections in
+// JSON and the plain or quoted scalars. The other YAML features, such as the anchors, the block scalars and the flow
+// collections not in JSON, are rejected, so the documents written by hand or by other tools should be JSON.
+//
+// data    the JSON or YAML document.
+//
+// BucketConfig    the configuration document.
+// error    it's nil if no error, otherwise it's an error object.
+func ParseBucketConfig(data []byte) (BucketConfig, error) {
+	var config BucketConfig
+	text := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(text, "{") {
+		node, err := parseYAMLNode(text)
+		if err != nil {
+			return config, err
+		}
+		var sb strings.Builder
+		node.writeJSON(&sb, "")
+		text = sb.String()
+	}
+	if err := json.Unmarshal([]byte(text), &config); err != nil {
+		return config, fmt.Errorf("oss: invalid bucket config: %s", err.Error())
+	}
+	if config.Version > BucketConfigVersion {
+		return config, fmt.Errorf("oss: unsupported bucket config version %d", config.Version)
+	}
+	return config, nil
+}
+
+// node converts the document to the tree in the order of the fields without the XMLName fields
+func (config BucketConfig) node() (*docNode, error) {
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	return decodeDocNode(data, true)
+}
+
+func (config BucketConfig) clone() (BucketConfig, error) {
+	var out BucketConfig
+	data, err := json.Marshal(config)
+	if err != nil {
+		return out, err
+	}
+	err = json.Unmarshal(data, &out)
+	return out, err
+}
+
+// renameBuckets replaces the bucket names by the renames
+func (config *BucketConfig) renameBuckets(renames map[string]string) {
+	if config.Logging != nil {
+		if name, ok := renames[config.Logging.TargetBucket]; ok {
+			config.Logging.TargetBucket = name
+		}
+	}
+	for i := range config.Inventories {
+		destination := &config.Inventories[i].OSSBucketDestination
+		destination.Bucket = renameBucketInARN(destination.Bucket, renames)
+	}
+	for i := range config.Replication {
+		destination := config.Replication[i].Destination
+		if destination == nil {
+			continue
+		}
+		if name, ok := renames[destination.Bucket]; ok {
+			destination.Bucket = name
+		}
+	}
+	if config.Policy != nil {
+		for i := range config.Policy.Statement {
+			statement := &config.Policy.Statement[i]
+			for _, resources := range [][]string{statement.Resource, statement.NotResource} {
+				for j := range resources {
+					resources[j] = renameBucketInARN(resources[j], renames)
+				}
+			}
+		}
+	}
+}
+
+// renameBucketInARN replaces the bucket of the resource such as acs:oss:*:*:bucket/key or acs:oss:::bucket
+func renameBucketInARN(arn string, renames map[string]string) string {
+	parts := strings.SplitN(arn, ":", 5)
+	if len(parts) != 5 || parts[0] != "acs" || parts[1] != "oss" {
+		return arn
+	}
+	bucket, rest := parts[4], ""
+	if pos := strings.Index(bucket, "/"); pos != -1 {
+		bucket, rest = bucket[:pos], bucket[pos:]
+	}
+	if name, ok := renames[bucket]; ok {
+		parts[4] = name + rest
+	}
+	return strings.Join(parts, ":")
+}
+
+// spec converts the sections of BucketSpec which are not skipped
+func (config BucketConfig) spec(skipped map[string]bool) BucketSpec {
+	spec := BucketSpec{
+		ACL:                  config.ACL,
+		Versioning:           config.Versioning,
+		AccessMonitor:        config.AccessMonitor,
+		TransferAcceleration: config.TransferAcceleration,
+		Encryption:           config.Encryption,
+		Tags:                 config.Tags,
+		Logging:              config.Logging,
+		Referer:              config.Referer,
+		CORS:                 config.CORS,
+		Website:              config.Website,
+		ResponseHeader:       config.ResponseHeader,
+		Inventories:          config.Inventories,
+		Lifecycle:            config.Lifecycle,
+		Policy:               config.Policy,
+	}
+	for section, clear := range map[string]func(){
+		BucketSectionACL:                  func() { spec.ACL = nil },
+		BucketSectionVersioning:           func() { spec.Versioning = nil },
+		BucketSectionAccessMonitor:        func() { spec.AccessMonitor = nil },
+		BucketSectionTransferAcceleration: func() { spec.TransferAcceleration = nil },
+		BucketSectionEncryption:           func() { spec.Encryption = nil },
+		BucketSectionTags:                 func() { spec.Tags = nil },
+		BucketSectionLogging:              func() { spec.Logging = nil },
+		BucketSectionReferer:              func() { spec.Referer = nil },
+		BucketSectionCORS:                 func() { spec.CORS = nil },
+		BucketSectionWebsite:              func() { spec.Website = nil },
+		BucketSectionResponseHeader:       func() { spec.ResponseHeader = nil },
+		BucketSectionInventory:            func() { spec.Inventories = nil },
+		BucketSectionLifecycle:            func() { spec.Lifecycle = nil },
+		BucketSectionPolicy:               func() { spec.Policy = nil },
+	} {
+		if skipped[section] {
+			clear()
+		}
+	}
+	return spec
+}
+
+// extraChanges puts the sections not in BucketSpec, the replication rules, the CNAMEs and the WORM which already exist
+// are skipped so the document could be imported again
+func (config BucketConfig) extraChanges(client Client, bucketName string, skipped map[string]bool, options []Option) ([]BucketChange, error) {
+	var changes []BucketChange
+	if config.QoS != nil && !skipped[BucketSectionQoS] {
+		qos := *config.QoS
+		changes = append(changes, BucketChange{Section: BucketSectionQoS, Action: BucketChangeSet, Desired: qos,
+			order: bucketOrderQoS,
+			apply: func(client Client, bucketName string, options []Option) error {
+				return client.SetBucketQoSInfo(bucketName, qos, options...)
+			}})
+	}
+	if config.RequestPayment != nil && !skipped[BucketSectionRequestPayment] {
+		payer := *config.RequestPayment
+		changes = append(changes, BucketChange{Section: BucketSectionRequestPayment, Action: BucketChangeSet, Desired: payer,
+			order: bucketOrderRequestPayment,
+			apply: func(client Client, bucketName string, options []Option) error {
+				return client.SetBucketRequestPayment(bucketName, RequestPaymentConfiguration{Payer: payer}, options...)
+			}})
+	}
+	if config.ResourceGroup != nil && !skipped[BucketSectionResourceGroup] {
+		group := *config.ResourceGroup
+		changes = append(changes, BucketChange{Section: BucketSectionResourceGroup, Action: BucketChangeSet, Desired: group,
+			order: bucketOrderResourceGroup,
+			apply: func(client Client, bucketName string, options []Option) error {
+				return client.PutBucketResourceGroup(bucketName, PutBucketResourceGroup{ResourceGroupId: group}, options...)
+			}})
+	}
+	if !skipped[BucketSectionStyle] {
+		for _, s := range config.Styles {
+			style := s
+			changes = append(changes, BucketChange{Section: BucketSectionStyle + "/" + style.Name, Action: BucketChangeSet, Desired: style,
+				order: bucketOrderStyle,
+				apply: func(client Client, bucketName string, options []Option) error {
+					return client.PutBucketStyle(bucketName, style.Name, style.Content, options...)
+				}})
+		}
+	}
+	for _, planner := range []struct {
+		section string
+		plan    func(Client, string, []Option) ([]BucketChange, error)
+	}{
+		{BucketSectionReplication, config.replicationChanges},
+		{BucketSectionCname, config.cnameChanges},
+		{BucketSectionWorm, config.wormChanges},
+	} {
+		if skipped[planner.section] {
+			continue
+		}
+		planned, err := planner.plan(client, bucketName, options)
+		if err != nil {
+			return nil, fmt.Errorf("oss: plan bucket section %s failed: %s", planner.section, err.Error())
+		}
+		changes = append(changes, planned...)
+	}
+	return changes, nil
+}
+
+// replicationChanges puts the replication rules whose IDs don't exist, the rules could not be put again
+func (config BucketConfig) replicationChanges(client Client, bucketName string, options []Option) ([]BucketChange, error) {
+	if len(config.Replication) == 0 {
+		return nil, nil
+	}
+	existing := map[string]bool{}
+	data, err := client.GetBucketReplication(bucketName, options...)
+	if err != nil && !isNotConfigured(err) {
+		return nil, err
+	}
+	if err == nil {
+		var result GetBucketReplicationResult
+		if err = xml.Unmarshal([]byte(data), &result); err != nil {
+			return nil, err
+		}
+		for _, rule := range result.Rule {
+			existing[rule.ID] = true
+		}
+	}
+
+	var changes []BucketChange
+	for _, r := range config.Replication {
+		if existing[r.ID] {
+			continue
+		}
+		rule := r
+		changes = append(changes, BucketChange{Section: BucketSectionReplication + "/" + rule.ID, Action: BucketChangeSet, Desired: rule,
+			order: bucketOrderReplication,
+			apply: func(client Client, bucketName string, options []Option) error {
+				bs, err := xml.Marshal(PutBucketReplication{Rule: []ReplicationRule{rule}})
+				if err != nil {
+					return err
+				}
+				return client.PutBucketReplication(bucketName, string(bs), options...)
+			}})
+	}
+	return changes, nil
+}
+
+// cnameChanges adds the CNAMEs which don't exist
+func (config BucketConfig) cnameChanges(client Client, bucketName string, options []Option) ([]BucketChange, error) {
+	if len(config.Cnames) == 0 {
+		return nil, nil
+	}
+	existing := map[string]bool{}
+	result, err := client.ListBucketCname(bucketName, options...)
+	if err != nil && !isNotConfigured(err) {
+		return nil, err
+	}
+	for _, cname := range result.Cname {
+		existing[cname.Domain] = true
+	}
+
+	var changes []BucketChange
+	for _, cname := range config.Cnames {
+		if existing[cname] {
+			continue
+		}
+		domain := cname
+		changes = append(changes, BucketChange{Section: BucketSectionCname + "/" + domain, Action: BucketChangeSet, Desired: domain,
+			order: bucketOrderCname,
+			apply: func(client Client, bucketName string, options []Option) error {
+				return client.PutBucketCname(bucketName, domain, options...)
+			}})
+	}
+	return changes, nil
+}
+
+// wormChanges initiates the WORM if it doesn't exist, it's locked only by LockWorm(true)
+func (config BucketConfig) wormChanges(client Client, bucketName string, options []Option) ([]BucketChange, error) {
+	if config.Worm == nil {
+		return nil, nil
+	}
+	value, err := FindOption(options, bucketLockWorm, false)
+	if err != nil {
+		return nil, err
+	}
+	lock := value.(bool) && config.Worm.State == "Locked"
+	current, err := client.GetBucketWorm(bucketName, options...)
+	if err != nil && !isNotConfigured(err) {
+		return nil, err
+	}
+	if current.State == "Locked" || (current.State == "InProgress" && !lock) {
+		return nil, nil
+	}
+
+	worm := *config.Worm
+	if !lock {
+		worm.State = "InProgress"
+	}
+	wormID := current.WormId
+	return []BucketChange{{Section: BucketSectionWorm, Action: BucketChangeSet, Current: current.State, Desired: worm,
+		order: bucketOrderWorm,
+		apply: func(client Client, bucketName string, options []Option) error {
+			if wormID == "" {
+				id, err := client.InitiateBucketWorm(bucketName, worm.RetentionPeriodInDays, options...)
+				if err != nil || !lock {
+					return err
+				}
+				wormID = id
+			}
+			return client.CompleteBucketWorm(bucketName, wormID, options...)
+		}}}, nil
+}
+
+func exportBucketACL(client Client, bucketName string, config *BucketConfig, options []Option) error {
+	result, err := client.GetBucketACL(bucketName, options...)
+	if err != nil {
+		return err
+	}
+	acl := ACLType(result.ACL)
+	config.ACL = &acl
+	return nil
+}
+
+func exportBucketVersioning(client Client, bucketName string, config *BucketConfig, options []Option) error {
+	result, err := client.GetBucketVersioning(bucketName, options...)
+	if err != nil || result.Status == "" {
+		return err
+	}
+	config.Versioning = &result.Status
+	return nil
+}
+
+func exportBucketAccessMonitor(client Client, bucketName string, config *BucketConfig, options []Option) error {
+	result, err := client.GetBucketAccessMonitor(bucketName, options...)
+	if err != nil || result.Status == "" {
+		return err
+	}
+	enabled := result.Status == "Enabled"
+	config.AccessMonitor = &enabled
+	return nil
+}
+
+func exportBucketTransferAcceleration(client Client, bucketName string, config *BucketConfig, options []Option) error {
+	result, err := client.GetBucketTransferAcc(bucketName, options...)
+	if err != nil {
+		return err
+	}
+	config.TransferAcceleration = &result.Enabled
+	return nil
+}
+
+func exportBucketEncryption(client Client, bucketName string, config *BucketConfig, options []Option) error {
+	result, err := client.GetBucketEncryption(bucketName, options...)
+	if err != nil || result.SSEDefault.SSEAlgorithm == "" {
+		return err
+	}
+	config.Encryption = &result.SSEDefault
+	return nil
+}
+
+func exportBucketTags(client Client, bucketName string, config *BucketConfig, options []Option) error {
+	result, err := client.GetBucketTagging(bucketName, options...)
+	if err != nil || len(result.Tags) == 0 {
+		return err
+	}
+	config.Tags = sortedTags(result.Tags)
+	return nil
+}
+
+func exportBucketLogging(client Client, bucketName string, config *BucketConfig, options []Option) error {
+	result, err := client.GetBucketLogging(bucketName, options...)
+	if err != nil || result.LoggingEnabled.TargetBucket == "" {
+		return err
+	}
+	config.Logging = &result.LoggingEnabled
+	return nil
+}
+
+func exportBucketReferer(client Client, bucketName string, config *BucketConfig, options []Option) error {
+	result, err := client.GetBucketReferer(bucketName, options...)
+	if err != nil {
+		return err
+	}
+	referer := RefererXML(result)
+	config.Referer = &referer
+	return nil
+}
+
+func exportBucketCORS(client Client, bucketName string, config *BucketConfig, options []Option) error {
+	result, err := client.GetBucketCORS(bucketName, options...)
+	if err != nil || len(result.CORSRules) == 0 {
+		return err
+	}
+	cors := CORSXML(result)
+	config.CORS = &cors
+	return nil
+}
+
+func exportBucketWebsite(client Client, bucketName string, config *BucketConfig, options []Option) error {
+	result, err := client.GetBucketWebsite(bucketName, options...)
+	if err != nil {
+		return err
+	}
+	website := WebsiteXML(result)
+	config.Website = &website
+	return nil
+}
+
+func exportBucketResponseHeader(client Client, bucketName string, config *BucketConfig, options []Option) error {
+	result, err := client.GetBucketResponseHeader(bucketName, options...)
+	if err != nil || len(result.Rule) == 0 {
+		return err
+	}
+	header := ResponseHeaderXml(result)
+	config.ResponseHeader = &header
+	return nil
+}
+
+func exportBucketInventories(client Client, bucketName string, config *BucketConfig, options []Option) error {
+	inventories, err := listBucketInventories(client, bucketName, options)
+	if err != nil || len(inventories) == 0 {
+		return err
+	}
+	config.Inventories = inventories
+	return nil
+}
+
+func exportBucketLifecycle(client Client, bucketName string, config *BucketConfig, options []Option) error {
+	result, err := client.GetBucketLifecycle(bucketName, options...)
+	if err != nil || len(result.Rules) == 0 {
+		return err
+	}
+	lifecycle := LifecycleConfiguration(result)
+	config.Lifecycle = &lifecycle
+	return nil
+}
+
+func exportBucketPolicy(client Client, bucketName string, config *BucketConfig, options []Option) error {
+	policy, err := client.GetBucketPolicy(bucketName, options...)
+	if err != nil || strings.TrimSpace(policy) == "" {
+		return err
+	}
+	doc, err := ParsePolicy(policy)
+	if err != nil {
+		return err
+	}
+	config.Policy = &doc
+	return nil
+}
+
+func exportBucketQoS(client Client, bucketName string, config *BucketConfig, options []Option) error {
+	result, err := client.GetBucketQosInfo(bucketName, options...)
+	if err != nil {
+		return err
+	}
+	config.QoS = &result
+	return nil
+}
+
+func exportBucketReplication(client Client, bucketName string, config *BucketConfig, options []Option) error {
+	data, err := client.GetBucketReplication(bucketName, options...)
+	if err != nil {
+		return err
+	}
+	var result GetBucketReplicationResult
+	if err = xml.Unmarshal([]byte(data), &result); err != nil {
+		return err
+	}
+	for _, rule := range result.Rule {
+		rule.Status, rule.Progress, rule.HistoricalObject = "", nil, ""
+		config.Replication = append(config.Replication, rule)
+	}
+	return nil
+}
+
+func exportBucketCnames(client Client, bucketName string, config *BucketConfig, options []Option) error {
+	result, err := client.ListBucketCname(bucketName, options...)
+	if err != nil {
+		return err
+	}
+	for _, cname := range result.Cname {
+		config.Cnames = append(config.Cnames, cname.Domain)
+	}
+	return nil
+}
+
+func exportBucketStyles(client Client, bucketName string, config *BucketConfig, options []Option) error {
+	result, err := client.ListBucketStyle(bucketName, options...)
+	if err != nil {
+		return err
+	}
+	for _, style := range result.Style {
+		config.Styles = append(config.Styles, BucketStyleXml{Name: style.Name, Content: style.Content})
+	}
+	return nil
+}
+
+func exportBucketResourceGroup(client Client, bucketName string, config *BucketConfig, options []Option) error {
+	result, err := client.GetBucketResourceGroup(bucketName, options...)
+	if err != nil || result.ResourceGroupId == "" {
+		return err
+	}
+	config.ResourceGroup = &result.ResourceGroupId
+	return nil
+}
+
+func exportBucketRequestPayment(client Client, bucketName string, config *BucketConfig, options []Option) error {
+	result, err := client.GetBucketRequestPayment(bucketName, options...)
+	if err != nil || result.Payer == "" {
+		return err
+	}
+	config.RequestPayment = &result.Payer
+	return nil
+}
+
+func exportBucketWorm(client Client, bucketName string, config *BucketConfig, options []Option) error {
+	result, err := client.GetBucketWorm(bucketName, options...)
+	if err != nil || (result.State != "InProgress" && result.State != "Locked") {
+		return err
+	}
+	config.Worm = &WormConfiguration{State: result.State, RetentionPeriodInDays: result.RetentionPeriodInDays}
+	return nil
+}
@@ -0,0 +1,402 @@
+package oss
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type docKind int
+
+const (
+	docScalar docKind = iota
+	docObject
+	docArray
+)
+
+// docNode is a node of the configuration document keeping the order of the keys, the scalars are JSON literals
+type docNode struct {
+	kind   docKind
+	keys   []string   // The keys of the object
+	values []*docNode // The values of the object or the items of the array
+	scalar string     // The JSON literal of the scalar, such as "abc", 1, true or null
+}
+
+// decodeDocNode decodes the JSON into the tree. The XMLName fields of the XML types are dropped, and the null or
+// empty string members of the objects are dropped if omitEmpty is true, they're decoded as the zero values.
+func decodeDocNode(data []byte, omitEmpty bool) (*docNode, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decodeDocValue(decoder, omitEmpty)
+}
+
+func decodeDocValue(decoder *json.Decoder, omitEmpty bool) (*docNode, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch value := token.(type) {
+	case json.Delim:
+		node := &docNode{kind: docArray}
+		if value == '{' {
+			node.kind, node.keys = docObject, []string{}
+		}
+		for decoder.More() {
+			key := ""
+			if node.kind == docObject {
+				token, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, _ = token.(string)
+			}
+			child, err := decodeDocValue(decoder, omitEmpty)
+			if err != nil {
+				return nil, err
+			}
+			if node.kind == docObject {
+				if key == "XMLName" || (omitEmpty && (child.scalar == "null" || child.scalar == `""`)) {
+					continue
+				}
+				node.keys = append(node.keys, key)
+			}
+			node.values = append(node.values, child)
+		}
+		// The closing delimiter
+		if _, err = decoder.Token(); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case string:
+		return &docNode{scalar: quoteDocString(value)}, nil
+	case json.Number:
+		return &docNode{scalar: value.String()}, nil
+	case bool:
+		return &docNode{scalar: strconv.FormatBool(value)}, nil
+	}
+	return &docNode{scalar: "null"}, nil
+}
+
+// quoteDocString quotes the string as JSON without escaping the HTML characters, it's a double quoted YAML string too
+func quoteDocString(s string) string {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	return strings.TrimSuffix(buffer.String(), "\n")
+}
+
+func (node *docNode) writeJSON(sb *strings.Builder, indent string) {
+	switch {
+	case node.kind == docScalar:
+		sb.WriteString(node.scalar)
+		return
+	case node.kind == docObject && len(node.values) == 0:
+		sb.WriteString("{}")
+		return
+	case node.kind == docArray && len(node.values) == 0:
+		sb.WriteString("[]")
+		return
+	}
+
+	open, close := "[", "]"
+	if node.kind == docObject {
+		open, close = "{", "}"
+	}
+	sb.WriteString(open + "\n")
+	for i, value := range node.values {
+		sb.WriteString(indent + "  ")
+		if node.kind == docObject {
+			sb.WriteString(quoteDocString(node.keys[i]) + ": ")
+		}
+		value.writeJSON(sb, indent+"  ")
+		if i < len(node.values)-1 {
+			sb.WriteString(",")
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString(indent + close)
+}
+
+var plainYAMLKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// inline returns the value written on the line of its key or dash, false for the nonempty collections
+func (node *docNode) inline() (string, bool) {
+	switch {
+	case node.kind == docScalar:
+		return node.scalar, true
+	case node.kind == docObject && len(node.values) == 0:
+		return "{}", true
+	case node.kind == docArray && len(node.values) == 0:
+		return "[]", true
+	}
+	return "", false
+}
+
+// writeYAML writes the block collection, continued means its first line follows the dash of its parent
+func (node *docNode) writeYAML(sb *strings.Builder, indent int, continued bool) {
+	prefix := strings.Repeat(" ", indent)
+	for i, value := range node.values {
+		if i > 0 || !continued {
+			sb.WriteString(prefix)
+		}
+		if node.kind == docArray {
+			if text, ok := value.inline(); ok {
+				sb.WriteString("- " + text + "\n")
+			} else if value.kind == docArray {
+				sb.WriteString("-\n")
+				value.writeYAML(sb, indent+2, false)
+			} else {
+				sb.WriteString("- ")
+				value.writeYAML(sb, indent+2, true)
+			}
+			continue
+		}
+
+		key := node.keys[i]
+		if !plainYAMLKey.MatchString(key) {
+			key = quoteDocString(key)
+		}
+		if text, ok := value.inline(); ok {
+			sb.WriteString(key + ": " + text + "\n")
+			continue
+		}
+		sb.WriteString(key + ":\n")
+		value.writeYAML(sb, indent+2, false)
+	}
+}
+
+// yamlLine is a line of the YAML document without the indent
+type yamlLine struct {
+	indent int
+	text   string
+	number int
+}
+
+// parseYAMLNode parses the subset of YAML written by BucketConfig.YAML, it's not a general YAML parser
+func parseYAMLNode(text string) (*docNode, error) {
+	var lines []*yamlLine
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, fmt.Errorf("oss: invalid YAML at line %d: tab indent", i+1)
+		}
+		lines = append(lines, &yamlLine{indent: len(line) - len(trimmed), text: trimmed, number: i + 1})
+	}
+	if len(lines) == 0 {
+		return &docNode{kind: docObject, keys: []string{}}, nil
+	}
+	parser := &yamlParser{lines: lines}
+	node, err := parser.parseBlock(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if parser.pos < len(lines) {
+		return nil, parser.errorf("unexpected indent")
+	}
+	return node, nil
+}
+
+type yamlParser struct {
+	lines []*yamlLine
+	pos   int
+}
+
+func (p *yamlParser) errorf(format string, args ...interface{}) error {
+	number := 0
+	if p.pos < len(p.lines) {
+		number = p.lines[p.pos].number
+	}
+	return fmt.Errorf("oss: invalid YAML at line %d: %s", number, fmt.Sprintf(format, args...))
+}
+
+func isYAMLSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func (p *yamlParser) parseBlock(indent int) (*docNode, error) {
+	if isYAMLSequenceItem(p.lines[p.pos].text) {
+		return p.parseSequence(indent)
+	}
+	return p.parseMapping(indent)
+}
+
+func (p *yamlParser) parseSequence(indent int) (*docNode, error) {
+	node := &docNode{kind: docArray}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent != indent || !isYAMLSequenceItem(line.text) {
+			break
+		}
+		content := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+		if content == "" {
+			p.pos++
+			child, err := p.parseChild(indent)
+			if err != nil {
+				return nil, err
+			}
+			node.values = append(node.values, child)
+			continue
+		}
+		if _, _, ok := splitYAMLKey(content); ok {
+			// The mapping starts on the line of the dash, it's indented by the position of its first key
+			line.indent, line.text = line.indent+len(line.text)-len(content), content
+			child, err := p.parseMapping(line.indent)
+			if err != nil {
+				return nil, err
+			}
+			node.values = append(node.values, child)
+			continue
+		}
+		child, err := parseYAMLScalar(content)
+		if err != nil {
+			return nil, p.errorf("%s", err.Error())
+		}
+		node.values = append(node.values, child)
+		p.pos++
+	}
+	return node, nil
+}
+
+func (p *yamlParser) parseMapping(indent int) (*docNode, error) {
+	node := &docNode{kind: docObject, keys: []string{}}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent || (line.indent == indent && isYAMLSequenceItem(line.text)) {
+			break
+		}
+		if line.indent > indent {
+			return nil, p.errorf("unexpected indent")
+		}
+		key, rest, ok := splitYAMLKey(line.text)
+		if !ok {
+			return nil, p.errorf("expect a key")
+		}
+		p.pos++
+		var child *docNode
+		var err error
+		if rest == "" {
+			// A sequence may be at the indent of its key
+			if p.pos < len(p.lines) && p.lines[p.pos].indent == indent && isYAMLSequenceItem(p.lines[p.pos].text) {
+				child, err = p.parseSequence(indent)
+			} else {
+				child, err = p.parseChild(indent)
+			}
+		} else if child, err = parseYAMLScalar(rest); err != nil {
+			err = fmt.Errorf("oss: invalid YAML at line %d: %s", line.number, err.Error())
+		}
+		if err != nil {
+			return nil, err
+		}
+		node.keys = append(node.keys, key)
+		node.values = append(node.values, child)
+	}
+	return node, nil
+}
+
+// parseChild parses the block indented deeper than the parent, null if there isn't one
+func (p *yamlParser) parseChild(indent int) (*docNode, error) {
+	if p.pos >= len(p.lines) || p.lines[p.pos].indent <= indent {
+		return &docNode{scalar: "null"}, nil
+	}
+	return p.parseBlock(p.lines[p.pos].indent)
+}
+
+// splitYAMLKey splits "key: value" or "key:", the key may be quoted
+func splitYAMLKey(text string) (string, string, bool) {
+	if strings.HasPrefix(text, "\"") || strings.HasPrefix(text, "'") {
+		end := quotedYAMLEnd(text)
+		if end == -1 || end+1 >= len(text) || text[end+1] != ':' {
+			return "", "", false
+		}
+		rest := text[end+2:]
+		if rest != "" && rest[0] != ' ' {
+			return "", "", false
+		}
+		key, err := parseYAMLScalar(text[:end+1])
+		if err != nil {
+			return "", "", false
+		}
+		var s string
+		json.Unmarshal([]byte(key.scalar), &s)
+		return s, strings.TrimSpace(rest), true
+	}
+	if strings.HasPrefix(text, "[") || strings.HasPrefix(text, "{") {
+		return "", "", false
+	}
+	if strings.HasSuffix(text, ":") {
+		return text[:len(text)-1], "", true
+	}
+	if pos := strings.Index(text, ": "); pos != -1 {
+		return text[:pos], strings.TrimSpace(text[pos+2:]), true
+	}
+	return "", "", false
+}
+
+// quotedYAMLEnd returns the position of the closing quote
+func quotedYAMLEnd(text string) int {
+	quote := text[0]
+	for i := 1; i < len(text); i++ {
+		switch {
+		case quote == '"' && text[i] == '\\':
+			i++
+		case text[i] == quote && quote == '\'' && i+1 < len(text) && text[i+1] == '\'':
+			i++
+		case text[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+var yamlNumber = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+
+func parseYAMLScalar(text string) (*docNode, error) {
+	text = strings.TrimSpace(text)
+	switch {
+	case strings.HasPrefix(text, "\""):
+		end := quotedYAMLEnd(text)
+		if end == -1 {
+			return nil, fmt.Errorf("unterminated string")
+		}
+		var s string
+		if err := json.Unmarshal([]byte(text[:end+1]), &s); err != nil {
+			return nil, fmt.Errorf("invalid string %s", text[:end+1])
+		}
+		return &docNode{scalar: quoteDocString(s)}, nil
+	case strings.HasPrefix(text, "'"):
+		end := quotedYAMLEnd(text)
+		if end == -1 {
+			return nil, fmt.Errorf("unterminated string")
+		}
+		return &docNode{scalar: quoteDocString(strings.Replace(text[1:end], "''", "'", -1))}, nil
+	case strings.HasPrefix(text, "[") || strings.HasPrefix(text, "{"):
+		// The flow collections are supported in JSON
+		return decodeDocNode([]byte(text), false)
+	case strings.HasPrefix(text, "|") || strings.HasPrefix(text, ">"):
+		return nil, fmt.Errorf("block scalar is not supported")
+	}
+
+	if pos := strings.Index(text, " #"); pos != -1 {
+		text = strings.TrimSpace(text[:pos])
+	}
+	switch text {
+	case "", "~", "null", "Null", "NULL":
+		return &docNode{scalar: "null"}, nil
+	case "true", "True", "TRUE":
+		return &docNode{scalar: "true"}, nil
+	case "false", "False", "FALSE":
+		return &docNode{scalar: "false"}, nil
+	}
+	if yamlNumber.MatchString(text) {
+		return &docNode{scalar: text}, nil
+	}
+	return &docNode{scalar: quoteDocString(text)}, nil
+}
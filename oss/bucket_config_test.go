@@ -0,0 +1,209 @@
+package oss
+
+import (
+	"net/http/httptest"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+type OssBucketConfigSuite struct{}
+
+var _ = Suite(&OssBucketConfigSuite{})
+
+func newBucketConfigServer() *bucketSpecServer {
+	state := newBucketSpecServer()
+	configs := map[string]string{
+		"versioning": "<VersioningConfiguration><Status>Enabled</Status></VersioningConfiguration>",
+		"encryption": "<ServerSideEncryptionRule><ApplyServerSideEncryptionByDefault><SSEAlgorithm>KMS</SSEAlgorithm>" +
+			"<KMSMasterKeyID>key-1</KMSMasterKeyID></ApplyServerSideEncryptionByDefault></ServerSideEncryptionRule>",
+		"tagging": "<Tagging><TagSet><Tag><Key>team</Key><Value>b</Value></Tag><Tag><Key>env</Key><Value>prod</Value></Tag></TagSet></Tagging>",
+		"logging": "<BucketLoggingStatus><LoggingEnabled><TargetBucket>logs-bucket</TargetBucket><TargetPrefix>src/</TargetPrefix>" +
+			"</LoggingEnabled></BucketLoggingStatus>",
+		"lifecycle": "<LifecycleConfiguration><Rule><ID>tmp</ID><Prefix>tmp/</Prefix><Status>Enabled</Status>" +
+			"<Expiration><Days>3</Days></Expiration></Rule></LifecycleConfiguration>",
+		"policy": `{"Version":"1","Statement":[{"Effect":"Allow","Principal":"*","Action":["oss:GetObject"],` +
+			`"Resource":["acs:oss:*:*:src/public/*"],"Condition":{"StringLike":{"acs:Referer":["https://*.example.com/*"]}}}]}`,
+		"qosInfo": "<QoSConfiguration><TotalUploadBandwidth>10</TotalUploadBandwidth><TotalQps>100</TotalQps></QoSConfiguration>",
+		"replication": "<ReplicationConfiguration><Rule><ID>rep</ID><PrefixSet><Prefix>data/</Prefix></PrefixSet><Action>PUT</Action>" +
+			"<Destination><Bucket>backup</Bucket><Location>oss-cn-beijing</Location></Destination><Status>doing</Status>" +
+			"<HistoricalObjectReplication>enabled</HistoricalObjectReplication></Rule></ReplicationConfiguration>",
+		"cname": "<ListCnameResult><Bucket>src</Bucket><Owner>1</Owner><Cname><Domain>img.example.com</Domain>" +
+			"<Status>Enabled</Status></Cname></ListCnameResult>",
+		"style": "<StyleList><Style><Name>thumb</Name><Content>image/resize,p_50</Content>" +
+			"<CreateTime>Wed, 20 May 2020 12:07:15 GMT</CreateTime></Style></StyleList>",
+		"resourceGroup":  "<BucketResourceGroupConfiguration><ResourceGroupId>rg-1</ResourceGroupId></BucketResourceGroupConfiguration>",
+		"requestPayment": "<RequestPaymentConfiguration><Payer>Requester</Payer></RequestPaymentConfiguration>",
+		"worm": "<WormConfiguration><WormId>worm-0</WormId><State>Locked</State><RetentionPeriodInDays>7</RetentionPeriodInDays>" +
+			"<CreationDate>2020-10-15T15:50:32</CreationDate></WormConfiguration>",
+	}
+	for k, v := range configs {
+		state.configs[k] = v
+	}
+	state.inventories["report"] = "<InventoryConfiguration><Id>report</Id><IsEnabled>true</IsEnabled><Destination><OSSBucketDestination>" +
+		"<Format>CSV</Format><AccountId>1</AccountId><RoleArn>acs:ram::1:role/r</RoleArn><Bucket>acs:oss:::src</Bucket>" +
+		"<Prefix>inventory/</Prefix></OSSBucketDestination></Destination><Schedule><Frequency>Weekly</Frequency></Schedule>" +
+		"<IncludedObjectVersions>Current</IncludedObjectVersions></InventoryConfiguration>"
+	return state
+}
+
+func (s *OssBucketConfigSuite) TestExportBucketConfig(c *C) {
+	state := newBucketConfigServer()
+	server := httptest.NewServer(state)
+	defer server.Close()
+	client, err := New(server.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+
+	config, err := client.ExportBucketConfig("src")
+	c.Assert(err, IsNil)
+	c.Assert(config.Version, Equals, BucketConfigVersion)
+	c.Assert(config.Bucket, Equals, "src")
+	c.Assert(*config.ACL, Equals, ACLPrivate)
+	c.Assert(*config.Versioning, Equals, "Enabled")
+	c.Assert(config.AccessMonitor, IsNil)
+	c.Assert(config.CORS, IsNil)
+	c.Assert(config.Encryption.KMSMasterKeyID, Equals, "key-1")
+	c.Assert(config.Tags[0].Key, Equals, "env")
+	c.Assert(config.Logging.TargetBucket, Equals, "logs-bucket")
+	c.Assert(config.Referer.AllowEmptyReferer, Equals, true)
+	c.Assert(config.Inventories[0].OSSBucketDestination.Bucket, Equals, "acs:oss:::src")
+	c.Assert(config.Lifecycle.Rules[0].ID, Equals, "tmp")
+	c.Assert(config.Policy.Statement[0].Resource, DeepEquals, []string{"acs:oss:*:*:src/public/*"})
+	c.Assert(*config.QoS.TotalQPS, Equals, 100)
+	c.Assert(config.Replication[0].Status, Equals, "")
+	c.Assert(config.Replication[0].Destination.Bucket, Equals, "backup")
+	c.Assert(config.Cnames, DeepEquals, []string{"img.example.com"})
+	c.Assert(config.Styles[0].CreateTime, Equals, "")
+	c.Assert(*config.ResourceGroup, Equals, "rg-1")
+	c.Assert(*config.RequestPayment, Equals, "Requester")
+	c.Assert(*config.Worm, DeepEquals, WormConfiguration{State: "Locked", RetentionPeriodInDays: 7})
+
+	// The documents keep the order of the fields without the XMLName fields
+	data, err := config.JSON()
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(data), "XMLName"), Equals, false)
+	c.Assert(strings.Index(string(data), `"ACL"`) < strings.Index(string(data), `"Worm"`), Equals, true)
+	parsed, err := ParseBucketConfig(data)
+	c.Assert(err, IsNil)
+	again, err := parsed.JSON()
+	c.Assert(err, IsNil)
+	c.Assert(string(again), Equals, string(data))
+
+	yaml, err := config.YAML()
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(yaml), "Version: 1\nBucket: \"src\"\nACL: \"private\"\n"), Equals, true)
+	c.Assert(strings.Contains(string(yaml), "\"acs:Referer\":"), Equals, true)
+	parsed, err = ParseBucketConfig(yaml)
+	c.Assert(err, IsNil)
+	again, err = parsed.JSON()
+	c.Assert(err, IsNil)
+	c.Assert(string(again), Equals, string(data))
+
+	config, err = client.ExportBucketConfig("src", SkipBucketSections(BucketSectionPolicy), SkipBucketSections(BucketSectionWorm))
+	c.Assert(err, IsNil)
+	c.Assert(config.Policy, IsNil)
+	c.Assert(config.Worm, IsNil)
+	c.Assert(config.Lifecycle, NotNil)
+
+	state.fail = "GET qosInfo"
+	_, err = client.ExportBucketConfig("src")
+	c.Assert(err, NotNil)
+}
+
+func (s *OssBucketConfigSuite) TestParseBucketConfigYAML(c *C) {
+	yaml := `# A handwritten config
+Version: 1
+Bucket: src
+ACL: public-read
+Tags:
+- Key: env
+  Value: 'it''s'
+- {"Key": "team", "Value": "b"}
+- {"Key": "empty", "Value": ""}
+Cnames: ["a.example.com", "b.example.com"]
+Lifecycle:
+  Rules:
+    - ID: r1
+      Prefix: "logs/"
+      Status: Enabled
+      Expiration:
+        Days: 30  # a month
+Website: ~
+ResourceGroup: ""
+`
+	config, err := ParseBucketConfig([]byte(yaml))
+	c.Assert(err, IsNil)
+	c.Assert(*config.ACL, Equals, ACLPublicRead)
+	c.Assert(config.Tags[0].Value, Equals, "it's")
+	c.Assert(config.Tags[1].Key, Equals, "team")
+	c.Assert(config.Cnames, DeepEquals, []string{"a.example.com", "b.example.com"})
+	c.Assert(config.Lifecycle.Rules[0].Prefix, Equals, "logs/")
+	c.Assert(config.Lifecycle.Rules[0].Expiration.Days, Equals, 30)
+	c.Assert(config.Website, IsNil)
+	// The empty strings are kept
+	c.Assert(config.Tags[2], Equals, Tag{Key: "empty", Value: ""})
+	c.Assert(config.ResourceGroup, NotNil)
+	c.Assert(*config.ResourceGroup, Equals, "")
+
+	_, err = ParseBucketConfig([]byte("Version: 1\n  Bucket: src\n"))
+	c.Assert(err, NotNil)
+	_, err = ParseBucketConfig([]byte("Version: 2\n"))
+	c.Assert(err, NotNil)
+	_, err = ParseBucketConfig([]byte("Bucket: |\n  src\n"))
+	c.Assert(err, NotNil)
+}
+
+func (s *OssBucketConfigSuite) TestImportBucketConfig(c *C) {
+	source := httptest.NewServer(newBucketConfigServer())
+	defer source.Close()
+	client, err := New(source.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+	config, err := client.ExportBucketConfig("src")
+	c.Assert(err, IsNil)
+
+	state := newBucketSpecServer()
+	target := httptest.NewServer(state)
+	defer target.Close()
+	client, err = New(target.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+
+	plan, err := client.ImportBucketConfig("dst", config, SkipBucketSections(BucketSectionCname), RenameBucket("logs-bucket", "dst-logs"))
+	c.Assert(err, IsNil)
+	c.Assert(plan.Bucket, Equals, "dst")
+	c.Assert(state.log, DeepEquals, []string{"PUT versioning", "PUT encryption", "PUT tagging", "PUT resourceGroup",
+		"PUT requestPayment", "PUT qosInfo", "PUT logging", "PUT style", "PUT inventory", "PUT lifecycle",
+		"POST replication", "PUT policy", "POST worm"})
+
+	// The bucket names are replaced, the other buckets are kept
+	c.Assert(strings.Contains(state.configs["logging"], "<TargetBucket>dst-logs</TargetBucket>"), Equals, true)
+	c.Assert(strings.Contains(state.inventories["report"], "<Bucket>acs:oss:::dst</Bucket>"), Equals, true)
+	c.Assert(strings.Contains(state.configs["policy"], "acs:oss:*:*:dst/public/*"), Equals, true)
+	c.Assert(strings.Contains(state.configs["replication"], "<Bucket>backup</Bucket>"), Equals, true)
+	c.Assert(strings.Contains(state.configs["worm"], "<RetentionPeriodInDays>7</RetentionPeriodInDays>"), Equals, true)
+	// The document isn't changed
+	c.Assert(config.Policy.Statement[0].Resource, DeepEquals, []string{"acs:oss:*:*:src/public/*"})
+
+	c.Assert(strings.Contains(state.configs["worm"], "<State>InProgress</State>"), Equals, true)
+
+	// Imported again, the sections of BucketSpec and the existing rules are unchanged, the others are put again.
+	// The WORM in progress is locked by LockWorm.
+	state.log = nil
+	_, err = client.ImportBucketConfig("dst", config, SkipBucketSections(BucketSectionCname), RenameBucket("logs-bucket", "dst-logs"),
+		LockWorm(true))
+	c.Assert(err, IsNil)
+	c.Assert(state.log, DeepEquals, []string{"PUT resourceGroup", "PUT requestPayment", "PUT qosInfo", "PUT style", "POST wormId"})
+	c.Assert(strings.Contains(state.configs["worm"], "<State>Locked</State>"), Equals, true)
+
+	// The CNAMEs are added last, the existing ones are skipped
+	state.log = nil
+	config.Cnames = append(config.Cnames, "www.example.com")
+	state.configs["cname"] = "<ListCnameResult><Cname><Domain>www.example.com</Domain></Cname></ListCnameResult>"
+	_, err = client.ImportBucketConfig("dst", config, RenameBucket("logs-bucket", "dst-logs"), LockWorm(true))
+	c.Assert(err, IsNil)
+	c.Assert(state.log, DeepEquals, []string{"PUT resourceGroup", "PUT requestPayment", "PUT qosInfo", "PUT style", "POST cname"})
+	c.Assert(strings.Contains(state.configs["cname"], "<Domain>img.example.com</Domain>"), Equals, true)
+
+	config.Version = BucketConfigVersion + 1
+	_, err = client.ImportBucketConfig("dst", config)
+	c.Assert(err, NotNil)
+}
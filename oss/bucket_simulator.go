@@ -0,0 +1,318 @@
+package oss
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Actions of SimulateWebsite
+const (
+	WebsiteActionNone          = "None"          // The object is served as is
+	WebsiteActionIndexDocument = "IndexDocument" // The index document of the directory is served
+	WebsiteActionErrorDocument = "ErrorDocument" // The error document is served with 404
+	WebsiteActionRedirect      = "Redirect"      // The client is redirected, by an External or AliCDN rule
+	WebsiteActionInternal      = "Internal"      // The request is rewritten to another object
+	WebsiteActionMirror        = "Mirror"        // The object is fetched from the mirror origin
+)
+
+// CORSRequest is a cross-origin request evaluated by SimulateCORS
+type CORSRequest struct {
+	Origin    string   // The Origin header
+	Method    string   // The method of the request, or Access-Control-Request-Method of the preflight request
+	Headers   []string // Access-Control-Request-Headers of the preflight request
+	Preflight bool     // Whether it's an OPTIONS preflight request
+}
+
+// CORSResult is the result of SimulateCORS
+type CORSResult struct {
+	Matched   bool        // Whether a rule matches, the preflight request fails with 403 otherwise
+	RuleIndex int         // The index of the first matched rule, -1 if no rule matches
+	Headers   http.Header // The CORS response headers
+}
+
+// SimulateCORS evaluates the CORS rules offline, the first rule matching the origin, the method and the
+// request headers of the preflight request is used.
+// AllowedOrigin and AllowedHeader support one * wildcard, and the headers are case insensitive.
+//
+// config    the CORS configuration, such as the result of GetBucketCORS.
+// req    the request.
+//
+// CORSResult    the matched rule and the response headers.
+func SimulateCORS(config CORSXML, req CORSRequest) CORSResult {
+	result := CORSResult{RuleIndex: -1, Headers: http.Header{}}
+	if config.ResponseVary != nil && *config.ResponseVary {
+		result.Headers.Set("Vary", "Origin, Access-Control-Request-Method, Access-Control-Request-Headers")
+	}
+	if req.Origin == "" {
+		return result
+	}
+
+	for i, rule := range config.CORSRules {
+		origin, ok := matchCORSOrigin(rule.AllowedOrigin, req.Origin)
+		if !ok || !containsString(rule.AllowedMethod, req.Method) {
+			continue
+		}
+		if req.Preflight && !matchCORSHeaders(rule.AllowedHeader, req.Headers) {
+			continue
+		}
+
+		result.Matched, result.RuleIndex = true, i
+		result.Headers.Set("Access-Control-Allow-Origin", origin)
+		result.Headers.Set("Access-Control-Allow-Methods", strings.Join(rule.AllowedMethod, ", "))
+		if req.Preflight && len(req.Headers) > 0 {
+			result.Headers.Set("Access-Control-Allow-Headers", strings.Join(req.Headers, ", "))
+		}
+		if len(rule.ExposeHeader) > 0 {
+			result.Headers.Set("Access-Control-Expose-Headers", strings.Join(rule.ExposeHeader, ", "))
+		}
+		if req.Preflight && rule.MaxAgeSeconds > 0 {
+			result.Headers.Set("Access-Control-Max-Age", strconv.Itoa(rule.MaxAgeSeconds))
+		}
+		return result
+	}
+	return result
+}
+
+// matchCORSOrigin returns the Access-Control-Allow-Origin of the matched origin, * is returned as is
+func matchCORSOrigin(allowed []string, origin string) (string, bool) {
+	for _, pattern := range allowed {
+		if pattern == "*" {
+			return "*", true
+		}
+		if matchWildcard(pattern, origin, true) {
+			return origin, true
+		}
+	}
+	return "", false
+}
+
+func matchCORSHeaders(allowed []string, headers []string) bool {
+	for _, header := range headers {
+		matched := false
+		for _, pattern := range allowed {
+			if matchWildcard(pattern, strings.TrimSpace(header), true) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// RefererResult is the result of SimulateReferer
+type RefererResult struct {
+	Allowed bool
+	Reason  string // Why the referer is allowed or denied
+	Matched string // The matched entry of the whitelist or the blacklist
+}
+
+// SimulateReferer evaluates the hotlink protection offline.
+// The empty referer is allowed by AllowEmptyReferer. A referer matching the blacklist is denied,
+// then a referer is allowed if the whitelist is empty or matches it.
+// The entries support the * and ? wildcards, an entry without the scheme matches both http and https.
+// The query string of the referer is ignored unless AllowTruncateQueryString is false.
+//
+// config    the referer configuration, such as the result of GetBucketReferer.
+// referer    the Referer header.
+//
+// RefererResult    whether the referer is allowed and why.
+func SimulateReferer(config RefererXML, referer string) RefererResult {
+	if referer == "" {
+		if config.AllowEmptyReferer {
+			return RefererResult{Allowed: true, Reason: "empty referer is allowed"}
+		}
+		return RefererResult{Reason: "empty referer is not allowed"}
+	}
+
+	if config.AllowTruncateQueryString == nil || *config.AllowTruncateQueryString {
+		if pos := strings.Index(referer, "?"); pos != -1 {
+			referer = referer[:pos]
+		}
+	}
+
+	if config.RefererBlacklist != nil {
+		if entry, ok := matchReferer(config.RefererBlacklist.Referer, referer); ok {
+			return RefererResult{Reason: "referer matches the blacklist", Matched: entry}
+		}
+	}
+	if len(config.RefererList) == 0 {
+		return RefererResult{Allowed: true, Reason: "whitelist is empty"}
+	}
+	if entry, ok := matchReferer(config.RefererList, referer); ok {
+		return RefererResult{Allowed: true, Reason: "referer matches the whitelist", Matched: entry}
+	}
+	return RefererResult{Reason: "referer does not match the whitelist"}
+}
+
+func matchReferer(entries []string, referer string) (string, bool) {
+	withoutScheme := referer
+	if pos := strings.Index(referer, "://"); pos != -1 {
+		withoutScheme = referer[pos+3:]
+	}
+	for _, entry := range entries {
+		target := referer
+		if !strings.Contains(entry, "://") {
+			target = withoutScheme
+		}
+		if matchWildcard(entry, target, true) {
+			return entry, true
+		}
+	}
+	return "", false
+}
+
+// WebsiteRequest is a request to the static website evaluated by SimulateWebsite
+type WebsiteRequest struct {
+	Key        string      // The object key, such as dir/index.html, without the leading /
+	Query      string      // The raw query string without ?
+	Headers    http.Header // The request headers
+	Protocol   string      // The protocol of the request, http by default
+	StatusCode int         // The status code of getting the object, such as 404, 0 means it's not known
+}
+
+// WebsiteResult is the result of SimulateWebsite
+type WebsiteResult struct {
+	Action        string       // The action, such as WebsiteActionRedirect
+	RuleNumber    int          // The RuleNumber of the matched rule, 0 if no rule matches
+	StatusCode    int          // The status code of the redirect, or 404 of the error document
+	Location      string       // The Location of the redirect
+	Key           string       // The object served, such as the index document, the error document or the rewritten key
+	MirrorURL     string       // The URL fetched from the mirror origin
+	MirrorHeaders http.Header  // The headers sent to the mirror origin
+	Rule          *RoutingRule // The matched rule
+}
+
+// SimulateWebsite evaluates the static website configuration offline.
+// The routing rules are checked by RuleNumber, and the first rule whose KeyPrefixEquals, HttpErrorCodeReturnedEquals
+// and IncludeHeader all match fires. Otherwise the index document is served for the directories and the error document
+// is served for 404.
+//
+// config    the website configuration, such as the result of GetBucketWebsite.
+// req    the request.
+//
+// WebsiteResult    the action and its details.
+func SimulateWebsite(config WebsiteXML, req WebsiteRequest) WebsiteResult {
+	rules := append([]RoutingRule{}, config.RoutingRules...)
+	sort.Stable(routingRulesByNumber(rules))
+	for i := range rules {
+		if matchRoutingCondition(rules[i].Condition, req) {
+			return applyRoutingRule(&rules[i], req)
+		}
+	}
+
+	if (req.Key == "" || strings.HasSuffix(req.Key, "/")) && config.IndexDocument.Suffix != "" {
+		return WebsiteResult{Action: WebsiteActionIndexDocument, Key: req.Key + config.IndexDocument.Suffix}
+	}
+	if req.StatusCode == http.StatusNotFound && config.ErrorDocument.Key != "" {
+		return WebsiteResult{Action: WebsiteActionErrorDocument, StatusCode: http.StatusNotFound, Key: config.ErrorDocument.Key}
+	}
+	return WebsiteResult{Action: WebsiteActionNone, Key: req.Key}
+}
+
+type routingRulesByNumber []RoutingRule
+
+func (r routingRulesByNumber) Len() int           { return len(r) }
+func (r routingRulesByNumber) Less(i, j int) bool { return r[i].RuleNumber < r[j].RuleNumber }
+func (r routingRulesByNumber) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+
+func matchRoutingCondition(condition Condition, req WebsiteRequest) bool {
+	if !strings.HasPrefix(req.Key, condition.KeyPrefixEquals) {
+		return false
+	}
+	if condition.HTTPErrorCodeReturnedEquals != 0 && condition.HTTPErrorCodeReturnedEquals != req.StatusCode {
+		return false
+	}
+	for _, header := range condition.IncludeHeader {
+		if req.Headers == nil || req.Headers.Get(header.Key) != header.Equals {
+			return false
+		}
+	}
+	return true
+}
+
+func applyRoutingRule(rule *RoutingRule, req WebsiteRequest) WebsiteResult {
+	redirect := rule.Redirect
+	result := WebsiteResult{RuleNumber: rule.RuleNumber, Rule: rule}
+
+	key := req.Key
+	if redirect.ReplaceKeyWith != "" {
+		key = strings.Replace(redirect.ReplaceKeyWith, "${key}", req.Key, -1)
+	} else if redirect.ReplaceKeyPrefixWith != "" {
+		key = redirect.ReplaceKeyPrefixWith + strings.TrimPrefix(req.Key, rule.Condition.KeyPrefixEquals)
+	}
+
+	switch redirect.RedirectType {
+	case "Internal":
+		result.Action, result.Key = WebsiteActionInternal, key
+	case "Mirror":
+		result.Action, result.Key = WebsiteActionMirror, key
+		result.MirrorURL = redirect.MirrorURL + key
+		if isTrue(redirect.MirrorPassQueryString) && req.Query != "" {
+			result.MirrorURL += "?" + req.Query
+		}
+		result.MirrorHeaders = mirrorHeaders(redirect.MirrorHeaders, req.Headers)
+	default:
+		// External, AliCDN or empty
+		result.Action, result.Key = WebsiteActionRedirect, key
+		result.StatusCode = redirect.HttpRedirectCode
+		if result.StatusCode == 0 {
+			result.StatusCode = http.StatusFound
+		}
+		protocol := redirect.Protocol
+		if protocol == "" {
+			protocol = req.Protocol
+		}
+		if protocol == "" {
+			protocol = "http"
+		}
+		host := redirect.HostName
+		if host == "" && req.Headers != nil {
+			host = req.Headers.Get("Host")
+		}
+		result.Location = protocol + "://" + host + "/" + key
+		if isTrue(redirect.PassQueryString) && req.Query != "" {
+			result.Location += "?" + req.Query
+		}
+	}
+	return result
+}
+
+// mirrorHeaders builds the headers sent to the mirror origin, Set overrides Remove which overrides Pass
+func mirrorHeaders(config MirrorHeaders, headers http.Header) http.Header {
+	out := http.Header{}
+	for k, v := range headers {
+		if isTrue(config.PassAll) || containsFold(config.Pass, k) {
+			out[k] = append([]string{}, v...)
+		}
+	}
+	for _, k := range config.Remove {
+		out.Del(k)
+	}
+	for _, set := range config.Set {
+		out.Set(set.Key, set.Value)
+	}
+	return out
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package oss
+
+import (
+	"net/http"
+
+	. "gopkg.in/check.v1"
+)
+
+type OssBucketSimulatorSuite struct{}
+
+var _ = Suite(&OssBucketSimulatorSuite{})
+
+func (s *OssBucketSimulatorSuite) TestSimulateCORS(c *C) {
+	vary := true
+	config := CORSXML{
+		CORSRules: []CORSRule{
+			{
+				AllowedOrigin: []string{"https://*.example.com"},
+				AllowedMethod: []string{"GET", "PUT"},
+				AllowedHeader: []string{"x-oss-*", "Content-Type"},
+				ExposeHeader:  []string{"ETag", "x-oss-request-id"},
+				MaxAgeSeconds: 600,
+			},
+			{
+				AllowedOrigin: []string{"*"},
+				AllowedMethod: []string{"GET"},
+			},
+		},
+		ResponseVary: &vary,
+	}
+
+	result := SimulateCORS(config, CORSRequest{Origin: "https://app.example.com", Method: "PUT",
+		Headers: []string{"X-Oss-Meta-A", "content-type"}, Preflight: true})
+	c.Assert(result.Matched, Equals, true)
+	c.Assert(result.RuleIndex, Equals, 0)
+	c.Assert(result.Headers.Get("Access-Control-Allow-Origin"), Equals, "https://app.example.com")
+	c.Assert(result.Headers.Get("Access-Control-Allow-Methods"), Equals, "GET, PUT")
+	c.Assert(result.Headers.Get("Access-Control-Allow-Headers"), Equals, "X-Oss-Meta-A, content-type")
+	c.Assert(result.Headers.Get("Access-Control-Expose-Headers"), Equals, "ETag, x-oss-request-id")
+	c.Assert(result.Headers.Get("Access-Control-Max-Age"), Equals, "600")
+	c.Assert(result.Headers.Get("Vary"), Not(Equals), "")
+
+	// The header isn't allowed by the first rule, the second rule doesn't allow PUT
+	result = SimulateCORS(config, CORSRequest{Origin: "https://app.example.com", Method: "PUT",
+		Headers: []string{"Authorization"}, Preflight: true})
+	c.Assert(result.Matched, Equals, false)
+	c.Assert(result.RuleIndex, Equals, -1)
+	c.Assert(result.Headers.Get("Access-Control-Allow-Origin"), Equals, "")
+
+	// The actual request doesn't check the headers, the wildcard origin is returned as is
+	result = SimulateCORS(config, CORSRequest{Origin: "http://other.com", Method: "GET"})
+	c.Assert(result.RuleIndex, Equals, 1)
+	c.Assert(result.Headers.Get("Access-Control-Allow-Origin"), Equals, "*")
+	c.Assert(result.Headers.Get("Access-Control-Max-Age"), Equals, "")
+
+	result = SimulateCORS(config, CORSRequest{Method: "GET"})
+	c.Assert(result.Matched, Equals, false)
+}
+
+func (s *OssBucketSimulatorSuite) TestSimulateReferer(c *C) {
+	config := RefererXML{
+		AllowEmptyReferer: false,
+		RefererList:       []string{"*.example.com/*", "https://www.aliyun.com/?ndex.html"},
+		RefererBlacklist:  &RefererBlacklist{Referer: []string{"*.bad.example.com/*"}},
+	}
+
+	cases := []struct {
+		referer string
+		allowed bool
+	}{
+		{"", false},
+		{"http://img.example.com/a.html", true},
+		{"https://img.example.com/a.html?x=1", true},
+		{"https://evil.bad.example.com/a.html", false},
+		{"https://www.aliyun.com/index.html", true},
+		{"http://www.aliyun.com/index.html", false},
+		{"https://other.com/", false},
+	}
+	for _, t := range cases {
+		result := SimulateReferer(config, t.referer)
+		c.Assert(result.Allowed, Equals, t.allowed, Commentf("%s: %s", t.referer, result.Reason))
+	}
+	c.Assert(SimulateReferer(config, "https://evil.bad.example.com/").Matched, Equals, "*.bad.example.com/*")
+
+	// The query string is matched if it's not truncated
+	truncate := false
+	config.AllowTruncateQueryString = &truncate
+	config.RefererList = []string{"https://www.aliyun.com/index.html"}
+	c.Assert(SimulateReferer(config, "https://www.aliyun.com/index.html?a=1").Allowed, Equals, false)
+	config.RefererList = []string{"https://www.aliyun.com/index.html*"}
+	c.Assert(SimulateReferer(config, "https://www.aliyun.com/index.html?a=1").Allowed, Equals, true)
+
+	// The empty whitelist allows all but the blacklist
+	config = RefererXML{AllowEmptyReferer: true, RefererBlacklist: &RefererBlacklist{Referer: []string{"*bad*"}}}
+	c.Assert(SimulateReferer(config, "").Allowed, Equals, true)
+	c.Assert(SimulateReferer(config, "https://good.com/").Allowed, Equals, true)
+	c.Assert(SimulateReferer(config, "https://bad.com/").Allowed, Equals, false)
+}
+
+func (s *OssBucketSimulatorSuite) TestSimulateWebsite(c *C) {
+	pass := true
+	config := WebsiteXML{
+		IndexDocument: IndexDocument{Suffix: "index.html"},
+		ErrorDocument: ErrorDocument{Key: "error.html"},
+		RoutingRules: []RoutingRule{
+			{
+				RuleNumber: 3,
+				Condition:  Condition{KeyPrefixEquals: "img/", HTTPErrorCodeReturnedEquals: 404},
+				Redirect: Redirect{RedirectType: "Mirror", MirrorURL: "https://origin.example.com/", MirrorPassQueryString: &pass,
+					MirrorHeaders: MirrorHeaders{PassAll: &pass, Remove: []string{"Cookie"}, Set: []MirrorHeaderSet{{Key: "X-From", Value: "oss"}}}},
+			},
+			{
+				RuleNumber: 1,
+				Condition:  Condition{KeyPrefixEquals: "old/"},
+				Redirect: Redirect{RedirectType: "External", Protocol: "https", HostName: "new.example.com",
+					ReplaceKeyPrefixWith: "new/", HttpRedirectCode: 301, PassQueryString: &pass},
+			},
+			{
+				RuleNumber: 2,
+				Condition:  Condition{IncludeHeader: []IncludeHeader{{Key: "X-Mobile", Equals: "1"}}},
+				Redirect:   Redirect{RedirectType: "Internal", ReplaceKeyWith: "mobile/${key}"},
+			},
+		},
+	}
+
+	result := SimulateWebsite(config, WebsiteRequest{Key: "old/a.html", Query: "v=1"})
+	c.Assert(result.Action, Equals, WebsiteActionRedirect)
+	c.Assert(result.RuleNumber, Equals, 1)
+	c.Assert(result.StatusCode, Equals, 301)
+	c.Assert(result.Location, Equals, "https://new.example.com/new/a.html?v=1")
+
+	result = SimulateWebsite(config, WebsiteRequest{Key: "a.html", Headers: http.Header{"X-Mobile": {"1"}}})
+	c.Assert(result.Action, Equals, WebsiteActionInternal)
+	c.Assert(result.Key, Equals, "mobile/a.html")
+
+	headers := http.Header{"Cookie": {"a=1"}, "User-Agent": {"test"}}
+	result = SimulateWebsite(config, WebsiteRequest{Key: "img/a.png", Query: "w=1", Headers: headers, StatusCode: 404})
+	c.Assert(result.Action, Equals, WebsiteActionMirror)
+	c.Assert(result.RuleNumber, Equals, 3)
+	c.Assert(result.MirrorURL, Equals, "https://origin.example.com/img/a.png?w=1")
+	c.Assert(result.MirrorHeaders.Get("Cookie"), Equals, "")
+	c.Assert(result.MirrorHeaders.Get("User-Agent"), Equals, "test")
+	c.Assert(result.MirrorHeaders.Get("X-From"), Equals, "oss")
+
+	// The object exists, no rule matches
+	result = SimulateWebsite(config, WebsiteRequest{Key: "img/a.png", StatusCode: 200})
+	c.Assert(result.Action, Equals, WebsiteActionNone)
+	c.Assert(result.Rule, IsNil)
+
+	result = SimulateWebsite(config, WebsiteRequest{Key: "docs/"})
+	c.Assert(result.Action, Equals, WebsiteActionIndexDocument)
+	c.Assert(result.Key, Equals, "docs/index.html")
+
+	result = SimulateWebsite(config, WebsiteRequest{Key: "missing.html", StatusCode: 404})
+	c.Assert(result.Action, Equals, WebsiteActionErrorDocument)
+	c.Assert(result.StatusCode, Equals, 404)
+	c.Assert(result.Key, Equals, "error.html")
+
+	// The default redirect keeps the host and the protocol of the request
+	config.RoutingRules = []RoutingRule{{RuleNumber: 1, Condition: Condition{KeyPrefixEquals: "a/"}, Redirect: Redirect{RedirectType: "External"}}}
+	result = SimulateWebsite(config, WebsiteRequest{Key: "a/b", Query: "x=1", Protocol: "https", Headers: http.Header{"Host": {"www.example.com"}}})
+	c.Assert(result.StatusCode, Equals, 302)
+	c.Assert(result.Location, Equals, "https://www.example.com/a/b")
+}
@@ -0,0 +1,782 @@
+package oss
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Actions of a BucketChange
+const (
+	BucketChangeSet    = "Set"
+	BucketChangeDelete = "Delete"
+)
+
+// Sections of BucketSpec and BucketConfig
+const (
+	BucketSectionACL                  = "ACL"
+	BucketSectionVersioning           = "Versioning"
+	BucketSectionAccessMonitor        = "AccessMonitor"
+	BucketSectionTransferAcceleration = "TransferAcceleration"
+	BucketSectionEncryption           = "Encryption"
+	BucketSectionTags                 = "Tags"
+	BucketSectionLogging              = "Logging"
+	BucketSectionReferer              = "Referer"
+	BucketSectionCORS                 = "CORS"
+	BucketSectionWebsite              = "Website"
+	BucketSectionResponseHeader       = "ResponseHeader"
+	BucketSectionInventory            = "Inventory"
+	BucketSectionLifecycle            = "Lifecycle"
+	BucketSectionPolicy               = "Policy"
+	BucketSectionQoS                  = "QoS"
+	BucketSectionReplication          = "Replication"
+	BucketSectionCname                = "Cname"
+	BucketSectionStyle                = "Style"
+	BucketSectionResourceGroup        = "ResourceGroup"
+	BucketSectionRequestPayment       = "RequestPayment"
+	BucketSectionWorm                 = "Worm"
+)
+
+// The apply order of the sections. The access monitor is enabled before the lifecycle which may transition by
+// access time, and disabled after it. The versioning is set before the lifecycle of the noncurrent versions, and
+// the policy is set after the others since it may deny the following requests. The WORM is set after them since it
+// could not be undone once it's locked, and the CNAMEs are the last since they fail if the ownership of the domains
+// isn't verified.
+const (
+	bucketOrderACL = iota
+	bucketOrderVersioning
+	bucketOrderAccessMonitorEnable
+	bucketOrderEncryption
+	bucketOrderTags
+	bucketOrderResourceGroup
+	bucketOrderRequestPayment
+	bucketOrderQoS
+	bucketOrderTransferAcceleration
+	bucketOrderLogging
+	bucketOrderReferer
+	bucketOrderCORS
+	bucketOrderWebsite
+	bucketOrderResponseHeader
+	bucketOrderStyle
+	bucketOrderInventory
+	bucketOrderLifecycle
+	bucketOrderAccessMonitorDisable
+	bucketOrderReplication
+	bucketOrderPolicy
+	bucketOrderWorm
+	bucketOrderCname
+)
+
+// BucketSpec is the desired configuration of a bucket used by PlanBucket and ApplyBucket.
+// A nil section is not managed, an empty section deletes the configuration.
+type BucketSpec struct {
+	ACL                  *ACLType                 // The bucket ACL
+	Versioning           *string                  // Enabled or Suspended, Suspended equals to never enabled
+	AccessMonitor        *bool                    // Whether the access monitor is enabled
+	TransferAcceleration *bool                    // Whether the transfer acceleration is enabled
+	Encryption           *SSEDefaultRule          // The default encryption, empty SSEAlgorithm deletes it
+	Tags                 []Tag                    // The bucket tags in any order, an empty slice deletes them
+	Logging              *LoggingEnabled          // The logging, empty TargetBucket disables it
+	Referer              *RefererXML              // The hotlink protection
+	CORS                 *CORSXML                 // The CORS rules, no rule deletes them
+	Website              *WebsiteXML              // The static website, empty IndexDocument, ErrorDocument and RoutingRules delete it
+	ResponseHeader       *ResponseHeaderXml       // The response header rules, no rule deletes them
+	Inventories          []InventoryConfiguration // The inventories matched by Id, the others are deleted. An empty slice deletes all.
+	Lifecycle            *LifecycleConfiguration  // The lifecycle rules, no rule deletes them
+	Policy               *PolicyDocument          // The bucket policy, no statement deletes it
+}
+
+// BucketChange is a section to change by ApplyBucketPlan
+type BucketChange struct {
+	Section string      // The section, such as Lifecycle or Inventory/report1
+	Action  string      // BucketChangeSet or BucketChangeDelete
+	Current interface{} // The current configuration, nil if it's not configured
+	Desired interface{} // The desired configuration, nil for BucketChangeDelete
+
+	order int
+	apply func(client Client, bucketName string, options []Option) error
+}
+
+// BucketPlan is the changes from the current configuration of a bucket to a BucketSpec, in the apply order
+type BucketPlan struct {
+	Bucket  string
+	Changes []BucketChange
+}
+
+// IsEmpty checks if the bucket is already configured as the spec
+func (plan BucketPlan) IsEmpty() bool {
+	return len(plan.Changes) == 0
+}
+
+// String lists the changes, such as "Set ACL" per line
+func (plan BucketPlan) String() string {
+	lines := make([]string, 0, len(plan.Changes))
+	for _, change := range plan.Changes {
+		lines = append(lines, change.Action+" "+change.Section)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// BucketApplyError is returned by ApplyBucketPlan when a change fails, the changes before it are applied
+type BucketApplyError struct {
+	Section string // The section failed
+	Applied int    // The count of the changes applied
+	Err     error  // The error of the section
+}
+
+func (e *BucketApplyError) Error() string {
+	return fmt.Sprintf("oss: apply bucket section %s failed after %d changes applied: %s", e.Section, e.Applied, e.Err.Error())
+}
+
+// PlanBucket fetches the current configuration of the managed sections and computes the changes to the spec.
+// The configurations not set, whose getters return 404 errors such as NoSuchLifecycle, are treated as empty.
+// The lifecycle is validated by ValidateLifecycle with the access monitor state after applying.
+//
+// bucketName    the bucket name.
+// spec    the desired configuration.
+//
+// BucketPlan    the changes in the apply order.
+// error    it's nil if no error, otherwise it's an error object.
+func (client Client) PlanBucket(bucketName string, spec BucketSpec, options ...Option) (BucketPlan, error) {
+	plan := BucketPlan{Bucket: bucketName}
+	planners := []func(Client, string, BucketSpec, []Option, *BucketPlan) error{
+		planBucketACL, planBucketVersioning, planBucketAccessMonitor, planBucketEncryption, planBucketTags,
+		planBucketTransferAcceleration, planBucketLogging, planBucketReferer, planBucketCORS, planBucketWebsite,
+		planBucketResponseHeader, planBucketInventories, planBucketLifecycle, planBucketPolicy,
+	}
+	for _, planner := range planners {
+		if err := planner(client, bucketName, spec, options, &plan); err != nil {
+			return BucketPlan{}, err
+		}
+	}
+	sort.Stable(bucketChangesByOrder(plan.Changes))
+	return plan, nil
+}
+
+// ApplyBucket plans the changes by PlanBucket and applies them by ApplyBucketPlan
+//
+// bucketName    the bucket name.
+// spec    the desired configuration.
+//
+// BucketPlan    the changes planned, they're all applied when error is nil.
+// error    it's nil if no error, otherwise it's an error object, *BucketApplyError if a change fails.
+func (client Client) ApplyBucket(bucketName string, spec BucketSpec, options ...Option) (BucketPlan, error) {
+	plan, err := client.PlanBucket(bucketName, spec, options...)
+	if err != nil {
+		return plan, err
+	}
+	return plan, client.ApplyBucketPlan(plan, options...)
+}
+
+// ApplyBucketPlan applies the changes of the plan in order and stops at the first failure
+//
+// plan    the plan returned by PlanBucket.
+//
+// error    it's nil if no error, otherwise it's *BucketApplyError.
+func (client Client) ApplyBucketPlan(plan BucketPlan, options ...Option) error {
+	for i, change := range plan.Changes {
+		if err := change.apply(client, plan.Bucket, options); err != nil {
+			return &BucketApplyError{Section: change.Section, Applied: i, Err: err}
+		}
+	}
+	return nil
+}
+
+type bucketChangesByOrder []BucketChange
+
+func (c bucketChangesByOrder) Len() int           { return len(c) }
+func (c bucketChangesByOrder) Less(i, j int) bool { return c[i].order < c[j].order }
+func (c bucketChangesByOrder) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+
+// isNotConfigured checks if the error means the configuration is not set, such as NoSuchLifecycle
+func isNotConfigured(err error) bool {
+	srvErr, ok := err.(ServiceError)
+	return ok && srvErr.StatusCode == http.StatusNotFound && srvErr.Code != "NoSuchBucket"
+}
+
+func planBucketACL(client Client, bucketName string, spec BucketSpec, options []Option, plan *BucketPlan) error {
+	if spec.ACL == nil {
+		return nil
+	}
+	result, err := client.GetBucketACL(bucketName, options...)
+	if err != nil {
+		return err
+	}
+	if result.ACL == string(*spec.ACL) {
+		return nil
+	}
+	acl := *spec.ACL
+	plan.Changes = append(plan.Changes, BucketChange{Section: BucketSectionACL, Action: BucketChangeSet, Current: ACLType(result.ACL), Desired: acl,
+		order: bucketOrderACL,
+		apply: func(client Client, bucketName string, options []Option) error {
+			return client.SetBucketACL(bucketName, acl, options...)
+		}})
+	return nil
+}
+
+func planBucketVersioning(client Client, bucketName string, spec BucketSpec, options []Option, plan *BucketPlan) error {
+	if spec.Versioning == nil {
+		return nil
+	}
+	result, err := client.GetBucketVersioning(bucketName, options...)
+	if err != nil {
+		return err
+	}
+	status := *spec.Versioning
+	if result.Status == status || (result.Status == "" && status == string(VersionSuspended)) {
+		return nil
+	}
+	plan.Changes = append(plan.Changes, BucketChange{Section: BucketSectionVersioning, Action: BucketChangeSet, Current: result.Status, Desired: status,
+		order: bucketOrderVersioning,
+		apply: func(client Client, bucketName string, options []Option) error {
+			return client.SetBucketVersioning(bucketName, VersioningConfig{Status: status}, options...)
+		}})
+	return nil
+}
+
+// currentAccessMonitor gets whether the access monitor is enabled
+func currentAccessMonitor(client Client, bucketName string, options []Option) (bool, error) {
+	result, err := client.GetBucketAccessMonitor(bucketName, options...)
+	if err != nil {
+		if isNotConfigured(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return result.Status == "Enabled", nil
+}
+
+func planBucketAccessMonitor(client Client, bucketName string, spec BucketSpec, options []Option, plan *BucketPlan) error {
+	if spec.AccessMonitor == nil {
+		return nil
+	}
+	current, err := currentAccessMonitor(client, bucketName, options)
+	if err != nil {
+		return err
+	}
+	enabled := *spec.AccessMonitor
+	if current == enabled {
+		return nil
+	}
+	status, order := "Enabled", bucketOrderAccessMonitorEnable
+	if !enabled {
+		status, order = "Disabled", bucketOrderAccessMonitorDisable
+	}
+	plan.Changes = append(plan.Changes, BucketChange{Section: BucketSectionAccessMonitor, Action: BucketChangeSet, Current: current, Desired: enabled,
+		order: order,
+		apply: func(client Client, bucketName string, options []Option) error {
+			return client.PutBucketAccessMonitor(bucketName, PutBucketAccessMonitor{Status: status}, options...)
+		}})
+	return nil
+}
+
+func planBucketEncryption(client Client, bucketName string, spec BucketSpec, options []Option, plan *BucketPlan) error {
+	if spec.Encryption == nil {
+		return nil
+	}
+	var current *SSEDefaultRule
+	result, err := client.GetBucketEncryption(bucketName, options...)
+	if err == nil {
+		current = &result.SSEDefault
+	} else if !isNotConfigured(err) {
+		return err
+	}
+
+	desired := *spec.Encryption
+	if desired.SSEAlgorithm == "" {
+		if current != nil {
+			plan.Changes = append(plan.Changes, BucketChange{Section: BucketSectionEncryption, Action: BucketChangeDelete, Current: *current,
+				order: bucketOrderEncryption,
+				apply: func(client Client, bucketName string, options []Option) error {
+					return client.DeleteBucketEncryption(bucketName, options...)
+				}})
+		}
+		return nil
+	}
+	if current != nil && xmlEqual(*current, desired) {
+		return nil
+	}
+	change := BucketChange{Section: BucketSectionEncryption, Action: BucketChangeSet, Desired: desired,
+		order: bucketOrderEncryption,
+		apply: func(client Client, bucketName string, options []Option) error {
+			return client.SetBucketEncryption(bucketName, ServerEncryptionRule{SSEDefault: desired}, options...)
+		}}
+	if current != nil {
+		change.Current = *current
+	}
+	plan.Changes = append(plan.Changes, change)
+	return nil
+}
+
+func planBucketTags(client Client, bucketName string, spec BucketSpec, options []Option, plan *BucketPlan) error {
+	if spec.Tags == nil {
+		return nil
+	}
+	result, err := client.GetBucketTagging(bucketName, options...)
+	if err != nil && !isNotConfigured(err) {
+		return err
+	}
+	current, desired := sortedTags(result.Tags), sortedTags(spec.Tags)
+	if xmlEqual(Tagging{Tags: current}, Tagging{Tags: desired}) {
+		return nil
+	}
+	if len(desired) == 0 {
+		plan.Changes = append(plan.Changes, BucketChange{Section: BucketSectionTags, Action: BucketChangeDelete, Current: current,
+			order: bucketOrderTags,
+			apply: func(client Client, bucketName string, options []Option) error {
+				return client.DeleteBucketTagging(bucketName, options...)
+			}})
+		return nil
+	}
+	plan.Changes = append(plan.Changes, BucketChange{Section: BucketSectionTags, Action: BucketChangeSet, Current: current, Desired: desired,
+		order: bucketOrderTags,
+		apply: func(client Client, bucketName string, options []Option) error {
+			return client.SetBucketTagging(bucketName, Tagging{Tags: desired}, options...)
+		}})
+	return nil
+}
+
+func sortedTags(tags []Tag) []Tag {
+	sorted := make([]Tag, 0, len(tags))
+	for _, tag := range tags {
+		sorted = append(sorted, Tag{Key: tag.Key, Value: tag.Value})
+	}
+	for i := 1; i < len(sorted); i++ {
+		for j := i; j > 0 && sorted[j].Key < sorted[j-1].Key; j-- {
+			sorted[j], sorted[j-1] = sorted[j-1], sorted[j]
+		}
+	}
+	return sorted
+}
+
+func planBucketTransferAcceleration(client Client, bucketName string, spec BucketSpec, options []Option, plan *BucketPlan) error {
+	if spec.TransferAcceleration == nil {
+		return nil
+	}
+	current := false
+	result, err := client.GetBucketTransferAcc(bucketName, options...)
+	if err == nil {
+		current = result.Enabled
+	} else if !isNotConfigured(err) {
+		return err
+	}
+	enabled := *spec.TransferAcceleration
+	if current == enabled {
+		return nil
+	}
+	plan.Changes = append(plan.Changes, BucketChange{Section: BucketSectionTransferAcceleration, Action: BucketChangeSet, Current: current, Desired: enabled,
+		order: bucketOrderTransferAcceleration,
+		apply: func(client Client, bucketName string, options []Option) error {
+			return client.SetBucketTransferAcc(bucketName, TransferAccConfiguration{Enabled: enabled}, options...)
+		}})
+	return nil
+}
+
+func planBucketLogging(client Client, bucketName string, spec BucketSpec, options []Option, plan *BucketPlan) error {
+	if spec.Logging == nil {
+		return nil
+	}
+	result, err := client.GetBucketLogging(bucketName, options...)
+	if err != nil && !isNotConfigured(err) {
+		return err
+	}
+	current, desired := result.LoggingEnabled, *spec.Logging
+	if current.TargetBucket == desired.TargetBucket && current.TargetPrefix == desired.TargetPrefix {
+		return nil
+	}
+	if desired.TargetBucket == "" {
+		plan.Changes = append(plan.Changes, BucketChange{Section: BucketSectionLogging, Action: BucketChangeDelete, Current: current,
+			order: bucketOrderLogging,
+			apply: func(client Client, bucketName string, options []Option) error {
+				return client.DeleteBucketLogging(bucketName, options...)
+			}})
+		return nil
+	}
+	change := BucketChange{Section: BucketSectionLogging, Action: BucketChangeSet, Desired: desired,
+		order: bucketOrderLogging,
+		apply: func(client Client, bucketName string, options []Option) error {
+			return client.SetBucketLogging(bucketName, desired.TargetBucket, desired.TargetPrefix, true, options...)
+		}}
+	if current.TargetBucket != "" {
+		change.Current = current
+	}
+	plan.Changes = append(plan.Changes, change)
+	return nil
+}
+
+func planBucketReferer(client Client, bucketName string, spec BucketSpec, options []Option, plan *BucketPlan) error {
+	if spec.Referer == nil {
+		return nil
+	}
+	result, err := client.GetBucketReferer(bucketName, options...)
+	if err != nil {
+		return err
+	}
+	current, desired := RefererXML(result), *spec.Referer
+	if xmlEqual(normalizeReferer(current), normalizeReferer(desired)) {
+		return nil
+	}
+	plan.Changes = append(plan.Changes, BucketChange{Section: BucketSectionReferer, Action: BucketChangeSet, Current: current, Desired: desired,
+		order: bucketOrderReferer,
+		apply: func(client Client, bucketName string, options []Option) error {
+			bs, err := xml.Marshal(desired)
+			if err != nil {
+				return err
+			}
+			return client.PutBucketRefererXml(bucketName, string(bs), options...)
+		}})
+	return nil
+}
+
+// normalizeReferer fills the default of AllowTruncateQueryString, which is true
+func normalizeReferer(referer RefererXML) RefererXML {
+	if referer.AllowTruncateQueryString == nil {
+		truncate := true
+		referer.AllowTruncateQueryString = &truncate
+	}
+	if referer.RefererBlacklist != nil && len(referer.RefererBlacklist.Referer) == 0 {
+		referer.RefererBlacklist = nil
+	}
+	return referer
+}
+
+func planBucketCORS(client Client, bucketName string, spec BucketSpec, options []Option, plan *BucketPlan) error {
+	if spec.CORS == nil {
+		return nil
+	}
+	var current *CORSXML
+	result, err := client.GetBucketCORS(bucketName, options...)
+	if err == nil {
+		cors := CORSXML(result)
+		current = &cors
+	} else if !isNotConfigured(err) {
+		return err
+	}
+
+	desired := *spec.CORS
+	if len(desired.CORSRules) == 0 {
+		if current != nil {
+			plan.Changes = append(plan.Changes, BucketChange{Section: BucketSectionCORS, Action: BucketChangeDelete, Current: *current,
+				order: bucketOrderCORS,
+				apply: func(client Client, bucketName string, options []Option) error {
+					return client.DeleteBucketCORS(bucketName, options...)
+				}})
+		}
+		return nil
+	}
+	if current != nil && xmlEqual(normalizeCORS(*current), normalizeCORS(desired)) {
+		return nil
+	}
+	change := BucketChange{Section: BucketSectionCORS, Action: BucketChangeSet, Desired: desired,
+		order: bucketOrderCORS,
+		apply: func(client Client, bucketName string, options []Option) error {
+			return client.SetBucketCORSV2(bucketName, PutBucketCORS(desired), options...)
+		}}
+	if current != nil {
+		change.Current = *current
+	}
+	plan.Changes = append(plan.Changes, change)
+	return nil
+}
+
+// normalizeCORS fills the default of ResponseVary, which is false
+func normalizeCORS(cors CORSXML) CORSXML {
+	if cors.ResponseVary == nil {
+		vary := false
+		cors.ResponseVary = &vary
+	}
+	return cors
+}
+
+func planBucketWebsite(client Client, bucketName string, spec BucketSpec, options []Option, plan *BucketPlan) error {
+	if spec.Website == nil {
+		return nil
+	}
+	var current *WebsiteXML
+	result, err := client.GetBucketWebsite(bucketName, options...)
+	if err == nil {
+		website := WebsiteXML(result)
+		current = &website
+	} else if !isNotConfigured(err) {
+		return err
+	}
+
+	desired := *spec.Website
+	if desired.IndexDocument.Suffix == "" && desired.ErrorDocument.Key == "" && len(desired.RoutingRules) == 0 {
+		if current != nil {
+			plan.Changes = append(plan.Changes, BucketChange{Section: BucketSectionWebsite, Action: BucketChangeDelete, Current: *current,
+				order: bucketOrderWebsite,
+				apply: func(client Client, bucketName string, options []Option) error {
+					return client.DeleteBucketWebsite(bucketName, options...)
+				}})
+		}
+		return nil
+	}
+	if current != nil && xmlEqual(normalizeWebsite(*current), normalizeWebsite(desired)) {
+		return nil
+	}
+	change := BucketChange{Section: BucketSectionWebsite, Action: BucketChangeSet, Desired: desired,
+		order: bucketOrderWebsite,
+		apply: func(client Client, bucketName string, options []Option) error {
+			return client.SetBucketWebsiteDetail(bucketName, desired, options...)
+		}}
+	if current != nil {
+		change.Current = *current
+	}
+	plan.Changes = append(plan.Changes, change)
+	return nil
+}
+
+// normalizeWebsite fills the defaults of the redirects, MirrorFollowRedirect is true and the others are false
+func normalizeWebsite(website WebsiteXML) WebsiteXML {
+	rules := make([]RoutingRule, len(website.RoutingRules))
+	for i, rule := range website.RoutingRules {
+		redirect := &rule.Redirect
+		for _, b := range []**bool{&redirect.PassQueryString, &redirect.MirrorPassQueryString, &redirect.MirrorCheckMd5, &redirect.MirrorHeaders.PassAll} {
+			if *b == nil {
+				value := false
+				*b = &value
+			}
+		}
+		if redirect.MirrorFollowRedirect == nil {
+			value := true
+			redirect.MirrorFollowRedirect = &value
+		}
+		rules[i] = rule
+	}
+	website.RoutingRules = rules
+	return website
+}
+
+func planBucketResponseHeader(client Client, bucketName string, spec BucketSpec, options []Option, plan *BucketPlan) error {
+	if spec.ResponseHeader == nil {
+		return nil
+	}
+	var current *ResponseHeaderXml
+	result, err := client.GetBucketResponseHeader(bucketName, options...)
+	if err == nil && len(result.Rule) > 0 {
+		header := ResponseHeaderXml(result)
+		current = &header
+	} else if err != nil && !isNotConfigured(err) {
+		return err
+	}
+
+	desired := *spec.ResponseHeader
+	if len(desired.Rule) == 0 {
+		if current != nil {
+			plan.Changes = append(plan.Changes, BucketChange{Section: BucketSectionResponseHeader, Action: BucketChangeDelete, Current: *current,
+				order: bucketOrderResponseHeader,
+				apply: func(client Client, bucketName string, options []Option) error {
+					return client.DeleteBucketResponseHeader(bucketName, options...)
+				}})
+		}
+		return nil
+	}
+	if current != nil && xmlEqual(*current, desired) {
+		return nil
+	}
+	change := BucketChange{Section: BucketSectionResponseHeader, Action: BucketChangeSet, Desired: desired,
+		order: bucketOrderResponseHeader,
+		apply: func(client Client, bucketName string, options []Option) error {
+			return client.PutBucketResponseHeader(bucketName, PutBucketResponseHeader(desired), options...)
+		}}
+	if current != nil {
+		change.Current = *current
+	}
+	plan.Changes = append(plan.Changes, change)
+	return nil
+}
+
+// listBucketInventories lists all the inventories of the bucket
+func listBucketInventories(client Client, bucketName string, options []Option) ([]InventoryConfiguration, error) {
+	var inventories []InventoryConfiguration
+	token := ""
+	for {
+		result, err := client.ListBucketInventory(bucketName, token, options...)
+		if err != nil {
+			if isNotConfigured(err) {
+				return inventories, nil
+			}
+			return nil, err
+		}
+		inventories = append(inventories, result.InventoryConfiguration...)
+		if result.IsTruncated == nil || !*result.IsTruncated || result.NextContinuationToken == "" {
+			return inventories, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+func planBucketInventories(client Client, bucketName string, spec BucketSpec, options []Option, plan *BucketPlan) error {
+	if spec.Inventories == nil {
+		return nil
+	}
+	inventories, err := listBucketInventories(client, bucketName, options)
+	if err != nil {
+		return err
+	}
+	current := map[string]InventoryConfiguration{}
+	for _, inventory := range inventories {
+		current[inventory.Id] = inventory
+	}
+
+	desiredIDs := map[string]bool{}
+	for _, inventory := range spec.Inventories {
+		if inventory.Id == "" {
+			return fmt.Errorf("oss: the Id of the inventory is empty")
+		}
+		if desiredIDs[inventory.Id] {
+			return fmt.Errorf("oss: duplicate inventory Id %s", inventory.Id)
+		}
+		desiredIDs[inventory.Id] = true
+
+		existing, ok := current[inventory.Id]
+		if ok && xmlEqual(existing, inventory) {
+			continue
+		}
+		desired := inventory
+		change := BucketChange{Section: BucketSectionInventory + "/" + inventory.Id, Action: BucketChangeSet, Desired: desired,
+			order: bucketOrderInventory,
+			apply: func(client Client, bucketName string, options []Option) error {
+				// An inventory could not be overwritten, it's deleted first
+				if ok {
+					if err := client.DeleteBucketInventory(bucketName, desired.Id, options...); err != nil {
+						return err
+					}
+				}
+				return client.SetBucketInventory(bucketName, desired, options...)
+			}}
+		if ok {
+			change.Current = existing
+		}
+		plan.Changes = append(plan.Changes, change)
+	}
+
+	for _, inventory := range inventories {
+		if desiredIDs[inventory.Id] {
+			continue
+		}
+		id := inventory.Id
+		plan.Changes = append(plan.Changes, BucketChange{Section: BucketSectionInventory + "/" + id, Action: BucketChangeDelete, Current: inventory,
+			order: bucketOrderInventory,
+			apply: func(client Client, bucketName string, options []Option) error {
+				return client.DeleteBucketInventory(bucketName, id, options...)
+			}})
+	}
+	return nil
+}
+
+func planBucketLifecycle(client Client, bucketName string, spec BucketSpec, options []Option, plan *BucketPlan) error {
+	if spec.Lifecycle == nil {
+		return nil
+	}
+	var current *LifecycleConfiguration
+	result, err := client.GetBucketLifecycle(bucketName, options...)
+	if err == nil {
+		lifecycle := LifecycleConfiguration(result)
+		current = &lifecycle
+	} else if !isNotConfigured(err) {
+		return err
+	}
+
+	desired := *spec.Lifecycle
+	if len(desired.Rules) == 0 {
+		if current != nil {
+			plan.Changes = append(plan.Changes, BucketChange{Section: BucketSectionLifecycle, Action: BucketChangeDelete, Current: *current,
+				order: bucketOrderLifecycle,
+				apply: func(client Client, bucketName string, options []Option) error {
+					return client.DeleteBucketLifecycle(bucketName, options...)
+				}})
+		}
+		return nil
+	}
+
+	accessMonitor := false
+	if spec.AccessMonitor != nil {
+		accessMonitor = *spec.AccessMonitor
+	} else if accessMonitor, err = currentAccessMonitor(client, bucketName, options); err != nil {
+		return err
+	}
+	if err = ValidateLifecycle(desired, accessMonitor); err != nil {
+		return err
+	}
+
+	if current != nil && DiffLifecycle(*current, desired).IsEmpty() {
+		return nil
+	}
+	change := BucketChange{Section: BucketSectionLifecycle, Action: BucketChangeSet, Desired: desired,
+		order: bucketOrderLifecycle,
+		apply: func(client Client, bucketName string, options []Option) error {
+			return client.SetBucketLifecycle(bucketName, desired.Rules, options...)
+		}}
+	if current != nil {
+		change.Current = *current
+	}
+	plan.Changes = append(plan.Changes, change)
+	return nil
+}
+
+func planBucketPolicy(client Client, bucketName string, spec BucketSpec, options []Option, plan *BucketPlan) error {
+	if spec.Policy == nil {
+		return nil
+	}
+	var current *PolicyDocument
+	policy, err := client.GetBucketPolicy(bucketName, options...)
+	if err == nil && strings.TrimSpace(policy) != "" {
+		doc, err := ParsePolicy(policy)
+		if err != nil {
+			return err
+		}
+		current = &doc
+	} else if err != nil && !isNotConfigured(err) {
+		return err
+	}
+
+	desired := *spec.Policy
+	if len(desired.Statement) == 0 {
+		if current != nil {
+			plan.Changes = append(plan.Changes, BucketChange{Section: BucketSectionPolicy, Action: BucketChangeDelete, Current: *current,
+				order: bucketOrderPolicy,
+				apply: func(client Client, bucketName string, options []Option) error {
+					return client.DeleteBucketPolicy(bucketName, options...)
+				}})
+		}
+		return nil
+	}
+	if err := desired.Validate(); err != nil {
+		return err
+	}
+	if current != nil && policyEqual(*current, desired) {
+		return nil
+	}
+	change := BucketChange{Section: BucketSectionPolicy, Action: BucketChangeSet, Desired: desired,
+		order: bucketOrderPolicy,
+		apply: func(client Client, bucketName string, options []Option) error {
+			return client.SetBucketPolicyDocument(bucketName, desired, options...)
+		}}
+	if current != nil {
+		change.Current = *current
+	}
+	plan.Changes = append(plan.Changes, change)
+	return nil
+}
+
+// policyEqual compares the policies by their values, the forms such as a string or an array are ignored
+func policyEqual(a, b PolicyDocument) bool {
+	canonical := func(doc PolicyDocument) ([]byte, error) {
+		statements := make([]PolicyStatement, len(doc.Statement))
+		for i, s := range doc.Statement {
+			s.raw = nil
+			statements[i] = s
+		}
+		doc.Statement = statements
+		return json.Marshal(doc)
+	}
+	ja, erra := canonical(a)
+	jb, errb := canonical(b)
+	return erra == nil && errb == nil && bytes.Equal(ja, jb)
+}
@@ -0,0 +1,214 @@
+package oss
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+type OssBucketSpecSuite struct{}
+
+var _ = Suite(&OssBucketSpecSuite{})
+
+// bucketSpecServer keeps the configurations of a bucket by the subresource and logs the modifications
+type bucketSpecServer struct {
+	mu          sync.Mutex
+	configs     map[string]string
+	inventories map[string]string
+	log         []string
+	fail        string
+}
+
+func newBucketSpecServer() *bucketSpecServer {
+	return &bucketSpecServer{
+		configs: map[string]string{
+			"acl":        bucketSpecACL("private"),
+			"versioning": "<VersioningConfiguration></VersioningConfiguration>",
+			"referer":    "<RefererConfiguration><AllowEmptyReferer>true</AllowEmptyReferer><RefererList></RefererList></RefererConfiguration>",
+		},
+		inventories: map[string]string{},
+	}
+}
+
+func bucketSpecACL(acl string) string {
+	return "<AccessControlPolicy><Owner><ID>1</ID></Owner><AccessControlList><Grant>" + acl + "</Grant></AccessControlList></AccessControlPolicy>"
+}
+
+func (s *bucketSpecServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query := r.URL.Query()
+	subresource := ""
+	for k := range query {
+		if k != "inventoryId" && k != "continuation-token" && k != "comp" && k != "styleName" {
+			subresource = k
+		}
+	}
+	if r.Method != "GET" {
+		s.log = append(s.log, r.Method+" "+subresource)
+	}
+	if r.Method+" "+subresource == s.fail {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("<Error><Code>InternalError</Code></Error>"))
+		return
+	}
+
+	body, _ := ioutil.ReadAll(r.Body)
+	switch {
+	case subresource == "inventory" && r.Method == "GET":
+		ids := []string{}
+		for id := range s.inventories {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		list := "<ListInventoryConfigurationsResult>"
+		for _, id := range ids {
+			list += s.inventories[id]
+		}
+		w.Write([]byte(list + "<IsTruncated>false</IsTruncated></ListInventoryConfigurationsResult>"))
+	case subresource == "inventory" && r.Method == "PUT":
+		s.inventories[query.Get("inventoryId")] = string(body)
+	case subresource == "inventory" && r.Method == "DELETE":
+		delete(s.inventories, query.Get("inventoryId"))
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == "GET":
+		config, ok := s.configs[subresource]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(fmt.Sprintf("<Error><Code>NoSuch%s</Code></Error>", strings.Title(subresource))))
+			return
+		}
+		w.Write([]byte(config))
+	case r.Method == "POST" && subresource == "worm":
+		w.Header().Set("x-oss-worm-id", "worm-1")
+		var initiate InitiateWormConfiguration
+		xml.Unmarshal(body, &initiate)
+		s.configs["worm"] = fmt.Sprintf("<WormConfiguration><WormId>worm-1</WormId><State>InProgress</State>"+
+			"<RetentionPeriodInDays>%d</RetentionPeriodInDays></WormConfiguration>", initiate.RetentionPeriodInDays)
+	case r.Method == "POST" && subresource == "wormId":
+		s.configs["worm"] = strings.Replace(s.configs["worm"], "InProgress", "Locked", 1)
+	case r.Method == "POST" && subresource == "cname":
+		var cname CnameConfigurationXML
+		xml.Unmarshal(body, &cname)
+		list := strings.TrimSuffix(s.configs["cname"], "</ListCnameResult>")
+		if list == "" {
+			list = "<ListCnameResult>"
+		}
+		s.configs["cname"] = list + "<Cname><Domain>" + cname.Domain + "</Domain></Cname></ListCnameResult>"
+	case r.Method == "PUT" && subresource == "acl":
+		s.configs["acl"] = bucketSpecACL(r.Header.Get(HTTPHeaderOssACL))
+	case r.Method == "PUT" || r.Method == "POST":
+		s.configs[subresource] = string(body)
+	case r.Method == "DELETE":
+		delete(s.configs, subresource)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func bucketSpecForTest(c *C) BucketSpec {
+	acl, enabled, suspended := ACLPublicRead, true, string(VersionSuspended)
+	rule, err := NewLifecycleRule("cold", "logs/").TransitionAfterAccessDays(30, StorageIA, false).ExpireAfterDays(365).Build()
+	c.Assert(err, IsNil)
+	policy, err := NewPolicyBuilder().DenyInsecureTransport("bucket").Build()
+	c.Assert(err, IsNil)
+	return BucketSpec{
+		ACL:           &acl,
+		Versioning:    &suspended,
+		AccessMonitor: &enabled,
+		Tags:          []Tag{{Key: "team", Value: "b"}, {Key: "env", Value: "prod"}},
+		CORS:          &CORSXML{},
+		Inventories: []InventoryConfiguration{{Id: "report", IsEnabled: &enabled, Frequency: "Daily", IncludedObjectVersions: "All",
+			OSSBucketDestination: OSSBucketDestination{Format: "CSV", AccountId: "1", RoleArn: "acs:ram::1:role/r", Bucket: "acs:oss:::dest"}}},
+		Lifecycle: &LifecycleConfiguration{Rules: []LifecycleRule{rule}},
+		Policy:    &policy,
+	}
+}
+
+func (s *OssBucketSpecSuite) TestPlanAndApplyBucket(c *C) {
+	state := newBucketSpecServer()
+	state.inventories["old"] = "<InventoryConfiguration><Id>old</Id></InventoryConfiguration>"
+	server := httptest.NewServer(state)
+	defer server.Close()
+	client, err := New(server.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+
+	spec := bucketSpecForTest(c)
+	plan, err := client.PlanBucket("bucket", spec)
+	c.Assert(err, IsNil)
+	c.Assert(plan.Bucket, Equals, "bucket")
+	// The versioning never enabled is suspended and the CORS not configured is empty, the access monitor
+	// is enabled before the lifecycle and the policy is set last
+	c.Assert(plan.String(), Equals, strings.Join([]string{"Set ACL", "Set AccessMonitor", "Set Tags",
+		"Set Inventory/report", "Delete Inventory/old", "Set Lifecycle", "Set Policy"}, "\n"))
+	c.Assert(plan.Changes[0].Current, Equals, ACLPrivate)
+	c.Assert(plan.Changes[0].Desired, Equals, ACLPublicRead)
+	c.Assert(plan.Changes[3].Current, IsNil)
+
+	c.Assert(client.ApplyBucketPlan(plan), IsNil)
+	c.Assert(state.log, DeepEquals, []string{"PUT acl", "PUT accessmonitor", "PUT tagging", "PUT inventory",
+		"DELETE inventory", "PUT lifecycle", "PUT policy"})
+
+	// Applied, the tags in another order and the policy in the stored form are unchanged
+	spec.Tags = []Tag{{Key: "env", Value: "prod"}, {Key: "team", Value: "b"}}
+	plan, err = client.PlanBucket("bucket", spec)
+	c.Assert(err, IsNil)
+	c.Assert(plan.IsEmpty(), Equals, true, Commentf("%s", plan.String()))
+
+	// Disabling the access monitor after removing the lifecycle, the sections not managed are kept
+	disabled := false
+	state.log = nil
+	plan, err = client.ApplyBucket("bucket", BucketSpec{AccessMonitor: &disabled, Lifecycle: &LifecycleConfiguration{},
+		Tags: []Tag{}, Policy: &PolicyDocument{}})
+	c.Assert(err, IsNil)
+	c.Assert(state.log, DeepEquals, []string{"DELETE tagging", "DELETE lifecycle", "PUT accessmonitor", "DELETE policy"})
+	c.Assert(plan.Changes[0].Action, Equals, BucketChangeDelete)
+	c.Assert(len(state.inventories), Equals, 1)
+}
+
+func (s *OssBucketSpecSuite) TestPlanBucketErrors(c *C) {
+	state := newBucketSpecServer()
+	server := httptest.NewServer(state)
+	defer server.Close()
+	client, err := New(server.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+
+	// The access-time transition requires the access monitor, which is disabled and not managed
+	spec := bucketSpecForTest(c)
+	spec.AccessMonitor = nil
+	_, err = client.PlanBucket("bucket", spec)
+	c.Assert(err, NotNil)
+	c.Assert(state.log, IsNil)
+
+	spec.Inventories = append(spec.Inventories, spec.Inventories[0])
+	spec.AccessMonitor = new(bool)
+	*spec.AccessMonitor = true
+	_, err = client.PlanBucket("bucket", spec)
+	c.Assert(err, NotNil)
+
+	// The failure stops applying, the changes before it are applied
+	spec = bucketSpecForTest(c)
+	state.fail = "PUT tagging"
+	plan, err := client.ApplyBucket("bucket", spec)
+	c.Assert(len(plan.Changes), Equals, 6)
+	applyErr, ok := err.(*BucketApplyError)
+	c.Assert(ok, Equals, true)
+	c.Assert(applyErr.Section, Equals, "Tags")
+	c.Assert(applyErr.Applied, Equals, 2)
+	c.Assert(applyErr.Err.(ServiceError).StatusCode, Equals, http.StatusInternalServerError)
+	c.Assert(state.log, DeepEquals, []string{"PUT acl", "PUT accessmonitor", "PUT tagging"})
+
+	// Deleting the website not configured is no change, the missing bucket isn't treated as not configured
+	plan, err = client.PlanBucket("bucket", BucketSpec{Website: &WebsiteXML{}})
+	c.Assert(err, IsNil)
+	c.Assert(plan.IsEmpty(), Equals, true)
+	c.Assert(isNotConfigured(ServiceError{StatusCode: http.StatusNotFound, Code: "NoSuchBucket"}), Equals, false)
+	c.Assert(isNotConfigured(ServiceError{StatusCode: http.StatusNotFound, Code: "NoSuchWebsiteConfiguration"}), Equals, true)
+}
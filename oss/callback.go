@@ -0,0 +1,376 @@
+package oss
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/md5"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// System variables which could be used in the callback body template
+const (
+	CallbackVarBucket      = "${bucket}"
+	CallbackVarObject      = "${object}"
+	CallbackVarETag        = "${etag}"
+	CallbackVarSize        = "${size}"
+	CallbackVarMimeType    = "${mimeType}"
+	CallbackVarImageHeight = "${imageInfo.height}"
+	CallbackVarImageWidth  = "${imageInfo.width}"
+	CallbackVarImageFormat = "${imageInfo.format}"
+	CallbackVarCRC64       = "${crc64}"
+	CallbackVarContentMD5  = "${contentMd5}"
+	CallbackVarVpcId       = "${vpcId}"
+	CallbackVarClientIp    = "${clientIp}"
+	CallbackVarReqId       = "${reqId}"
+	CallbackVarOperation   = "${operation}"
+)
+
+// Callback body types
+const (
+	CallbackBodyTypeForm = "application/x-www-form-urlencoded"
+	CallbackBodyTypeJSON = "application/json"
+)
+
+const maxCallbackURLs = 5
+
+// CallbackConfig defines the callback sent by OSS after an upload completes
+type CallbackConfig struct {
+	URLs     []string // Callback URLs, at most 5, OSS tries them in order until one succeeds
+	Host     string   // The Host header of the callback request, default is the host of the URL
+	Body     string   // The callback body template, such as "bucket=${bucket}&object=${object}&my_var=${x:my_var}"
+	BodyType string   // CallbackBodyTypeForm or CallbackBodyTypeJSON, default is CallbackBodyTypeForm
+	SNI      bool     // Whether to send the SNI to the callback server
+}
+
+// callbackConfigJSON is the JSON form of CallbackConfig in the x-oss-callback header
+type callbackConfigJSON struct {
+	CallbackURL      string `json:"callbackUrl"`
+	CallbackHost     string `json:"callbackHost,omitempty"`
+	CallbackBody     string `json:"callbackBody"`
+	CallbackBodyType string `json:"callbackBodyType,omitempty"`
+	CallbackSNI      bool   `json:"callbackSNI,omitempty"`
+}
+
+// Encode validates the config and encodes it to the value of the x-oss-callback header
+func (config CallbackConfig) Encode() (string, error) {
+	if len(config.URLs) == 0 {
+		return "", fmt.Errorf("oss: callback url is empty")
+	}
+	if len(config.URLs) > maxCallbackURLs {
+		return "", fmt.Errorf("oss: too many callback urls, max is %d", maxCallbackURLs)
+	}
+	for _, u := range config.URLs {
+		if u == "" || strings.Contains(u, ";") {
+			return "", fmt.Errorf("oss: invalid callback url %q", u)
+		}
+	}
+	if config.Body == "" {
+		return "", fmt.Errorf("oss: callback body is empty")
+	}
+	if config.BodyType != "" && config.BodyType != CallbackBodyTypeForm && config.BodyType != CallbackBodyTypeJSON {
+		return "", fmt.Errorf("oss: invalid callback body type %s", config.BodyType)
+	}
+
+	return encodeCallbackJSON(callbackConfigJSON{
+		CallbackURL:      strings.Join(config.URLs, ";"),
+		CallbackHost:     config.Host,
+		CallbackBody:     config.Body,
+		CallbackBodyType: config.BodyType,
+		CallbackSNI:      config.SNI,
+	})
+}
+
+// EncodeCallbackVars validates the custom variables and encodes them to the value of the x-oss-callback-var header.
+// The keys must start with "x:" and be lower case, such as "x:my_var".
+func EncodeCallbackVars(vars map[string]string) (string, error) {
+	for k := range vars {
+		if !strings.HasPrefix(k, "x:") || len(k) == 2 || strings.ToLower(k) != k {
+			return "", fmt.Errorf("oss: invalid callback var %s, it must be lower case and start with x:", k)
+		}
+	}
+	return encodeCallbackJSON(vars)
+}
+
+// encodeCallbackJSON encodes v to base64 JSON without escaping HTML characters such as '&'
+func encodeCallbackJSON(v interface{}) (string, error) {
+	buf := bytes.NewBuffer([]byte{})
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(bytes.TrimRight(buf.Bytes(), "\n")), nil
+}
+
+// WithCallback is an option to set the x-oss-callback header from a CallbackConfig
+func WithCallback(config CallbackConfig) Option {
+	return func(params map[string]optionValue) error {
+		value, err := config.Encode()
+		if err != nil {
+			return err
+		}
+		params[HTTPHeaderOssCallback] = optionValue{value, optionHTTP}
+		return nil
+	}
+}
+
+// WithCallbackVars is an option to set the x-oss-callback-var header from the custom variables
+func WithCallbackVars(vars map[string]string) Option {
+	return func(params map[string]optionValue) error {
+		value, err := EncodeCallbackVars(vars)
+		if err != nil {
+			return err
+		}
+		params[HTTPHeaderOssCallbackVar] = optionValue{value, optionHTTP}
+		return nil
+	}
+}
+
+// callbackPubKeyURLPrefix is the only location OSS publishes its callback public keys, the keys are fetched over https
+const callbackPubKeyURLPrefix = "https://gosspublic.alicdn.com/"
+
+// callbackPubKeyHTTPPrefix is the location in x-oss-pub-key-url, it's rewritten to callbackPubKeyURLPrefix
+const callbackPubKeyHTTPPrefix = "http://gosspublic.alicdn.com/"
+
+const (
+	maxCallbackBodySize   = 1024 * 1024 // The max size of the callback body read by Verify
+	maxCallbackPubKeySize = 64 * 1024   // The max size of the public key downloaded
+)
+
+// callbackPubKeyClient downloads the public keys, the timeout keeps a stalled download from blocking the callbacks
+var callbackPubKeyClient = &http.Client{Timeout: 10 * time.Second}
+
+// CallbackVerifier verifies the signature of the callback requests sent by OSS
+type CallbackVerifier struct {
+	// FetchPublicKey downloads the PEM public key, default is an HTTPS GET restricted to gosspublic.alicdn.com.
+	// The http URLs of gosspublic.alicdn.com are rewritten to https before they're passed.
+	FetchPublicKey func(pubKeyURL string) ([]byte, error)
+
+	mu   sync.Mutex
+	keys map[string]*rsa.PublicKey
+}
+
+// NewCallbackVerifier creates a CallbackVerifier which fetches the public keys from OSS
+func NewCallbackVerifier() *CallbackVerifier {
+	return &CallbackVerifier{FetchPublicKey: fetchCallbackPublicKey}
+}
+
+// Verify reads the body of the callback request and checks its signature.
+//
+// r    the callback request, its body is consumed, the body larger than 1MB is rejected.
+//
+// []byte    the callback body.
+// error    it's nil if the signature is valid, otherwise it's an error object.
+func (v *CallbackVerifier) Verify(r *http.Request) ([]byte, error) {
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxCallbackBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxCallbackBodySize {
+		return nil, fmt.Errorf("oss: callback body exceeds %d bytes", maxCallbackBodySize)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(r.Header.Get(HTTPHeaderAuthorization))
+	if err != nil || len(signature) == 0 {
+		return body, fmt.Errorf("oss: invalid callback authorization")
+	}
+
+	pubKeyURL, err := base64.StdEncoding.DecodeString(r.Header.Get(HTTPHeaderOssPubKeyURL))
+	if err != nil || len(pubKeyURL) == 0 {
+		return body, fmt.Errorf("oss: invalid callback x-oss-pub-key-url")
+	}
+
+	pubKey, err := v.publicKey(string(pubKeyURL))
+	if err != nil {
+		return body, err
+	}
+
+	path, err := url.PathUnescape(r.URL.EscapedPath())
+	if err != nil {
+		return body, err
+	}
+	authStr := path
+	if r.URL.RawQuery != "" {
+		authStr += "?" + r.URL.RawQuery
+	}
+	authStr += "\n" + string(body)
+
+	digest := md5.Sum([]byte(authStr))
+	if err = rsa.VerifyPKCS1v15(pubKey, crypto.MD5, digest[:], signature); err != nil {
+		return body, fmt.Errorf("oss: callback signature mismatch")
+	}
+	return body, nil
+}
+
+// Handler returns an http.Handler which verifies the callback requests before calling next.
+// The invalid requests are rejected with 400 Bad Request.
+func (v *CallbackVerifier) Handler(next func(w http.ResponseWriter, r *http.Request, body []byte)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := v.Verify(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		next(w, r, body)
+	})
+}
+
+// publicKey returns the cached public key or fetches it, the lock isn't held while fetching
+func (v *CallbackVerifier) publicKey(pubKeyURL string) (*rsa.PublicKey, error) {
+	if strings.HasPrefix(pubKeyURL, callbackPubKeyHTTPPrefix) {
+		pubKeyURL = callbackPubKeyURLPrefix + pubKeyURL[len(callbackPubKeyHTTPPrefix):]
+	}
+
+	v.mu.Lock()
+	key, ok := v.keys[pubKeyURL]
+	v.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	fetch := v.FetchPublicKey
+	if fetch == nil {
+		fetch = fetchCallbackPublicKey
+	}
+	data, err := fetch(pubKeyURL)
+	if err != nil {
+		return nil, err
+	}
+	key, err = parseCallbackPublicKey(data)
+	if err != nil {
+		return nil, err
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.keys == nil {
+		v.keys = map[string]*rsa.PublicKey{}
+	}
+	v.keys[pubKeyURL] = key
+	return key, nil
+}
+
+// fetchCallbackPublicKey downloads the public key from gosspublic.alicdn.com over https
+func fetchCallbackPublicKey(pubKeyURL string) ([]byte, error) {
+	if !strings.HasPrefix(pubKeyURL, callbackPubKeyURLPrefix) {
+		return nil, fmt.Errorf("oss: untrusted callback public key url %s", pubKeyURL)
+	}
+
+	resp, err := callbackPubKeyClient.Get(pubKeyURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("oss: fetch callback public key failed, status code %d", resp.StatusCode)
+	}
+	return ioutil.ReadAll(io.LimitReader(resp.Body, maxCallbackPubKeySize))
+}
+
+// parseCallbackPublicKey parses a PKIX or PKCS1 PEM encoded RSA public key
+func parseCallbackPublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("oss: invalid callback public key")
+	}
+	if pub, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		if key, ok := pub.(*rsa.PublicKey); ok {
+			return key, nil
+		}
+		return nil, fmt.Errorf("oss: callback public key is not RSA")
+	}
+	return x509.ParsePKCS1PublicKey(block.Bytes)
+}
+
+// DecodeCallbackBody decodes the callback body into v, which must be a pointer.
+// JSON bodies are decoded by encoding/json. Form bodies are decoded into the
+// string, bool and numeric fields of a struct using the names of the json tags.
+//
+// contentType    the Content-Type header of the callback request.
+// body    the callback body returned by CallbackVerifier.Verify.
+// v    the pointer to the result.
+//
+// error    it's nil if no error, otherwise it's an error object.
+func DecodeCallbackBody(contentType string, body []byte, v interface{}) error {
+	if strings.HasPrefix(strings.ToLower(contentType), CallbackBodyTypeJSON) {
+		return json.Unmarshal(body, v)
+	}
+
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("oss: callback body must be decoded into a struct pointer")
+	}
+	rv = rv.Elem()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" || field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if _, ok := values[name]; !ok {
+			continue
+		}
+		if err := setCallbackField(rv.Field(i), values.Get(name)); err != nil {
+			return fmt.Errorf("oss: decode callback field %s failed, %s", name, err.Error())
+		}
+	}
+	return nil
+}
+
+// setCallbackField sets the string value to a struct field
+func setCallbackField(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package oss
+
+import (
+	"context"
+	"crypto"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type OssCallbackSuite struct{}
+
+var _ = Suite(&OssCallbackSuite{})
+
+func (s *OssCallbackSuite) TestCallbackConfigEncode(c *C) {
+	config := CallbackConfig{
+		URLs:     []string{"http://example.com:23450", "https://backup.example.com/cb"},
+		Host:     "example.com",
+		Body:     "bucket=" + CallbackVarBucket + "&object=" + CallbackVarObject + "&my_var=${x:my_var}",
+		BodyType: CallbackBodyTypeForm,
+	}
+	value, err := config.Encode()
+	c.Assert(err, IsNil)
+
+	data, err := base64.StdEncoding.DecodeString(value)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(data), "&object="), Equals, true)
+	decoded := map[string]interface{}{}
+	c.Assert(json.Unmarshal(data, &decoded), IsNil)
+	c.Assert(decoded["callbackUrl"], Equals, "http://example.com:23450;https://backup.example.com/cb")
+	c.Assert(decoded["callbackHost"], Equals, "example.com")
+	c.Assert(decoded["callbackBody"], Equals, "bucket=${bucket}&object=${object}&my_var=${x:my_var}")
+	c.Assert(decoded["callbackBodyType"], Equals, CallbackBodyTypeForm)
+	_, ok := decoded["callbackSNI"]
+	c.Assert(ok, Equals, false)
+
+	headers := map[string]string{}
+	err = handleOptions(headers, []Option{WithCallback(config), WithCallbackVars(map[string]string{"x:my_var": "v"})})
+	c.Assert(err, IsNil)
+	c.Assert(headers[HTTPHeaderOssCallback], Equals, value)
+	data, err = base64.StdEncoding.DecodeString(headers[HTTPHeaderOssCallbackVar])
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "{\"x:my_var\":\"v\"}")
+
+	// invalid configs
+	_, err = CallbackConfig{Body: "a=b"}.Encode()
+	c.Assert(err, NotNil)
+	_, err = CallbackConfig{URLs: []string{"1", "2", "3", "4", "5", "6"}, Body: "a=b"}.Encode()
+	c.Assert(err, NotNil)
+	_, err = CallbackConfig{URLs: []string{"http://example.com"}}.Encode()
+	c.Assert(err, NotNil)
+	_, err = CallbackConfig{URLs: []string{"http://example.com"}, Body: "a=b", BodyType: "text/plain"}.Encode()
+	c.Assert(err, NotNil)
+	_, err = EncodeCallbackVars(map[string]string{"my_var": "v"})
+	c.Assert(err, NotNil)
+	_, err = EncodeCallbackVars(map[string]string{"x:My_var": "v"})
+	c.Assert(err, NotNil)
+}
+
+func (s *OssCallbackSuite) TestCallbackVerifier(c *C) {
+	priv, err := rsa.GenerateKey(rand.Reader, 1024)
+	c.Assert(err, IsNil)
+	pubDER, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	c.Assert(err, IsNil)
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})
+
+	// OSS sends the http URL, the key is fetched over https
+	pubKeyURL := "http://gosspublic.alicdn.com/callback_pub_key_v1.pem"
+	var fetched []string
+	verifier := &CallbackVerifier{FetchPublicKey: func(u string) ([]byte, error) {
+		fetched = append(fetched, u)
+		return pubPEM, nil
+	}}
+
+	type callbackBody struct {
+		Bucket string `json:"bucket"`
+		Object string `json:"object"`
+		Size   int64  `json:"size"`
+	}
+	var received callbackBody
+	var decodeErr error
+	server := httptest.NewServer(verifier.Handler(func(w http.ResponseWriter, r *http.Request, body []byte) {
+		decodeErr = DecodeCallbackBody(r.Header.Get(HTTPHeaderContentType), body, &received)
+		w.Write([]byte("{\"Status\":\"OK\"}"))
+	}))
+	defer server.Close()
+
+	send := func(path, body string, sign bool) int {
+		req, err := http.NewRequest("POST", server.URL+path, strings.NewReader(body))
+		c.Assert(err, IsNil)
+		req.Header.Set(HTTPHeaderContentType, CallbackBodyTypeForm)
+		req.Header.Set(HTTPHeaderOssPubKeyURL, base64.StdEncoding.EncodeToString([]byte(pubKeyURL)))
+		authPath := strings.Replace(path, "%20", " ", -1)
+		digest := md5.Sum([]byte(authPath + "\n" + body))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, priv, crypto.MD5, digest[:])
+		c.Assert(err, IsNil)
+		if !sign {
+			signature[0] ^= 0xff
+		}
+		req.Header.Set(HTTPHeaderAuthorization, base64.StdEncoding.EncodeToString(signature))
+		resp, err := http.DefaultClient.Do(req)
+		c.Assert(err, IsNil)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	c.Assert(send("/callback%20path?id=1", "bucket=b&object=o&size=10", true), Equals, http.StatusOK)
+	c.Assert(decodeErr, IsNil)
+	c.Assert(received, Equals, callbackBody{Bucket: "b", Object: "o", Size: 10})
+
+	c.Assert(send("/callback", "bucket=b&object=o&size=10", false), Equals, http.StatusBadRequest)
+	c.Assert(fetched, DeepEquals, []string{"https://gosspublic.alicdn.com/callback_pub_key_v1.pem"})
+
+	// the large body is rejected
+	c.Assert(send("/callback", strings.Repeat("a", maxCallbackBodySize+1), true), Equals, http.StatusBadRequest)
+
+	// untrusted public key location
+	_, err = fetchCallbackPublicKey("https://example.com/key.pem")
+	c.Assert(err, NotNil)
+	_, err = fetchCallbackPublicKey("http://gosspublic.alicdn.com/callback_pub_key_v1.pem")
+	c.Assert(err, NotNil)
+
+	// the stalled download times out
+	stalled := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer stalled.Close()
+	client := callbackPubKeyClient
+	defer func() { callbackPubKeyClient = client }()
+	callbackPubKeyClient = stalled.Client()
+	callbackPubKeyClient.Timeout = 50 * time.Millisecond
+	transport := callbackPubKeyClient.Transport.(*http.Transport)
+	transport.TLSClientConfig.ServerName = "example.com"
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return net.Dial(network, stalled.Listener.Addr().String())
+	}
+	start := time.Now()
+	_, err = fetchCallbackPublicKey("https://gosspublic.alicdn.com/callback_pub_key_v1.pem")
+	c.Assert(strings.Contains(err.Error(), "Client.Timeout"), Equals, true, Commentf("%s", err))
+	c.Assert(time.Since(start) < 5*time.Second, Equals, true)
+}
+
+func (s *OssCallbackSuite) TestDecodeCallbackBody(c *C) {
+	var result struct {
+		Bucket string  `json:"bucket"`
+		Height int     `json:"height"`
+		Ratio  float64 `json:"ratio"`
+		Ok     bool
+	}
+	err := DecodeCallbackBody(CallbackBodyTypeJSON, []byte("{\"bucket\":\"b\",\"height\":10}"), &result)
+	c.Assert(err, IsNil)
+	c.Assert(result.Bucket, Equals, "b")
+	c.Assert(result.Height, Equals, 10)
+
+	err = DecodeCallbackBody(CallbackBodyTypeForm, []byte("bucket=b2&ratio=0.5&Ok=true&other=x"), &result)
+	c.Assert(err, IsNil)
+	c.Assert(result.Bucket, Equals, "b2")
+	c.Assert(result.Ratio, Equals, 0.5)
+	c.Assert(result.Ok, Equals, true)
+
+	err = DecodeCallbackBody(CallbackBodyTypeForm, []byte("height=abc"), &result)
+	c.Assert(err, NotNil)
+
+	err = DecodeCallbackBody(CallbackBodyTypeForm, []byte("bucket=b"), result)
+	c.Assert(err, NotNil)
+}
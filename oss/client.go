@@ -4,6 +4,9 @@ package oss
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/xml"
 	"fmt"
 	"io"
@@ -73,6 +76,17 @@ func New(endpoint, accessKeyID, accessKeySecret string, options ...ClientOption)
 		return nil, fmt.Errorf("Init client Error, invalid Auth version: %v", config.AuthVersion)
 	}
 
+	if len(config.Endpoints) > 0 {
+		if config.endpoints, err = newEndpointSet(config); err != nil {
+			return nil, err
+		}
+	}
+
+	// Derive the signing region from the standard endpoint
+	if config.AuthVersion == AuthV4 && config.Region == "" && config.CloudBoxId == "" {
+		config.Region, config.CloudBoxId = signingScopeFromHost(url.NetLoc)
+	}
+
 	// Create HTTP connection
 	err = conn.init(config, url, client.HTTPClient)
 
@@ -2908,6 +2922,97 @@ func Product(product string) ClientOption {
 	}
 }
 
+// FailoverEndpoints sets the fallback endpoints after the endpoint of New, and the policy to choose the endpoints
+func FailoverEndpoints(policy EndpointPolicyType, endpoints ...string) ClientOption {
+	return func(client *Client) {
+		client.Config.EndpointPolicy = policy
+		client.Config.Endpoints = endpoints
+	}
+}
+
+// EndpointBreakerSetting sets the consecutive failures to stop using an endpoint, and the interval to probe it again
+func EndpointBreakerSetting(failureThreshold int, probeInterval time.Duration) ClientOption {
+	return func(client *Client) {
+		client.Config.EndpointBreaker.FailureThreshold = failureThreshold
+		client.Config.EndpointBreaker.ProbeInterval = probeInterval
+	}
+}
+
+// TLSRootCAs sets the CAs to verify the server certificates, such as the CA of a private deployment
+func TLSRootCAs(pool *x509.CertPool) ClientOption {
+	return func(client *Client) {
+		client.Config.TLSRootCAs = pool
+	}
+}
+
+// TLSClientCertificates sets the client certificates of mutual TLS
+func TLSClientCertificates(certs ...tls.Certificate) ClientOption {
+	return func(client *Client) {
+		client.Config.TLSCertificates = certs
+	}
+}
+
+// TLSMinVersion sets the minimum TLS version, such as tls.VersionTLS12
+func TLSMinVersion(version uint16) ClientOption {
+	return func(client *Client) {
+		client.Config.TLSMinVersion = version
+	}
+}
+
+// EnableHTTP2 only effective from go1.13 onward, EnableHTTP2 sets whether to try HTTP/2 for https. The connections are
+// still closed if they're idle longer than the read/write timeout.
+func EnableHTTP2(enabled bool) ClientOption {
+	return func(client *Client) {
+		client.Config.EnableHTTP2 = enabled
+	}
+}
+
+// DialContext sets the dialer of the connections instead of the default one, such as the dialer of a VPN. The
+// connect timeout, LocalAddr and TCPKeepAlive are the dialer's own business.
+func DialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) ClientOption {
+	return func(client *Client) {
+		client.Config.DialContext = dial
+	}
+}
+
+// DNSResolver sets the resolver of the host names, such as a resolver of the private DNS server
+func DNSResolver(resolver *net.Resolver) ClientOption {
+	return func(client *Client) {
+		client.Config.DNSResolver = resolver
+	}
+}
+
+// DNSCache caches the resolved addresses for the TTL, the host is resolved again if all the addresses can't connect
+func DNSCache(ttl time.Duration) ClientOption {
+	return func(client *Client) {
+		client.Config.DNSCacheTTL = ttl
+	}
+}
+
+// TCPKeepAlive sets the TCP keep-alive period of the connections, negative disables it
+func TCPKeepAlive(period time.Duration) ClientOption {
+	return func(client *Client) {
+		client.Config.TCPKeepAlive = period
+	}
+}
+
+// ProxyFromEnvironment uses the proxy of the environment variables HTTP_PROXY, HTTPS_PROXY and NO_PROXY if Proxy and
+// AuthProxy aren't set. The SOCKS5 proxy is also supported by Proxy, such as Proxy("socks5://127.0.0.1:1080").
+func ProxyFromEnvironment() ClientOption {
+	return func(client *Client) {
+		client.Config.IsProxyFromEnv = true
+	}
+}
+
+// BandwidthLimiters sets the default limiters of the upload and the download bandwidth, they could be shared by the
+// clients and nil is unlimited. They override LimitUploadSpeed and LimitDownloadSpeed.
+func BandwidthLimiters(upload, download BandwidthLimiter) ClientOption {
+	return func(client *Client) {
+		client.Config.UploadBandwidth = upload
+		client.Config.DownloadBandwidth = download
+	}
+}
+
 // VerifyObjectStrict  sets the flag of verifying object name strictly.
 func VerifyObjectStrict(enable bool) ClientOption {
 	return func(client *Client) {
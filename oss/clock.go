@@ -0,0 +1,87 @@
+package oss
+
+import (
+	"encoding/xml"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// clockSkewThreshold is the least skew corrected by the Date header, the smaller skew is ignored since the header is
+// in seconds and OSS allows 15 minutes
+const clockSkewThreshold = time.Minute
+
+// The formats of ServerTime in the RequestTimeTooSkewed error
+var serverTimeFormats = []string{"2006-01-02T15:04:05.000Z", time.RFC3339Nano, http.TimeFormat}
+
+// clockOffset is the offset of the server clock to the local clock, it's shared by the connections of a client
+type clockOffset struct {
+	nanos int64
+}
+
+func (c *clockOffset) get() time.Duration {
+	if c == nil {
+		return 0
+	}
+	return time.Duration(atomic.LoadInt64(&c.nanos))
+}
+
+func (c *clockOffset) set(offset time.Duration) {
+	if c != nil {
+		atomic.StoreInt64(&c.nanos, int64(offset))
+	}
+}
+
+// ClockOffset returns the offset of the server clock to the local clock measured by the responses, it's added to the
+// local time to sign the requests.
+//
+// time.Duration    the offset, it's positive if the local clock is slow.
+func (client Client) ClockOffset() time.Duration {
+	return client.Config.clockOffset.get()
+}
+
+// now returns the local time corrected by the offset of the server clock
+func (conn Conn) now() time.Time {
+	return time.Now().Add(conn.config.clockOffset.get())
+}
+
+// setClockOffset corrects the clock by the server time measured at the local time
+func (conn Conn) setClockOffset(serverTime, local time.Time) {
+	offset := serverTime.Sub(local)
+	if old := conn.config.clockOffset.get(); offset-old > -clockSkewThreshold && offset-old < clockSkewThreshold {
+		return
+	}
+	conn.config.clockOffset.set(offset)
+	conn.config.WriteLog(Warn, "clock skew detected, the offset of the server clock is %s\n", offset.String())
+}
+
+// updateClockOffset measures the skew by the Date header of the response. Only the responses of OSS, which have the
+// x-oss-request-id header, are trusted, the Date of the proxies and the CDN caches may be stale.
+func (conn Conn) updateClockOffset(header http.Header) {
+	if header.Get(HTTPHeaderOssRequestID) == "" {
+		return
+	}
+	if t, err := http.ParseTime(header.Get(HTTPHeaderDate)); err == nil {
+		conn.setClockOffset(t, time.Now())
+	}
+}
+
+// clockSkewServerTime returns the ServerTime of the RequestTimeTooSkewed error
+func clockSkewServerTime(err error) (time.Time, bool) {
+	srvErr, ok := err.(ServiceError)
+	if !ok || srvErr.Code != "RequestTimeTooSkewed" {
+		return time.Time{}, false
+	}
+	var skewed struct {
+		ServerTime string `xml:"ServerTime"`
+	}
+	if xml.Unmarshal([]byte(srvErr.RawMessage), &skewed) != nil {
+		return time.Time{}, false
+	}
+	for _, format := range serverTimeFormats {
+		if t, err := time.Parse(format, skewed.ServerTime); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
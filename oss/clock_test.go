@@ -0,0 +1,134 @@
+package oss
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type OssClockSuite struct{}
+
+var _ = Suite(&OssClockSuite{})
+
+// newSkewedServer serves the requests by the clock skewed from the local clock, the requests whose Date header differs
+// more than 15 minutes fail with RequestTimeTooSkewed
+func newSkewedServer(c *C, skew time.Duration, bodies *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serverTime := time.Now().Add(skew).UTC()
+		w.Header().Set(HTTPHeaderDate, serverTime.Format(http.TimeFormat))
+		if r.URL.Query().Get("cached") == "" {
+			w.Header().Set(HTTPHeaderOssRequestID, "5C3D9175B6FC201293AD****")
+		}
+		// The presigned URLs have no Date header
+		date, err := http.ParseTime(r.Header.Get(HTTPHeaderDate))
+		if err != nil {
+			date = serverTime
+		}
+		if diff := serverTime.Sub(date); diff > 15*time.Minute || diff < -15*time.Minute {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("<Error><Code>RequestTimeTooSkewed</Code><Message>The difference between the request time and the current time is too large.</Message>" +
+				"<RequestTime>" + date.Format("2006-01-02T15:04:05.000Z") + "</RequestTime>" +
+				"<ServerTime>" + serverTime.Format("2006-01-02T15:04:05.000Z") + "</ServerTime></Error>"))
+			return
+		}
+		data, err := ioutil.ReadAll(r.Body)
+		c.Assert(err, IsNil)
+		*bodies = append(*bodies, r.Method+" "+string(data))
+	}))
+}
+
+func (s *OssClockSuite) TestClockSkewRetry(c *C) {
+	var bodies []string
+	server := newSkewedServer(c, 30*time.Minute, &bodies)
+	defer server.Close()
+	client, err := New(server.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("bucket")
+	c.Assert(err, IsNil)
+	c.Assert(client.ClockOffset(), Equals, time.Duration(0))
+
+	// The request is signed again and the body is sent again
+	c.Assert(bucket.PutObject("a", strings.NewReader("data")), IsNil)
+	c.Assert(bodies, DeepEquals, []string{"PUT data"})
+	offset := client.ClockOffset()
+	c.Assert(offset > 29*time.Minute && offset < 31*time.Minute, Equals, true, Commentf("offset %s", offset))
+
+	// The corrected clock is used by the following requests and the signed URLs
+	_, err = bucket.GetObjectMeta("a")
+	c.Assert(err, IsNil)
+	c.Assert(len(bodies), Equals, 2)
+	signedURL, err := bucket.SignURL("a", HTTPGet, 60)
+	c.Assert(err, IsNil)
+	u, err := url.Parse(signedURL)
+	c.Assert(err, IsNil)
+	expires, err := strconv.ParseInt(u.Query().Get(HTTPParamExpires), 10, 64)
+	c.Assert(err, IsNil)
+	c.Assert(expires-time.Now().Add(30*time.Minute).Unix() > 0, Equals, true)
+
+	// The body which can't be sent again fails, but the clock is corrected for the next requests
+	bodies = nil
+	client, err = New(server.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+	bucket, err = client.Bucket("bucket")
+	c.Assert(err, IsNil)
+	err = bucket.PutObject("b", io.MultiReader(strings.NewReader("data")))
+	c.Assert(err.(ServiceError).Code, Equals, "RequestTimeTooSkewed")
+	c.Assert(client.ClockOffset() > 29*time.Minute, Equals, true)
+	c.Assert(bucket.PutObject("b", io.MultiReader(strings.NewReader("data"))), IsNil)
+	c.Assert(bodies, DeepEquals, []string{"PUT data"})
+}
+
+func (s *OssClockSuite) TestClockOffsetByDate(c *C) {
+	var bodies []string
+	server := newSkewedServer(c, -10*time.Minute, &bodies)
+	defer server.Close()
+	client, err := New(server.URL, "ak", "sk", AuthVersion(AuthV4), Region("cn-hangzhou"))
+	c.Assert(err, IsNil)
+
+	// The skew is measured by the Date header of the successful responses
+	bucket, err := client.Bucket("bucket")
+	c.Assert(err, IsNil)
+	c.Assert(bucket.PutObject("a", strings.NewReader("data")), IsNil)
+	c.Assert(bodies, DeepEquals, []string{"PUT data"})
+	offset := client.ClockOffset()
+	c.Assert(offset < -9*time.Minute && offset > -11*time.Minute, Equals, true, Commentf("offset %s", offset))
+
+	// The responses not from OSS and the presigned URLs don't change the clock
+	client, err = New(server.URL, "ak", "sk", AuthVersion(AuthV4), Region("cn-hangzhou"))
+	c.Assert(err, IsNil)
+	bucket, err = client.Bucket("bucket")
+	c.Assert(err, IsNil)
+	_, err = bucket.GetObjectMeta("a", AddParam("cached", "1"))
+	c.Assert(err, IsNil)
+	c.Assert(client.ClockOffset(), Equals, time.Duration(0))
+	signedURL, err := bucket.SignURL("a", HTTPGet, 60)
+	c.Assert(err, IsNil)
+	body, err := bucket.GetObjectWithURL(signedURL)
+	c.Assert(err, IsNil)
+	body.Close()
+	c.Assert(client.ClockOffset(), Equals, time.Duration(0))
+
+	// The small skew is ignored
+	client, err = New(server.URL, "ak", "sk", AuthVersion(AuthV4), Region("cn-hangzhou"))
+	c.Assert(err, IsNil)
+	client.Config.clockOffset.set(offset)
+	conn := client.Conn
+	conn.setClockOffset(time.Now().Add(offset+10*time.Second), time.Now())
+	c.Assert(client.ClockOffset(), Equals, offset)
+	conn.setClockOffset(time.Now(), time.Now())
+	c.Assert(client.ClockOffset() > -time.Second, Equals, true)
+
+	_, ok := clockSkewServerTime(ServiceError{Code: "AccessDenied"})
+	c.Assert(ok, Equals, false)
+	serverTime, ok := clockSkewServerTime(ServiceError{Code: "RequestTimeTooSkewed",
+		RawMessage: "<Error><ServerTime>2024-01-02T03:04:05.000Z</ServerTime></Error>"})
+	c.Assert(ok, Equals, true)
+	c.Assert(serverTime.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)), Equals, true)
+}
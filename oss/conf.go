@@ -2,10 +2,14 @@ package oss
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"log"
 	"net"
 	"os"
+	"sync"
 	"time"
 )
 
@@ -168,6 +172,8 @@ type Config struct {
 	UploadLimiter       *OssLimiter         // Bandwidth limit reader for upload
 	DownloadLimitSpeed  int                 // Download limit speed:KB/s, 0 is unlimited
 	DownloadLimiter     *OssLimiter         // Bandwidth limit reader for download
+	UploadBandwidth     BandwidthLimiter    // The upload limiter which could be shared by the clients, it overrides UploadLimiter
+	DownloadBandwidth   BandwidthLimiter    // The download limiter which could be shared by the clients, it overrides DownloadLimiter
 	CredentialsProvider CredentialsProvider // User provides interface to get AccessKeyID, AccessKeySecret, SecurityToken
 	LocalAddr           net.Addr            // local client host info
 	UserSetUa           bool                // UserAgent is set by user or not
@@ -179,15 +185,43 @@ type Config struct {
 	CloudBoxId          string              //
 	Product             string              //  oss or oss-cloudbox, default is oss
 	VerifyObjectStrict  bool                //  a flag of verifying object name strictly. Default is enable.
+	Endpoints           []string            //  the fallback endpoints after Endpoint, such as the internal and the accelerate endpoints
+	EndpointPolicy      EndpointPolicyType  //  the order in which the endpoints are tried, default is EndpointPrimary
+	EndpointBreaker     EndpointBreaker     //  the circuit breaker of the endpoints
+	TLSRootCAs          *x509.CertPool      //  the CAs to verify the server certificates, default is the system pool
+	TLSCertificates     []tls.Certificate   //  the client certificates of mutual TLS
+	TLSMinVersion       uint16              //  the minimum TLS version, such as tls.VersionTLS12
+	EnableHTTP2         bool                //  only effective from go1.13 onward, try HTTP/2 for https. Default is false.
+	DNSResolver         *net.Resolver       //  the resolver of the default dialer
+	DNSCacheTTL         time.Duration       //  the time to cache the resolved addresses, 0 is no cache
+	TCPKeepAlive        time.Duration       //  the TCP keep-alive period of the default dialer, negative disables it. Default is 30s.
+	IsProxyFromEnv      bool                //  use the proxy of HTTP_PROXY, HTTPS_PROXY and NO_PROXY if the proxy isn't set
+
+	// DialContext dials the connections instead of the default dialer, they're still wrapped by the read/write timeout
+	DialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	clockOffset *clockOffset // the offset of the server clock, measured by the responses
+	endpoints   *endpointSet // the health of the endpoints, it's nil if Endpoints isn't set
+
+	hedgeCounters *hedgeCounters // the statistics of the requests with the Hedge option
+
+	limitMu *sync.RWMutex // guards the speeds and the limiters changed by LimitUploadSpeed and LimitDownloadSpeed
 }
 
 // LimitUploadSpeed uploadSpeed:KB/s, 0 is unlimited,default is 0
 func (config *Config) LimitUploadSpeed(uploadSpeed int) error {
 	if uploadSpeed < 0 {
 		return fmt.Errorf("invalid argument, the value of uploadSpeed is less than 0")
+	}
+	config.lockLimiters()
+	defer config.unlockLimiters()
+	if config.UploadLimiter != nil {
+		// The running transfers are limited by the new speed, or aren't limited any more
+		config.UploadLimiter.SetLimit(uploadSpeed)
+		config.UploadLimitSpeed = uploadSpeed
+		return nil
 	} else if uploadSpeed == 0 {
 		config.UploadLimitSpeed = 0
-		config.UploadLimiter = nil
 		return nil
 	}
 
@@ -203,9 +237,16 @@ func (config *Config) LimitUploadSpeed(uploadSpeed int) error {
 func (config *Config) LimitDownloadSpeed(downloadSpeed int) error {
 	if downloadSpeed < 0 {
 		return fmt.Errorf("invalid argument, the value of downloadSpeed is less than 0")
+	}
+	config.lockLimiters()
+	defer config.unlockLimiters()
+	if config.DownloadLimiter != nil {
+		// The running transfers are limited by the new speed, or aren't limited any more
+		config.DownloadLimiter.SetLimit(downloadSpeed)
+		config.DownloadLimitSpeed = downloadSpeed
+		return nil
 	} else if downloadSpeed == 0 {
 		config.DownloadLimitSpeed = 0
-		config.DownloadLimiter = nil
 		return nil
 	}
 
@@ -217,6 +258,20 @@ func (config *Config) LimitDownloadSpeed(downloadSpeed int) error {
 	return err
 }
 
+// lockLimiters locks the speeds and the limiters to change them, the config not created by getDefaultOssConfig has
+// no lock
+func (config *Config) lockLimiters() {
+	if config.limitMu != nil {
+		config.limitMu.Lock()
+	}
+}
+
+func (config *Config) unlockLimiters() {
+	if config.limitMu != nil {
+		config.limitMu.Unlock()
+	}
+}
+
 // WriteLog output log function
 func (config *Config) WriteLog(LogLevel int, format string, a ...interface{}) {
 	if config.LogLevel < LogLevel || config.Logger == nil {
@@ -291,11 +346,20 @@ func getDefaultOssConfig() *Config {
 
 	config.AuthVersion = AuthV1
 	config.RedirectEnabled = true
+	config.TCPKeepAlive = time.Second * 30
 	config.InsecureSkipVerify = false
 
 	config.Product = "oss"
 
 	config.VerifyObjectStrict = true
 
+	config.EndpointPolicy = EndpointPrimary
+	config.EndpointBreaker.FailureThreshold = 3
+	config.EndpointBreaker.ProbeInterval = time.Second * 30
+
+	config.clockOffset = &clockOffset{}
+	config.hedgeCounters = &hedgeCounters{}
+	config.limitMu = &sync.RWMutex{}
+
 	return &config
 }
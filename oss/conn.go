@@ -15,10 +15,11 @@ import (
 	"net/http"
 	"net/url"
 	"os"
-	"sort"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss/signer"
 )
 
 // Conn defines OSS Conn
@@ -28,31 +29,6 @@ type Conn struct {
 	client *http.Client
 }
 
-var signKeyList = []string{"acl", "uploads", "location", "cors",
-	"logging", "website", "referer", "lifecycle",
-	"delete", "append", "tagging", "objectMeta",
-	"uploadId", "partNumber", "security-token",
-	"position", "img", "style", "styleName",
-	"replication", "replicationProgress",
-	"replicationLocation", "cname", "bucketInfo",
-	"comp", "qos", "live", "status", "vod",
-	"startTime", "endTime", "symlink",
-	"x-oss-process", "response-content-type", "x-oss-traffic-limit",
-	"response-content-language", "response-expires",
-	"response-cache-control", "response-content-disposition",
-	"response-content-encoding", "udf", "udfName", "udfImage",
-	"udfId", "udfImageDesc", "udfApplication", "comp",
-	"udfApplicationLog", "restore", "callback", "callback-var", "qosInfo",
-	"policy", "stat", "encryption", "versions", "versioning", "versionId", "requestPayment",
-	"x-oss-request-payer", "sequential",
-	"inventory", "inventoryId", "continuation-token", "asyncFetch",
-	"worm", "wormId", "wormExtend", "withHashContext",
-	"x-oss-enable-md5", "x-oss-enable-sha1", "x-oss-enable-sha256",
-	"x-oss-hash-ctx", "x-oss-md5-ctx", "transferAcceleration",
-	"regionList", "cloudboxes", "x-oss-ac-source-ip", "x-oss-ac-subnet-mask", "x-oss-ac-vpc-id", "x-oss-ac-forward-allow",
-	"metaQuery", "resourceGroup", "rtc", "x-oss-async-process", "responseHeader",
-}
-
 const (
 	timeFormatV4       = "20060102T150405Z"
 	shortTimeFormatV4  = "20060102"
@@ -79,6 +55,8 @@ func (conn *Conn) init(config *Config, urlMaker *urlMaker, client *http.Client)
 				}
 			}
 			transport.Proxy = http.ProxyURL(proxyURL)
+		} else if conn.config.IsProxyFromEnv {
+			transport.Proxy = http.ProxyFromEnvironment
 		}
 		client = &http.Client{Transport: transport}
 		if !config.RedirectEnabled {
@@ -104,7 +82,6 @@ func (conn Conn) DoWithContext(ctx context.Context, method, bucketName, objectNa
 	data io.Reader, initCRC uint64, listener ProgressListener) (*Response, error) {
 	urlParams := conn.getURLParams(params)
 	subResource := conn.getSubResource(params)
-	uri := conn.url.getURL(bucketName, objectName, urlParams)
 
 	resource := ""
 	if conn.config.AuthVersion != AuthV4 {
@@ -113,7 +90,40 @@ func (conn Conn) DoWithContext(ctx context.Context, method, bucketName, objectNa
 		resource = conn.getResourceV4(bucketName, objectName, subResource)
 	}
 
-	return conn.doRequest(ctx, method, uri, resource, headers, data, initCRC, listener)
+	// The request is sent again by another endpoint or the corrected clock, the body must be seekable
+	var position int64
+	seeker, seekable := data.(io.Seeker)
+	if seekable {
+		var errSeek error
+		if position, errSeek = seeker.Seek(0, io.SeekCurrent); errSeek != nil {
+			seekable = false
+		}
+	}
+	rewind := func() bool {
+		if data == nil {
+			return true
+		}
+		if !seekable {
+			return false
+		}
+		_, errSeek := seeker.Seek(position, io.SeekStart)
+		return errSeek == nil
+	}
+
+	resp, err := conn.doEndpoints(ctx, method, bucketName, objectName, urlParams, resource, headers, data, initCRC, listener, rewind)
+	serverTime, skewed := clockSkewServerTime(err)
+	if !skewed {
+		return resp, err
+	}
+	conn.config.clockOffset.set(serverTime.Sub(time.Now()))
+	if !rewind() {
+		return resp, err
+	}
+	if resp != nil {
+		resp.Body.Close()
+	}
+	conn.config.WriteLog(Warn, "retry the request signed by the corrected clock, offset:%s\n", conn.config.clockOffset.get().String())
+	return conn.doEndpoints(ctx, method, bucketName, objectName, urlParams, resource, headers, data, initCRC, listener, rewind)
 }
 
 // DoURL sends the request with signed URL and returns the response result.
@@ -200,107 +210,44 @@ func (conn Conn) DoURLWithContext(ctx context.Context, method HTTPMethod, signed
 }
 
 func (conn Conn) getURLParams(params map[string]interface{}) string {
-	// Sort
-	keys := make([]string, 0, len(params))
-	for k := range params {
-		keys = append(keys, k)
-	}
-	sort.Strings(keys)
-
-	// Serialize
-	var buf bytes.Buffer
-	for _, k := range keys {
-		if buf.Len() > 0 {
-			buf.WriteByte('&')
-		}
-		buf.WriteString(url.QueryEscape(k))
-		if params[k] != nil && params[k].(string) != "" {
-			buf.WriteString("=" + strings.Replace(url.QueryEscape(params[k].(string)), "+", "%20", -1))
-		}
-	}
-
-	return buf.String()
+	return signer.EncodeQuery(paramsToQuery(params))
 }
 
 func (conn Conn) getSubResource(params map[string]interface{}) string {
-	// Sort
-	keys := make([]string, 0, len(params))
-	signParams := make(map[string]string)
-	for k := range params {
-		if conn.config.AuthVersion == AuthV2 || conn.config.AuthVersion == AuthV4 {
-			encodedKey := url.QueryEscape(k)
-			keys = append(keys, encodedKey)
-			if params[k] != nil && params[k] != "" {
-				signParams[encodedKey] = strings.Replace(url.QueryEscape(params[k].(string)), "+", "%20", -1)
-			}
-		} else if conn.isParamSign(k) {
-			keys = append(keys, k)
-			if params[k] != nil {
-				signParams[k] = params[k].(string)
-			}
-		}
-	}
-	sort.Strings(keys)
-
-	// Serialize
-	var buf bytes.Buffer
-	for _, k := range keys {
-		if buf.Len() > 0 {
-			buf.WriteByte('&')
-		}
-		buf.WriteString(k)
-		if _, ok := signParams[k]; ok {
-			if signParams[k] != "" {
-				buf.WriteString("=" + signParams[k])
-			}
-		}
-	}
-	return buf.String()
+	return conn.getSigner().SubResource(paramsToQuery(params))
 }
 
 func (conn Conn) isParamSign(paramKey string) bool {
-	for _, k := range signKeyList {
-		if paramKey == k {
-			return true
-		}
-	}
-	return false
+	return signer.IsSubResource(paramKey)
 }
 
 // getResource gets canonicalized resource
 func (conn Conn) getResource(bucketName, objectName, subResource string) string {
-	if subResource != "" {
-		subResource = "?" + subResource
+	s := conn.getSigner()
+	if s.Version == signer.V4 {
+		s.Version = signer.V1
 	}
-	if bucketName == "" {
-		if conn.config.AuthVersion == AuthV2 {
-			return url.QueryEscape("/") + subResource
-		}
-		return fmt.Sprintf("/%s%s", bucketName, subResource)
-	}
-	if conn.config.AuthVersion == AuthV2 {
-		return url.QueryEscape("/"+bucketName+"/") + strings.Replace(url.QueryEscape(objectName), "+", "%20", -1) + subResource
-	}
-	return fmt.Sprintf("/%s/%s%s", bucketName, objectName, subResource)
+	return s.Resource(bucketName, objectName, subResource)
 }
 
 // getResource gets canonicalized resource
 func (conn Conn) getResourceV4(bucketName, objectName, subResource string) string {
-	if subResource != "" {
-		subResource = "?" + subResource
-	}
-
-	if bucketName == "" {
-		return fmt.Sprintf("/%s", subResource)
-	}
+	s := conn.getSigner()
+	s.Version = signer.V4
+	return s.Resource(bucketName, objectName, subResource)
+}
 
-	if objectName != "" {
-		objectName = url.QueryEscape(objectName)
-		objectName = strings.Replace(objectName, "+", "%20", -1)
-		objectName = strings.Replace(objectName, "%2F", "/", -1)
-		return fmt.Sprintf("/%s/%s%s", bucketName, objectName, subResource)
+// paramsToQuery converts the params to the query, the nil values are empty
+func paramsToQuery(params map[string]interface{}) url.Values {
+	query := make(url.Values, len(params))
+	for k, v := range params {
+		if v == nil {
+			query.Set(k, "")
+		} else {
+			query.Set(k, v.(string))
+		}
 	}
-	return fmt.Sprintf("/%s/%s", bucketName, subResource)
+	return query
 }
 
 func (conn Conn) doRequest(ctx context.Context, method string, uri *url.URL, canonicalizedResource string, headers map[string]string,
@@ -335,7 +282,7 @@ func (conn Conn) doRequest(ctx context.Context, method string, uri *url.URL, can
 		req.Header.Set("Proxy-Authorization", basic)
 	}
 
-	stNow := time.Now().UTC()
+	stNow := conn.now().UTC()
 	req.Header.Set(HTTPHeaderDate, stNow.Format(http.TimeFormat))
 	req.Header.Set(HTTPHeaderHost, req.Host)
 	req.Header.Set(HTTPHeaderUserAgent, conn.config.UserAgent)
@@ -382,6 +329,7 @@ func (conn Conn) doRequest(ctx context.Context, method string, uri *url.URL, can
 		publishProgress(listener, event)
 		return nil, err
 	}
+	conn.updateClockOffset(resp.Header)
 
 	if conn.config.LogLevel >= Debug {
 		//print out http resp
@@ -406,86 +354,16 @@ func (conn Conn) signURL(method HTTPMethod, bucketName, objectName string, expir
 		akIf = conn.config.GetCredentials()
 	}
 
-	m := strings.ToUpper(string(method))
-	req := &http.Request{
-		Method: m,
-		Header: make(http.Header),
-	}
-
-	if conn.config.IsAuthProxy {
-		auth := conn.config.ProxyUser + ":" + conn.config.ProxyPassword
-		basic := "Basic " + base64.StdEncoding.EncodeToString([]byte(auth))
-		req.Header.Set("Proxy-Authorization", basic)
+	header := make(http.Header)
+	for k, v := range headers {
+		header.Set(k, v)
 	}
-
-	if conn.config.AuthVersion == AuthV4 {
-		if akIf.GetSecurityToken() != "" {
-			params[HTTPParamOssSecurityToken] = akIf.GetSecurityToken()
-		}
-
-		if headers != nil {
-			for k, v := range headers {
-				req.Header.Set(k, v)
-			}
-		}
-
-		now := time.Now().UTC()
-		expires := expiration - now.Unix()
-		product := conn.config.GetSignProduct()
-		region := conn.config.GetSignRegion()
-		strDay := now.Format(shortTimeFormatV4)
-		additionalList, _ := conn.getAdditionalHeaderKeys(req)
-
-		params[HTTPParamSignatureVersion] = signingAlgorithmV4
-		params[HTTPParamCredential] = fmt.Sprintf("%s/%s/%s/%s/aliyun_v4_request", akIf.GetAccessKeyID(), strDay, region, product)
-		params[HTTPParamDate] = now.Format(timeFormatV4)
-		params[HTTPParamExpiresV2] = strconv.FormatInt(expires, 10)
-		if len(additionalList) > 0 {
-			params[HTTPParamAdditionalHeadersV2] = strings.Join(additionalList, ";")
-		}
-
-		subResource := conn.getSubResource(params)
-		canonicalizedResource := conn.getResourceV4(bucketName, objectName, subResource)
-		authorizationStr := conn.getSignedStrV4(req, canonicalizedResource, akIf.GetAccessKeySecret(), &now)
-		params[HTTPParamSignatureV2] = authorizationStr
-	} else {
-		if akIf.GetSecurityToken() != "" {
-			params[HTTPParamSecurityToken] = akIf.GetSecurityToken()
-		}
-
-		req.Header.Set(HTTPHeaderDate, strconv.FormatInt(expiration, 10))
-
-		if headers != nil {
-			for k, v := range headers {
-				req.Header.Set(k, v)
-			}
-		}
-
-		if conn.config.AuthVersion == AuthV2 {
-			params[HTTPParamSignatureVersion] = "OSS2"
-			params[HTTPParamExpiresV2] = strconv.FormatInt(expiration, 10)
-			params[HTTPParamAccessKeyIDV2] = conn.config.AccessKeyID
-			additionalList, _ := conn.getAdditionalHeaderKeys(req)
-			if len(additionalList) > 0 {
-				params[HTTPParamAdditionalHeadersV2] = strings.Join(additionalList, ";")
-			}
-		}
-
-		subResource := conn.getSubResource(params)
-		canonicalizedResource := conn.getResource(bucketName, objectName, subResource)
-		signedStr := conn.getSignedStr(req, canonicalizedResource, akIf.GetAccessKeySecret())
-
-		if conn.config.AuthVersion == AuthV1 {
-			params[HTTPParamExpires] = strconv.FormatInt(expiration, 10)
-			params[HTTPParamAccessKeyID] = akIf.GetAccessKeyID()
-			params[HTTPParamSignature] = signedStr
-		} else if conn.config.AuthVersion == AuthV2 {
-			params[HTTPParamSignatureV2] = signedStr
-		}
+	query := paramsToQuery(params)
+	err = conn.getSigner().PresignQuery(string(method), header, bucketName, objectName, query, akIf, time.Unix(expiration, 0))
+	if err != nil {
+		return "", err
 	}
-
-	urlParams := conn.getURLParams(params)
-	return conn.url.getSignURL(bucketName, objectName, urlParams), nil
+	return conn.url.getSignURL(bucketName, objectName, signer.EncodeQuery(query)), nil
 }
 
 func (conn Conn) signRtmpURL(bucketName, channelName, playlistName string, expiration int64) string {
@@ -541,10 +419,11 @@ func (conn Conn) handleBody(req *http.Request, body io.Reader, initCRC uint64,
 		rc = ioutil.NopCloser(reader)
 	}
 
-	if conn.isUploadLimitReq(req) {
+	if limiter := conn.uploadLimiter(req.Context()); limiter != nil && conn.isUploadLimitReq(req) {
 		limitReader := &LimitSpeedReader{
-			reader:     rc,
-			ossLimiter: conn.config.UploadLimiter,
+			reader:  rc,
+			limiter: limiter,
+			ctx:     req.Context(),
 		}
 		req.Body = limitReader
 	} else {
@@ -555,10 +434,6 @@ func (conn Conn) handleBody(req *http.Request, body io.Reader, initCRC uint64,
 
 // isUploadLimitReq: judge limit upload speed or not
 func (conn Conn) isUploadLimitReq(req *http.Request) bool {
-	if conn.config.UploadLimitSpeed == 0 || conn.config.UploadLimiter == nil {
-		return false
-	}
-
 	if req.Method != "GET" && req.Method != "DELETE" && req.Method != "HEAD" {
 		if req.ContentLength > 0 {
 			return true
@@ -674,10 +549,11 @@ func (conn Conn) handleResponse(resp *http.Response, crc hash.Hash64) (*Response
 		srvCRC, _ = strconv.ParseUint(resp.Header.Get(HTTPHeaderOssCRC64), 10, 64)
 
 		realBody := resp.Body
-		if conn.isDownloadLimitResponse(resp) {
+		if limiter := conn.downloadLimiter(resp.Request.Context()); limiter != nil && conn.isDownloadLimitResponse(resp) {
 			limitReader := &LimitSpeedReader{
-				reader:     realBody,
-				ossLimiter: conn.config.DownloadLimiter,
+				reader:  realBody,
+				limiter: limiter,
+				ctx:     resp.Request.Context(),
 			}
 			realBody = limitReader
 		}
@@ -695,7 +571,7 @@ func (conn Conn) handleResponse(resp *http.Response, crc hash.Hash64) (*Response
 
 // isUploadLimitReq: judge limit upload speed or not
 func (conn Conn) isDownloadLimitResponse(resp *http.Response) bool {
-	if resp == nil || conn.config.DownloadLimitSpeed == 0 || conn.config.DownloadLimiter == nil {
+	if resp == nil {
 		return false
 	}
 
@@ -197,6 +197,7 @@ const (
 	HTTPHeaderOssStorageClass                = "X-Oss-Storage-Class"
 	HTTPHeaderOssCallback                    = "X-Oss-Callback"
 	HTTPHeaderOssCallbackVar                 = "X-Oss-Callback-Var"
+	HTTPHeaderOssPubKeyURL                   = "X-Oss-Pub-Key-Url"
 	HTTPHeaderOssRequester                   = "X-Oss-Request-Payer"
 	HTTPHeaderOssTagging                     = "X-Oss-Tagging"
 	HTTPHeaderOssTaggingDirective            = "X-Oss-Tagging-Directive"
@@ -212,6 +213,11 @@ const (
 	HttpHeaderOssNotification                = "X-Oss-Notification"
 	HTTPHeaderOssEc                          = "X-Oss-Ec"
 	HTTPHeaderOssErr                         = "X-Oss-Err"
+	HTTPHeaderOssObjectType                  = "X-Oss-Object-Type"
+	HTTPHeaderOssRestore                     = "X-Oss-Restore"
+	HTTPHeaderOssVersionID                   = "X-Oss-Version-Id"
+	HTTPHeaderOssTaggingCount                = "X-Oss-Tagging-Count"
+	HTTPHeaderOssTransitionTime              = "X-Oss-Transition-Time"
 )
 
 // HTTP Param
@@ -246,6 +252,10 @@ const (
 
 	NullVersion = "null"
 
+	maxCopyObjectSize   = 1024 * 1024 * 1024 // Max object size of CopyObject, 1GB
+	defaultCopyPartSize = 100 * 1024 * 1024  // Part size used when copying large objects, 100MB
+	maxPartCount        = 10000              // Max part count of a multipart upload
+
 	DefaultContentSha256 = "UNSIGNED-PAYLOAD" // for v4 signature
 
 	Version = "v3.0.2" // Go SDK version
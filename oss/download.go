@@ -13,7 +13,6 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
-	"strconv"
 	"time"
 )
 
@@ -236,22 +235,19 @@ func (bucket Bucket) downloadFile(objectKey, filePath string, partSize int64, op
 	// Get the object detailed meta for object whole size
 	// must delete header:range to get whole object size
 	skipOptions := DeleteOption(options, HTTPHeaderRange)
-	meta, err := bucket.GetObjectDetailedMeta(objectKey, skipOptions...)
+	meta, err := bucket.headObject(objectKey, skipOptions...)
 	if err != nil {
 		return err
 	}
 
-	objectSize, err := strconv.ParseInt(meta.Get(HTTPHeaderContentLength), 10, 64)
-	if err != nil {
-		return err
-	}
+	objectSize := meta.ContentLength
 
 	enableCRC := false
 	expectedCRC := (uint64)(0)
-	if bucket.GetConfig().IsEnableCRC && meta.Get(HTTPHeaderOssCRC64) != "" {
+	if bucket.GetConfig().IsEnableCRC && meta.Headers.Get(HTTPHeaderOssCRC64) != "" {
 		if uRange == nil || (!uRange.HasStart && !uRange.HasEnd) {
 			enableCRC = true
-			expectedCRC, _ = strconv.ParseUint(meta.Get(HTTPHeaderOssCRC64), 10, 64)
+			expectedCRC = meta.HashCRC64
 		}
 	}
 
@@ -338,7 +334,7 @@ type objectStat struct {
 }
 
 // isValid flags of checkpoint data is valid. It returns true when the data is valid and the checkpoint is valid and the object is not updated.
-func (cp downloadCheckpoint) isValid(meta http.Header, uRange *UnpackedRange) (bool, error) {
+func (cp downloadCheckpoint) isValid(meta *ObjectMeta, uRange *UnpackedRange) (bool, error) {
 	// Compare the CP's Magic and the MD5
 	cpb := cp
 	cpb.MD5 = ""
@@ -350,21 +346,16 @@ func (cp downloadCheckpoint) isValid(meta http.Header, uRange *UnpackedRange) (b
 		return false, nil
 	}
 
-	objectSize, err := strconv.ParseInt(meta.Get(HTTPHeaderContentLength), 10, 64)
-	if err != nil {
-		return false, err
-	}
-
 	// Compare the object size, last modified time and etag
-	if cp.ObjStat.Size != objectSize ||
-		cp.ObjStat.LastModified != meta.Get(HTTPHeaderLastModified) ||
-		cp.ObjStat.Etag != meta.Get(HTTPHeaderEtag) {
+	if cp.ObjStat.Size != meta.ContentLength ||
+		cp.ObjStat.LastModified != meta.Headers.Get(HTTPHeaderLastModified) ||
+		cp.ObjStat.Etag != meta.ETag {
 		return false, nil
 	}
 
 	// Check the download range
 	if uRange != nil {
-		start, end := AdjustRange(uRange, objectSize)
+		start, end := AdjustRange(uRange, meta.ContentLength)
 		if start != cp.Start || end != cp.End {
 			return false, nil
 		}
@@ -431,30 +422,25 @@ func (cp downloadCheckpoint) getCompletedBytes() int64 {
 }
 
 // prepare initiates download tasks
-func (cp *downloadCheckpoint) prepare(meta http.Header, bucket *Bucket, objectKey, filePath string, partSize int64, uRange *UnpackedRange) error {
+func (cp *downloadCheckpoint) prepare(meta *ObjectMeta, bucket *Bucket, objectKey, filePath string, partSize int64, uRange *UnpackedRange) error {
 	// CP
 	cp.Magic = downloadCpMagic
 	cp.FilePath = filePath
 	cp.Object = objectKey
 
-	objectSize, err := strconv.ParseInt(meta.Get(HTTPHeaderContentLength), 10, 64)
-	if err != nil {
-		return err
-	}
-
-	cp.ObjStat.Size = objectSize
-	cp.ObjStat.LastModified = meta.Get(HTTPHeaderLastModified)
-	cp.ObjStat.Etag = meta.Get(HTTPHeaderEtag)
+	cp.ObjStat.Size = meta.ContentLength
+	cp.ObjStat.LastModified = meta.Headers.Get(HTTPHeaderLastModified)
+	cp.ObjStat.Etag = meta.ETag
 
-	if bucket.GetConfig().IsEnableCRC && meta.Get(HTTPHeaderOssCRC64) != "" {
+	if bucket.GetConfig().IsEnableCRC && meta.Headers.Get(HTTPHeaderOssCRC64) != "" {
 		if uRange == nil || (!uRange.HasStart && !uRange.HasEnd) {
 			cp.enableCRC = true
-			cp.CRC, _ = strconv.ParseUint(meta.Get(HTTPHeaderOssCRC64), 10, 64)
+			cp.CRC = meta.HashCRC64
 		}
 	}
 
 	// Parts
-	cp.Parts = getDownloadParts(objectSize, partSize, uRange)
+	cp.Parts = getDownloadParts(meta.ContentLength, partSize, uRange)
 	cp.PartStat = make([]bool, len(cp.Parts))
 	for i := range cp.PartStat {
 		cp.PartStat[i] = false
@@ -486,7 +472,7 @@ func (bucket Bucket) downloadFileWithCp(objectKey, filePath string, partSize int
 	// Get the object detailed meta for object whole size
 	// must delete header:range to get whole object size
 	skipOptions := DeleteOption(options, HTTPHeaderRange)
-	meta, err := bucket.GetObjectDetailedMeta(objectKey, skipOptions...)
+	meta, err := bucket.headObject(objectKey, skipOptions...)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,177 @@
+package oss
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// NetworkType is the network by which OSS is accessed, it decides the endpoint of the region
+type NetworkType string
+
+const (
+	// NetworkPublic is the internet, the endpoint is oss-<region>.aliyuncs.com
+	NetworkPublic NetworkType = "public"
+
+	// NetworkInternal is the internal network of the region such as VPC, the endpoint is
+	// oss-<region>-internal.aliyuncs.com
+	NetworkInternal NetworkType = "internal"
+
+	// NetworkAccelerate is the global transfer acceleration, the endpoint is oss-accelerate.aliyuncs.com
+	NetworkAccelerate NetworkType = "accelerate"
+
+	// NetworkOverseasAccelerate is the transfer acceleration outside the Chinese mainland, the endpoint is
+	// oss-accelerate-overseas.aliyuncs.com
+	NetworkOverseasAccelerate NetworkType = "accelerate-overseas"
+
+	// NetworkDualStack is the IPv4 and IPv6 dual stack, the endpoint is <region>.oss.aliyuncs.com
+	NetworkDualStack NetworkType = "dualstack"
+
+	// NetworkCloudBoxControl is the control plane of the cloud box, the endpoint is
+	// <cloudbox-id>.<region>.oss-cloudbox-control.aliyuncs.com
+	NetworkCloudBoxControl NetworkType = "cloudbox-control"
+
+	// NetworkCloudBoxData is the data plane of the cloud box, the endpoint is
+	// <cloudbox-id>.<region>.oss-cloudbox.aliyuncs.com
+	NetworkCloudBoxData NetworkType = "cloudbox-data"
+)
+
+const endpointDomain = ".aliyuncs.com"
+
+// The region ID such as cn-hangzhou, us-west-1 and cn-hangzhou-finance
+var regionIDPattern = regexp.MustCompile(`^[a-z][a-z0-9]*(-[a-z0-9]+)+$`)
+
+// EndpointResolver builds the endpoint of a region by the network type. The zero value resolves the public
+// endpoints without the scheme.
+type EndpointResolver struct {
+	Network    NetworkType  // The network type, it's NetworkPublic if empty
+	Scheme     string       // http or https, the endpoint has no scheme if empty, which is http for New
+	CloudBoxID string       // The cloud box ID, it's required by NetworkCloudBoxControl and NetworkCloudBoxData
+	Regions    []RegionInfo // The regions of DescribeRegions, the region must be one of them if it's not empty
+}
+
+// Resolve returns the endpoint of the region. If the Regions is set, the region must be one of them and its
+// InternetEndpoint, InternalEndpoint and AccelerateEndpoint are used for the corresponding network types.
+//
+// region    the region ID such as cn-hangzhou, the prefix oss- is allowed.
+//
+// string    the endpoint, only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func (r EndpointResolver) Resolve(region string) (string, error) {
+	region = strings.TrimPrefix(region, "oss-")
+	if !IsValidRegion(region) {
+		return "", fmt.Errorf("oss: invalid region %q", region)
+	}
+
+	var info *RegionInfo
+	if len(r.Regions) > 0 {
+		for i := range r.Regions {
+			if strings.TrimPrefix(r.Regions[i].Region, "oss-") == region {
+				info = &r.Regions[i]
+				break
+			}
+		}
+		if info == nil {
+			return "", fmt.Errorf("oss: unknown region %q", region)
+		}
+	}
+
+	var host string
+	switch r.Network {
+	case NetworkPublic, "":
+		host = "oss-" + region + endpointDomain
+		if info != nil && info.InternetEndpoint != "" {
+			host = info.InternetEndpoint
+		}
+	case NetworkInternal:
+		host = "oss-" + region + "-internal" + endpointDomain
+		if info != nil && info.InternalEndpoint != "" {
+			host = info.InternalEndpoint
+		}
+	case NetworkAccelerate:
+		host = "oss-accelerate" + endpointDomain
+		if info != nil && info.AccelerateEndpoint != "" {
+			host = info.AccelerateEndpoint
+		}
+	case NetworkOverseasAccelerate:
+		host = "oss-accelerate-overseas" + endpointDomain
+	case NetworkDualStack:
+		host = region + ".oss" + endpointDomain
+	case NetworkCloudBoxControl, NetworkCloudBoxData:
+		if !IsValidRegion(r.CloudBoxID) {
+			return "", fmt.Errorf("oss: invalid cloud box ID %q", r.CloudBoxID)
+		}
+		product := "oss-cloudbox"
+		if r.Network == NetworkCloudBoxControl {
+			product = "oss-cloudbox-control"
+		}
+		host = r.CloudBoxID + "." + region + "." + product + endpointDomain
+	default:
+		return "", fmt.Errorf("oss: invalid network type %q", r.Network)
+	}
+
+	if r.Scheme == "" {
+		return host, nil
+	}
+	if r.Scheme != "http" && r.Scheme != "https" {
+		return "", fmt.Errorf("oss: invalid scheme %q", r.Scheme)
+	}
+	return r.Scheme + "://" + host, nil
+}
+
+// IsValidRegion checks if the region ID is well formed, such as cn-hangzhou and ap-southeast-1
+func IsValidRegion(region string) bool {
+	return regionIDPattern.MatchString(region)
+}
+
+// NewWithRegion creates a client by the public HTTPS endpoint of the region, and the region is the signing
+// region of AuthV4.
+//
+// region    the region ID such as cn-hangzhou.
+// accessKeyID    access key Id.
+// accessKeySecret    access key secret.
+// options    the options of New.
+//
+// Client    creates the new client instance, the returned value is valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func NewWithRegion(region, accessKeyID, accessKeySecret string, options ...ClientOption) (*Client, error) {
+	endpoint, err := EndpointResolver{Scheme: "https"}.Resolve(region)
+	if err != nil {
+		return nil, err
+	}
+	options = append([]ClientOption{Region(strings.TrimPrefix(region, "oss-"))}, options...)
+	return New(endpoint, accessKeyID, accessKeySecret, options...)
+}
+
+// signingScopeFromHost derives the signing region and the cloud box ID from the standard endpoint, such as
+// oss-cn-hangzhou.aliyuncs.com, oss-cn-hangzhou-internal.aliyuncs.com, cn-hangzhou.oss.aliyuncs.com and
+// cb-xxx.cn-hangzhou.oss-cloudbox.aliyuncs.com. The bucket could be the first label. The region is empty for the
+// acceleration endpoints and the other domains.
+func signingScopeFromHost(host string) (region, cloudBoxID string) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	if !strings.HasSuffix(host, endpointDomain) {
+		return "", ""
+	}
+	labels := strings.Split(strings.TrimSuffix(host, endpointDomain), ".")
+	last := labels[len(labels)-1]
+	switch {
+	case last == "oss-cloudbox" || last == "oss-cloudbox-control":
+		if len(labels) >= 3 {
+			return labels[len(labels)-2], labels[len(labels)-3]
+		}
+	case last == "oss":
+		if len(labels) >= 2 && IsValidRegion(labels[len(labels)-2]) {
+			return labels[len(labels)-2], ""
+		}
+	case strings.HasPrefix(last, "oss-") && !strings.HasPrefix(last, "oss-accelerate"):
+		region = strings.TrimSuffix(strings.TrimPrefix(last, "oss-"), "-internal")
+		if IsValidRegion(region) {
+			return region, ""
+		}
+	}
+	return "", ""
+}
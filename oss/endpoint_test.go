@@ -0,0 +1,90 @@
+package oss
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type OssEndpointSuite struct{}
+
+var _ = Suite(&OssEndpointSuite{})
+
+func (s *OssEndpointSuite) TestResolve(c *C) {
+	cases := []struct {
+		resolver EndpointResolver
+		region   string
+		endpoint string
+	}{
+		{EndpointResolver{}, "cn-hangzhou", "oss-cn-hangzhou.aliyuncs.com"},
+		{EndpointResolver{Scheme: "https"}, "oss-cn-hangzhou", "https://oss-cn-hangzhou.aliyuncs.com"},
+		{EndpointResolver{Network: NetworkInternal}, "us-west-1", "oss-us-west-1-internal.aliyuncs.com"},
+		{EndpointResolver{Network: NetworkAccelerate}, "cn-hangzhou", "oss-accelerate.aliyuncs.com"},
+		{EndpointResolver{Network: NetworkOverseasAccelerate}, "ap-southeast-1", "oss-accelerate-overseas.aliyuncs.com"},
+		{EndpointResolver{Network: NetworkDualStack}, "cn-beijing", "cn-beijing.oss.aliyuncs.com"},
+		{EndpointResolver{Network: NetworkCloudBoxData, CloudBoxID: "cb-123"}, "cn-shanghai", "cb-123.cn-shanghai.oss-cloudbox.aliyuncs.com"},
+		{EndpointResolver{Network: NetworkCloudBoxControl, CloudBoxID: "cb-123"}, "cn-shanghai", "cb-123.cn-shanghai.oss-cloudbox-control.aliyuncs.com"},
+	}
+	for _, t := range cases {
+		endpoint, err := t.resolver.Resolve(t.region)
+		c.Assert(err, IsNil)
+		c.Assert(endpoint, Equals, t.endpoint)
+	}
+
+	for _, region := range []string{"", "hangzhou", "cn_hangzhou", "CN-Hangzhou", "cn-hangzhou.evil.com"} {
+		_, err := EndpointResolver{}.Resolve(region)
+		c.Assert(err, NotNil, Commentf("region %q", region))
+	}
+	_, err := EndpointResolver{Network: NetworkCloudBoxData}.Resolve("cn-shanghai")
+	c.Assert(err, NotNil)
+	_, err = EndpointResolver{Network: "vpc"}.Resolve("cn-shanghai")
+	c.Assert(err, NotNil)
+	_, err = EndpointResolver{Scheme: "ftp"}.Resolve("cn-shanghai")
+	c.Assert(err, NotNil)
+
+	// The regions of DescribeRegions
+	regions := []RegionInfo{{Region: "oss-cn-hangzhou", InternetEndpoint: "oss-cn-hangzhou.aliyuncs.com",
+		InternalEndpoint: "oss-cn-hangzhou-internal.aliyuncs.com", AccelerateEndpoint: "oss-accelerate.aliyuncs.com"}}
+	endpoint, err := EndpointResolver{Network: NetworkInternal, Regions: regions}.Resolve("cn-hangzhou")
+	c.Assert(err, IsNil)
+	c.Assert(endpoint, Equals, "oss-cn-hangzhou-internal.aliyuncs.com")
+	_, err = EndpointResolver{Regions: regions}.Resolve("cn-beijing")
+	c.Assert(err, NotNil)
+}
+
+func (s *OssEndpointSuite) TestSigningRegion(c *C) {
+	cases := []struct {
+		host       string
+		region     string
+		cloudBoxID string
+	}{
+		{"oss-cn-hangzhou.aliyuncs.com", "cn-hangzhou", ""},
+		{"OSS-cn-hangzhou-internal.aliyuncs.com:443", "cn-hangzhou", ""},
+		{"bucket.oss-us-west-1.aliyuncs.com", "us-west-1", ""},
+		{"cn-beijing.oss.aliyuncs.com", "cn-beijing", ""},
+		{"cb-123.cn-shanghai.oss-cloudbox.aliyuncs.com", "cn-shanghai", "cb-123"},
+		{"oss-accelerate.aliyuncs.com", "", ""},
+		{"oss-cn-hangzhou.example.com", "", ""},
+		{"127.0.0.1:8080", "", ""},
+	}
+	for _, t := range cases {
+		region, cloudBoxID := signingScopeFromHost(t.host)
+		c.Assert(region, Equals, t.region, Commentf("host %s", t.host))
+		c.Assert(cloudBoxID, Equals, t.cloudBoxID, Commentf("host %s", t.host))
+	}
+
+	client, err := New("oss-cn-hangzhou.aliyuncs.com", "ak", "sk", AuthVersion(AuthV4))
+	c.Assert(err, IsNil)
+	c.Assert(client.Config.GetSignRegion(), Equals, "cn-hangzhou")
+	client, err = New("oss-cn-hangzhou.aliyuncs.com", "ak", "sk", AuthVersion(AuthV4), Region("cn-beijing"))
+	c.Assert(err, IsNil)
+	c.Assert(client.Config.GetSignRegion(), Equals, "cn-beijing")
+	client, err = New("oss-cn-hangzhou.aliyuncs.com", "ak", "sk")
+	c.Assert(err, IsNil)
+	c.Assert(client.Config.Region, Equals, "")
+
+	client, err = NewWithRegion("cn-shenzhen", "ak", "sk", AuthVersion(AuthV4))
+	c.Assert(err, IsNil)
+	c.Assert(client.Config.Endpoint, Equals, "https://oss-cn-shenzhen.aliyuncs.com")
+	c.Assert(client.Config.Region, Equals, "cn-shenzhen")
+	_, err = NewWithRegion("shenzhen", "ak", "sk")
+	c.Assert(err, NotNil)
+}
@@ -0,0 +1,261 @@
+package oss
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EndpointPolicyType decides the order in which the endpoints are tried
+type EndpointPolicyType string
+
+const (
+	// EndpointPrimary tries the endpoints in order, the later endpoints are the fallbacks
+	EndpointPrimary EndpointPolicyType = "primary"
+
+	// EndpointLatency tries the endpoint of the lowest average latency first
+	EndpointLatency EndpointPolicyType = "latency"
+
+	// EndpointRoundRobin spreads the requests over the endpoints
+	EndpointRoundRobin EndpointPolicyType = "round-robin"
+)
+
+// EndpointBreaker defines the circuit breaker of the endpoints
+type EndpointBreaker struct {
+	FailureThreshold int           // The consecutive failures to open the breaker. Default is 3.
+	ProbeInterval    time.Duration // The interval after which a request probes the open endpoint. Default is 30s.
+}
+
+// EndpointStat is the metrics of an endpoint
+type EndpointStat struct {
+	Endpoint            string
+	Requests            int64         // The requests sent to the endpoint
+	Failures            int64         // The network failures and the 5xx responses
+	ConsecutiveFailures int           // The failures since the last success
+	Latency             time.Duration // The moving average latency of the requests
+	Available           bool          // It's false if the breaker is open
+}
+
+// endpointState is the health of an endpoint
+type endpointState struct {
+	name                string
+	url                 *urlMaker
+	requests            int64
+	failures            int64
+	consecutiveFailures int
+	latency             time.Duration
+	openUntil           time.Time // The breaker is open until the time, then a request probes the endpoint
+	probing             bool
+}
+
+// endpointSet is the endpoints of a client, the first is Config.Endpoint and the rest are Config.Endpoints
+type endpointSet struct {
+	policy    EndpointPolicyType
+	breaker   EndpointBreaker
+	mu        sync.Mutex
+	endpoints []*endpointState
+	next      int // The next endpoint of EndpointRoundRobin
+}
+
+func newEndpointSet(config *Config) (*endpointSet, error) {
+	s := &endpointSet{policy: config.EndpointPolicy, breaker: config.EndpointBreaker}
+	if s.breaker.FailureThreshold <= 0 {
+		s.breaker.FailureThreshold = 3
+	}
+	if s.breaker.ProbeInterval <= 0 {
+		s.breaker.ProbeInterval = 30 * time.Second
+	}
+	for _, endpoint := range append([]string{config.Endpoint}, config.Endpoints...) {
+		um := &urlMaker{}
+		if err := um.InitExt(endpoint, config.IsCname, config.IsUseProxy, config.IsPathStyle); err != nil {
+			return nil, err
+		}
+		s.endpoints = append(s.endpoints, &endpointState{name: endpoint, url: um})
+	}
+	return s, nil
+}
+
+// pick returns the endpoints to try in order. An open endpoint whose probe interval elapses is tried first by one
+// request, and all the endpoints are tried if all the breakers are open.
+func (s *endpointSet) pick(now time.Time) []*endpointState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var probe *endpointState
+	var available []*endpointState
+	for _, ep := range s.endpoints {
+		if ep.consecutiveFailures < s.breaker.FailureThreshold {
+			available = append(available, ep)
+		} else if probe == nil && !ep.probing && !now.Before(ep.openUntil) {
+			probe = ep
+		}
+	}
+
+	switch s.policy {
+	case EndpointLatency:
+		// Insertion sort keeps the order of the same latency, the unmeasured endpoints are tried first
+		for i := 1; i < len(available); i++ {
+			for j := i; j > 0 && available[j].latency < available[j-1].latency; j-- {
+				available[j], available[j-1] = available[j-1], available[j]
+			}
+		}
+	case EndpointRoundRobin:
+		if len(available) > 0 {
+			start := s.next % len(available)
+			s.next++
+			available = append(available[start:], available[:start]...)
+		}
+	}
+
+	if len(available) == 0 {
+		available = s.endpoints
+	}
+	if probe == nil {
+		return available
+	}
+	probe.probing = true
+	candidates := []*endpointState{probe}
+	for _, ep := range available {
+		if ep != probe {
+			candidates = append(candidates, ep)
+		}
+	}
+	return candidates
+}
+
+// done records the result of the request sent to the endpoint
+func (s *endpointSet) done(ep *endpointState, failed bool, latency time.Duration, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ep.probing = false
+	ep.requests++
+	if failed {
+		ep.failures++
+		ep.consecutiveFailures++
+		if ep.consecutiveFailures >= s.breaker.FailureThreshold {
+			ep.openUntil = now.Add(s.breaker.ProbeInterval)
+		}
+		return
+	}
+	ep.consecutiveFailures = 0
+	if ep.latency == 0 {
+		ep.latency = latency
+	} else {
+		ep.latency += (latency - ep.latency) / 5
+	}
+}
+
+// cancel releases the endpoint whose request is canceled or throttled, the result isn't recorded
+func (s *endpointSet) cancel(ep *endpointState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ep.probing = false
+}
+
+func (s *endpointSet) stats(now time.Time) []EndpointStat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := make([]EndpointStat, 0, len(s.endpoints))
+	for _, ep := range s.endpoints {
+		stats = append(stats, EndpointStat{
+			Endpoint:            ep.name,
+			Requests:            ep.requests,
+			Failures:            ep.failures,
+			ConsecutiveFailures: ep.consecutiveFailures,
+			Latency:             ep.latency,
+			Available:           ep.consecutiveFailures < s.breaker.FailureThreshold || !now.Before(ep.openUntil),
+		})
+	}
+	return stats
+}
+
+// endpointFailed checks if the request fails by the endpoint, such as the network failures and the 5xx responses
+func endpointFailed(resp *Response, err error) bool {
+	if resp != nil {
+		return resp.StatusCode >= 500
+	}
+	_, ok := err.(net.Error)
+	return ok
+}
+
+// endpointThrottled checks if the request is throttled by SlowDown, which is the limit of the bucket rather than a
+// failure of the endpoint
+func endpointThrottled(err error) bool {
+	srvErr, ok := err.(ServiceError)
+	return ok && srvErr.Code == "SlowDown"
+}
+
+// failoverAllowed checks if the failed request could be sent to the next endpoint. GET, HEAD, PUT and DELETE are
+// idempotent, the other requests such as POST are sent again only if the connection failed, since the first endpoint
+// may have done them.
+func failoverAllowed(method string, resp *Response, err error) bool {
+	switch strings.ToUpper(method) {
+	case string(HTTPGet), string(HTTPHead), string(HTTPPut), string(HTTPDelete):
+		return true
+	}
+	if resp != nil {
+		return false
+	}
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
+	}
+	opErr, ok := err.(*net.OpError)
+	return ok && opErr.Op == "dial"
+}
+
+// EndpointStats returns the metrics of the endpoints, it's nil if Config.Endpoints isn't set.
+//
+// []EndpointStat    the metrics of Config.Endpoint and Config.Endpoints in order.
+func (client Client) EndpointStats() []EndpointStat {
+	if client.Config.endpoints == nil {
+		return nil
+	}
+	return client.Config.endpoints.stats(time.Now())
+}
+
+// doEndpoints sends the request to the endpoints until it doesn't fail by the endpoint, the body is rewound before it's
+// sent again. The non-idempotent requests are failed over only if they weren't sent. The request is sent to Config.Endpoint only if Config.Endpoints isn't set.
+func (conn Conn) doEndpoints(ctx context.Context, method, bucketName, objectName, urlParams, resource string,
+	headers map[string]string, data io.Reader, initCRC uint64, listener ProgressListener, rewind func() bool) (*Response, error) {
+	set := conn.config.endpoints
+	if set == nil {
+		uri := conn.url.getURL(bucketName, objectName, urlParams)
+		return conn.doRequest(ctx, method, uri, resource, headers, data, initCRC, listener)
+	}
+
+	var resp *Response
+	var err error
+	candidates := set.pick(time.Now())
+	for i, ep := range candidates {
+		if i > 0 {
+			if !rewind() {
+				break
+			}
+			if resp != nil {
+				resp.Body.Close()
+			}
+			conn.config.WriteLog(Warn, "failover from endpoint %s to %s\n", candidates[i-1].name, ep.name)
+		}
+		start := time.Now()
+		resp, err = conn.doRequest(ctx, method, ep.url.getURL(bucketName, objectName, urlParams), resource, headers, data,
+			initCRC, listener)
+		if resp != nil {
+			resp.Endpoint = ep.name
+		}
+		if (ctx != nil && ctx.Err() != nil) || endpointThrottled(err) {
+			set.cancel(ep)
+			return resp, err
+		}
+		failed := endpointFailed(resp, err)
+		set.done(ep, failed, time.Since(start), time.Now())
+		conn.config.WriteLog(Debug, "endpoint:%s, failed:%t\n", ep.name, failed)
+		if !failed || !failoverAllowed(method, resp, err) {
+			return resp, err
+		}
+	}
+	return resp, err
+}
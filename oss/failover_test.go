@@ -0,0 +1,183 @@
+package oss
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type OssFailoverSuite struct{}
+
+var _ = Suite(&OssFailoverSuite{})
+
+// newCountingServer counts the requests, and fails them by 503 if the failing is not 0
+func newCountingServer(c *C, count, failing *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(count, 1)
+		if atomic.LoadInt32(failing) != 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		data, err := ioutil.ReadAll(r.Body)
+		c.Assert(err, IsNil)
+		if r.Method == "PUT" {
+			c.Assert(string(data), Equals, "data")
+		}
+	}))
+}
+
+func (s *OssFailoverSuite) TestFailover(c *C) {
+	var count1, count2, failing1, failing2 int32
+	server1 := newCountingServer(c, &count1, &failing1)
+	defer server1.Close()
+	server2 := newCountingServer(c, &count2, &failing2)
+	defer server2.Close()
+
+	client, err := New(server1.URL, "ak", "sk", FailoverEndpoints(EndpointPrimary, server2.URL),
+		EndpointBreakerSetting(2, 50*time.Millisecond))
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("bucket")
+	c.Assert(err, IsNil)
+
+	// The body is sent again to the fallback endpoint
+	atomic.StoreInt32(&failing1, 1)
+	c.Assert(bucket.PutObject("a", strings.NewReader("data")), IsNil)
+	c.Assert(bucket.PutObject("a", strings.NewReader("data")), IsNil)
+	c.Assert(atomic.LoadInt32(&count1), Equals, int32(2))
+	c.Assert(atomic.LoadInt32(&count2), Equals, int32(2))
+
+	// The breaker is open after 2 failures
+	c.Assert(bucket.PutObject("a", strings.NewReader("data")), IsNil)
+	c.Assert(atomic.LoadInt32(&count1), Equals, int32(2))
+	stats := client.EndpointStats()
+	c.Assert(len(stats), Equals, 2)
+	c.Assert(stats[0].Endpoint, Equals, server1.URL)
+	c.Assert(stats[0].Failures, Equals, int64(2))
+	c.Assert(stats[0].Available, Equals, false)
+	c.Assert(stats[1].Requests, Equals, int64(3))
+
+	// The body which can't be sent again isn't failed over
+	atomic.StoreInt32(&failing2, 1)
+	err = bucket.PutObject("a", io.MultiReader(strings.NewReader("data")))
+	c.Assert(err.(ServiceError).StatusCode, Equals, http.StatusServiceUnavailable)
+	atomic.StoreInt32(&failing2, 0)
+
+	// The endpoint is probed after the interval
+	atomic.StoreInt32(&failing1, 0)
+	time.Sleep(60 * time.Millisecond)
+	c.Assert(bucket.PutObject("a", strings.NewReader("data")), IsNil)
+	c.Assert(atomic.LoadInt32(&count1), Equals, int32(3))
+	c.Assert(client.EndpointStats()[0].Available, Equals, true)
+	c.Assert(client.EndpointStats()[0].ConsecutiveFailures, Equals, 0)
+
+	// The network failure
+	server1.Close()
+	_, err = bucket.GetObjectDetailedMeta("a")
+	c.Assert(err, IsNil)
+	c.Assert(client.EndpointStats()[0].ConsecutiveFailures, Equals, 1)
+
+	// The signed URL targets the endpoint
+	signedURL, err := bucket.SignURL("a", HTTPGet, 60, SignEndpoint(server2.URL))
+	c.Assert(err, IsNil)
+	c.Assert(strings.HasPrefix(signedURL, server2.URL+"/bucket/a?"), Equals, true)
+	signedURL, err = bucket.SignURL("a", HTTPGet, 60)
+	c.Assert(err, IsNil)
+	c.Assert(strings.HasPrefix(signedURL, server1.URL+"/bucket/a?"), Equals, true)
+}
+
+func (s *OssFailoverSuite) TestFailoverNotIdempotent(c *C) {
+	var count1, count2, failing2, slowDown int32
+	server1 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count1, 1)
+		if atomic.LoadInt32(&slowDown) != 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("<Error><Code>SlowDown</Code><Message>Please reduce your request rate.</Message></Error>"))
+			return
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server1.Close()
+	server2 := newCountingServer(c, &count2, &failing2)
+	defer server2.Close()
+
+	client, err := New(server1.URL, "ak", "sk", FailoverEndpoints(EndpointPrimary, server2.URL),
+		EndpointBreakerSetting(3, time.Minute))
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("bucket")
+	c.Assert(err, IsNil)
+
+	// POST isn't sent again after the endpoint received it
+	_, err = bucket.InitiateMultipartUpload("a")
+	c.Assert(err.(ServiceError).StatusCode, Equals, http.StatusServiceUnavailable)
+	c.Assert(atomic.LoadInt32(&count1), Equals, int32(1))
+	c.Assert(atomic.LoadInt32(&count2), Equals, int32(0))
+	c.Assert(client.EndpointStats()[0].ConsecutiveFailures, Equals, 1)
+
+	// SlowDown isn't a failure of the endpoint
+	atomic.StoreInt32(&slowDown, 1)
+	err = bucket.PutObject("a", strings.NewReader("data"))
+	c.Assert(err.(ServiceError).Code, Equals, "SlowDown")
+	c.Assert(atomic.LoadInt32(&count1), Equals, int32(2))
+	c.Assert(atomic.LoadInt32(&count2), Equals, int32(0))
+	c.Assert(client.EndpointStats()[0].ConsecutiveFailures, Equals, 1)
+	c.Assert(client.EndpointStats()[0].Failures, Equals, int64(1))
+
+	// POST is sent to the next endpoint if it failed to connect
+	server1.Close()
+	client, err = New(server1.URL, "ak", "sk", FailoverEndpoints(EndpointPrimary, server2.URL))
+	c.Assert(err, IsNil)
+	bucket, err = client.Bucket("bucket")
+	c.Assert(err, IsNil)
+	bucket.InitiateMultipartUpload("a")
+	c.Assert(atomic.LoadInt32(&count2), Equals, int32(1))
+}
+
+func (s *OssFailoverSuite) TestPolicy(c *C) {
+	var count1, count2, failing int32
+	server1 := newCountingServer(c, &count1, &failing)
+	defer server1.Close()
+	server2 := newCountingServer(c, &count2, &failing)
+	defer server2.Close()
+
+	client, err := New(server1.URL, "ak", "sk", FailoverEndpoints(EndpointRoundRobin, server2.URL))
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("bucket")
+	c.Assert(err, IsNil)
+	for i := 0; i < 4; i++ {
+		_, err = bucket.GetObjectDetailedMeta("a")
+		c.Assert(err, IsNil)
+	}
+	c.Assert(atomic.LoadInt32(&count1), Equals, int32(2))
+	c.Assert(atomic.LoadInt32(&count2), Equals, int32(2))
+
+	// The endpoint of the lowest latency first, the unmeasured ones are tried first
+	config := getDefaultOssConfig()
+	config.Endpoint = "a.example.com"
+	config.Endpoints = []string{"b.example.com", "c.example.com"}
+	config.EndpointPolicy = EndpointLatency
+	set, err := newEndpointSet(config)
+	c.Assert(err, IsNil)
+	now := time.Now()
+	set.done(set.endpoints[0], false, 300*time.Millisecond, now)
+	set.done(set.endpoints[1], false, 100*time.Millisecond, now)
+	var names []string
+	for _, ep := range set.pick(now) {
+		names = append(names, ep.name)
+	}
+	c.Assert(names, DeepEquals, []string{"c.example.com", "b.example.com", "a.example.com"})
+
+	// All the endpoints are tried if all the breakers are open
+	for i := 0; i < 3; i++ {
+		for _, ep := range set.endpoints {
+			set.done(ep, true, 0, now)
+		}
+	}
+	c.Assert(len(set.pick(now)), Equals, 3)
+	c.Assert(len(set.pick(now.Add(time.Minute))), Equals, 3)
+}
@@ -0,0 +1,182 @@
+package oss
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// hedgeConfig is the argument of the Hedge option
+type hedgeConfig struct {
+	delay    time.Duration
+	maxExtra int
+}
+
+// Hedge is an option of GetObject, GetObjectMeta and the other GET and HEAD requests of the objects, including the
+// ranged reads. If the response headers aren't received after the delay, a duplicate request is sent, up to maxExtra
+// duplicates each after another delay. The first response is used and the other requests are canceled. It's for the
+// small hot objects read by the latency sensitive services, since the duplicates are billed as the requests.
+//
+// delay    the time to wait for the response headers before sending a duplicate, no duplicate is sent if it's not positive.
+// maxExtra    the max number of the duplicates, no duplicate is sent if it's not positive.
+func Hedge(delay time.Duration, maxExtra int) Option {
+	if delay <= 0 || maxExtra < 0 {
+		maxExtra = 0
+	}
+	return addArg(hedgeArg, hedgeConfig{delay: delay, maxExtra: maxExtra})
+}
+
+// HedgeStats is the statistics of the requests with the Hedge option
+type HedgeStats struct {
+	Requests  int64 // The requests with the Hedge option
+	Hedged    int64 // The requests which sent the duplicates
+	Extra     int64 // The duplicates sent
+	ExtraWins int64 // The requests served by a duplicate
+}
+
+// hedgeCounters counts the hedged requests of a client
+type hedgeCounters struct {
+	requests  int64
+	hedged    int64
+	extra     int64
+	extraWins int64
+}
+
+// HedgeStats returns the statistics of the requests with the Hedge option.
+//
+// HedgeStats    the statistics since the client is created.
+func (client Client) HedgeStats() HedgeStats {
+	h := client.Config.hedgeCounters
+	if h == nil {
+		return HedgeStats{}
+	}
+	return HedgeStats{
+		Requests:  atomic.LoadInt64(&h.requests),
+		Hedged:    atomic.LoadInt64(&h.hedged),
+		Extra:     atomic.LoadInt64(&h.extra),
+		ExtraWins: atomic.LoadInt64(&h.extraWins),
+	}
+}
+
+// hedgeResult is the result of a request or a duplicate
+type hedgeResult struct {
+	resp  *Response
+	err   error
+	index int
+}
+
+// cancelOnClose cancels the context of the response when the body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	err := c.ReadCloser.Close()
+	c.cancel()
+	return err
+}
+
+// doHedged sends the request without body, and sends the duplicates if the response headers aren't received after
+// the delay. The first response is used, unless it's a network failure and the other requests are still pending.
+// The attempts have no listener, the listener receives the events of the request once from the calling goroutine.
+func (conn Conn) doHedged(ctx context.Context, hedge hedgeConfig, method, bucketName, objectName string,
+	params map[string]interface{}, headers map[string]string, listener ProgressListener) (*Response, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	counters := conn.config.hedgeCounters
+	if counters == nil {
+		counters = &hedgeCounters{}
+	}
+	atomic.AddInt64(&counters.requests, 1)
+
+	results := make(chan hedgeResult, hedge.maxExtra+1)
+	var cancels []context.CancelFunc
+	send := func() {
+		index := len(cancels)
+		attemptCtx, cancel := context.WithCancel(ctx)
+		cancels = append(cancels, cancel)
+		go func() {
+			resp, err := conn.DoWithContext(attemptCtx, method, bucketName, objectName, params, headers, nil, 0, nil)
+			results <- hedgeResult{resp: resp, err: err, index: index}
+		}()
+	}
+
+	event := newProgressEvent(TransferStartedEvent, 0, 0, 0)
+	publishProgress(listener, event)
+	send()
+	pending := 1
+	timer := time.NewTimer(hedge.delay)
+	defer timer.Stop()
+	var last hedgeResult
+	for pending > 0 {
+		select {
+		case r := <-results:
+			pending--
+			last = r
+			if r.err != nil && r.resp == nil && pending > 0 {
+				cancels[r.index]()
+				continue
+			}
+			conn.finishHedged(r, counters, cancels, pending, results)
+			publishHedged(listener, r)
+			return r.resp, r.err
+		case <-timer.C:
+			if len(cancels) > hedge.maxExtra {
+				continue
+			}
+			if len(cancels) == 1 {
+				atomic.AddInt64(&counters.hedged, 1)
+			}
+			atomic.AddInt64(&counters.extra, 1)
+			conn.config.WriteLog(Debug, "send the duplicate %d of %s %s/%s after %s\n", len(cancels), method, bucketName,
+				objectName, hedge.delay.String())
+			send()
+			pending++
+			timer.Reset(hedge.delay)
+		}
+	}
+	// All the requests fail
+	publishHedged(listener, last)
+	return last.resp, last.err
+}
+
+// publishHedged publishes the end of the request by the result used, it fails if no response is received
+func publishHedged(listener ProgressListener, r hedgeResult) {
+	eventType := TransferCompletedEvent
+	if r.resp == nil {
+		eventType = TransferFailedEvent
+	}
+	event := newProgressEvent(eventType, 0, 0, 0)
+	publishProgress(listener, event)
+}
+
+// finishHedged uses the result, the other requests are canceled and their responses are closed
+func (conn Conn) finishHedged(r hedgeResult, counters *hedgeCounters, cancels []context.CancelFunc, pending int,
+	results chan hedgeResult) {
+	if r.index > 0 {
+		atomic.AddInt64(&counters.extraWins, 1)
+		conn.config.WriteLog(Info, "the duplicate %d is used, %d requests sent\n", r.index, len(cancels))
+	}
+	for i, cancel := range cancels {
+		if i != r.index {
+			cancel()
+		}
+	}
+	if r.resp != nil {
+		r.resp.Body = &cancelOnClose{ReadCloser: r.resp.Body, cancel: cancels[r.index]}
+	} else {
+		cancels[r.index]()
+	}
+	if pending > 0 {
+		go func() {
+			for i := 0; i < pending; i++ {
+				if loser := <-results; loser.resp != nil {
+					loser.resp.Body.Close()
+				}
+			}
+		}()
+	}
+}
@@ -0,0 +1,143 @@
+package oss
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type OssHedgeSuite struct{}
+
+var _ = Suite(&OssHedgeSuite{})
+
+func (s *OssHedgeSuite) TestHedge(c *C) {
+	var count int32
+	slow := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&count, 1)
+		if r.URL.Path == "/bucket/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("<Error><Code>NoSuchKey</Code></Error>"))
+			return
+		}
+		if r.URL.Path == "/bucket/slow" && n == 1 {
+			// The first request is slow until it's canceled
+			select {
+			case <-r.Context().Done():
+				slow <- "canceled"
+			case <-time.After(5 * time.Second):
+				slow <- "timeout"
+			}
+			return
+		}
+		w.Write([]byte("data"))
+	}))
+	defer server.Close()
+	client, err := New(server.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("bucket")
+	c.Assert(err, IsNil)
+
+	// The duplicate is used and the slow request is canceled
+	body, err := bucket.GetObject("slow", Hedge(20*time.Millisecond, 2), Range(0, 3))
+	c.Assert(err, IsNil)
+	data, err := ioutil.ReadAll(body)
+	c.Assert(err, IsNil)
+	body.Close()
+	c.Assert(string(data), Equals, "data")
+	c.Assert(<-slow, Equals, "canceled")
+	c.Assert(client.HedgeStats(), Equals, HedgeStats{Requests: 1, Hedged: 1, Extra: 1, ExtraWins: 1})
+
+	// The fast request isn't hedged
+	atomic.StoreInt32(&count, 0)
+	header, err := bucket.GetObjectMeta("fast", Hedge(time.Second, 2))
+	c.Assert(err, IsNil)
+	c.Assert(header.Get(HTTPHeaderContentLength), Equals, "4")
+	c.Assert(atomic.LoadInt32(&count), Equals, int32(1))
+
+	// The error response is used
+	_, err = bucket.GetObject("missing", Hedge(time.Second, 2))
+	c.Assert(err.(ServiceError).Code, Equals, "NoSuchKey")
+	c.Assert(client.HedgeStats(), Equals, HedgeStats{Requests: 3, Hedged: 1, Extra: 1, ExtraWins: 1})
+}
+
+func (s *OssHedgeSuite) TestHedgeInvalid(c *C) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte("data"))
+	}))
+	defer server.Close()
+	client, err := New(server.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("bucket")
+	c.Assert(err, IsNil)
+
+	// No duplicate is sent without a positive delay or maxExtra
+	_, err = bucket.GetObjectMeta("a", Hedge(0, 2))
+	c.Assert(err, IsNil)
+	_, err = bucket.GetObjectMeta("a", Hedge(-time.Second, 2))
+	c.Assert(err, IsNil)
+	_, err = bucket.GetObjectMeta("a", Hedge(time.Millisecond, -5))
+	c.Assert(err, IsNil)
+	c.Assert(atomic.LoadInt32(&count), Equals, int32(3))
+	c.Assert(client.HedgeStats(), Equals, HedgeStats{Requests: 3})
+}
+
+// hedgeEventListener records the types of the progress events
+type hedgeEventListener struct {
+	mu     sync.Mutex
+	events []ProgressEventType
+}
+
+func (l *hedgeEventListener) ProgressChanged(event *ProgressEvent) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if event.EventType != TransferDataEvent {
+		l.events = append(l.events, event.EventType)
+	}
+}
+
+func (s *OssHedgeSuite) TestHedgeProgress(c *C) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&count, 1) == 2 {
+			// The first request of the hedged read is slow until it's canceled
+			<-r.Context().Done()
+			return
+		}
+		w.Write([]byte("data"))
+	}))
+	defer server.Close()
+	client, err := New(server.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("bucket")
+	c.Assert(err, IsNil)
+
+	read := func(options ...Option) []ProgressEventType {
+		listener := &hedgeEventListener{}
+		resp, err := bucket.do("GET", "a", nil, options, nil, listener)
+		c.Assert(err, IsNil)
+		data, err := ioutil.ReadAll(resp.Body)
+		c.Assert(err, IsNil)
+		c.Assert(string(data), Equals, "data")
+		resp.Body.Close()
+		listener.mu.Lock()
+		defer listener.mu.Unlock()
+		return listener.events
+	}
+
+	// The hedged request publishes the events once, though a duplicate is sent
+	plain := read()
+	c.Assert(plain, DeepEquals, []ProgressEventType{TransferStartedEvent, TransferCompletedEvent})
+	c.Assert(read(Hedge(20*time.Millisecond, 2)), DeepEquals, plain)
+	c.Assert(client.HedgeStats().Extra, Equals, int64(1))
+	time.Sleep(50 * time.Millisecond)
+	c.Assert(atomic.LoadInt32(&count), Equals, int32(3))
+}
@@ -0,0 +1,362 @@
+package oss
+
+import (
+	"compress/gzip"
+	"crypto/md5"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The fields of the inventory reports
+const (
+	InventoryFieldBucket              = "Bucket"
+	InventoryFieldKey                 = "Key"
+	InventoryFieldVersionId           = "VersionId"
+	InventoryFieldIsLatest            = "IsLatest"
+	InventoryFieldIsDeleteMarker      = "IsDeleteMarker"
+	InventoryFieldSize                = "Size"
+	InventoryFieldLastModifiedDate    = "LastModifiedDate"
+	InventoryFieldETag                = "ETag"
+	InventoryFieldStorageClass        = "StorageClass"
+	InventoryFieldIsMultipartUploaded = "IsMultipartUploaded"
+	InventoryFieldEncryptionStatus    = "EncryptionStatus"
+)
+
+// The report directories are named by the time, such as 2024-01-02T03-04Z
+var inventoryReportDir = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}-\d{2}Z$`)
+
+// InventoryManifest is the manifest.json of an inventory report
+type InventoryManifest struct {
+	CreationTimestamp string          `json:"creationTimestamp"`
+	DestinationBucket string          `json:"destinationBucket"`
+	FileFormat        string          `json:"fileFormat"`
+	FileSchema        string          `json:"fileSchema"` // The fields of the records, such as "Bucket, Key, Size"
+	Files             []InventoryFile `json:"files"`
+	SourceBucket      string          `json:"sourceBucket"`
+	Version           string          `json:"version"`
+	Key               string          `json:"-"` // The key of the manifest
+}
+
+// InventoryFile is a data file of an inventory report
+type InventoryFile struct {
+	Key         string `json:"key"`
+	Size        int64  `json:"size"`
+	MD5checksum string `json:"MD5checksum"` // The hex MD5 of the gzip file
+}
+
+// Fields returns the fields of the records in the order of the columns
+func (manifest InventoryManifest) Fields() []string {
+	var fields []string
+	for _, field := range strings.Split(manifest.FileSchema, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// InventoryRecord is an object of the inventory report, the fields not in the report are zero values
+type InventoryRecord struct {
+	Bucket              string
+	Key                 string // The object key, which is URL decoded
+	VersionId           string
+	IsLatest            bool
+	IsDeleteMarker      bool
+	Size                int64
+	LastModifiedDate    time.Time
+	ETag                string
+	StorageClass        string
+	IsMultipartUploaded bool
+	EncryptionStatus    bool
+	Extra               map[string]string // The fields not known by the SDK
+	File                int               // The index of the data file in the manifest
+}
+
+// GetInventoryManifest gets and parses the manifest.json of an inventory report
+//
+// bucket    the destination bucket of the inventory.
+// manifestKey    the key of the manifest.json.
+//
+// InventoryManifest    the manifest.
+// error    it's nil if no error, otherwise it's an error object.
+func GetInventoryManifest(bucket *Bucket, manifestKey string, options ...Option) (InventoryManifest, error) {
+	var manifest InventoryManifest
+	body, err := bucket.GetObject(manifestKey, options...)
+	if err != nil {
+		return manifest, err
+	}
+	defer body.Close()
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return manifest, err
+	}
+	if err = json.Unmarshal(data, &manifest); err != nil {
+		return manifest, fmt.Errorf("oss: invalid inventory manifest %s: %s", manifestKey, err.Error())
+	}
+	if manifest.FileFormat != "" && !strings.EqualFold(manifest.FileFormat, "CSV") {
+		return manifest, fmt.Errorf("oss: unsupported inventory file format %s", manifest.FileFormat)
+	}
+	manifest.Key = manifestKey
+	return manifest, nil
+}
+
+// LatestInventoryManifest finds the latest report of the inventory, which is
+// <prefix>/<sourceBucket>/<inventoryID>/<YYYY-MM-DDTHH-MMZ>/manifest.json in the destination bucket.
+// The reports without manifest.json are still being written and they're ignored.
+//
+// bucket    the destination bucket of the inventory.
+// prefix    the prefix of OSSBucketDestination, it could be empty.
+// sourceBucket    the bucket of the inventory.
+// inventoryID    the inventory ID.
+//
+// InventoryManifest    the manifest.
+// error    it's nil if no error, otherwise it's an error object.
+func LatestInventoryManifest(bucket *Bucket, prefix, sourceBucket, inventoryID string, options ...Option) (InventoryManifest, error) {
+	base := sourceBucket + "/" + inventoryID + "/"
+	if prefix = strings.TrimSuffix(prefix, "/"); prefix != "" {
+		base = prefix + "/" + base
+	}
+
+	var dirs []string
+	token := ""
+	for {
+		listOptions := append([]Option{}, options...)
+		result, err := bucket.ListObjectsV2(append(listOptions, Prefix(base), Delimiter("/"), ContinuationToken(token))...)
+		if err != nil {
+			return InventoryManifest{}, err
+		}
+		for _, dir := range result.CommonPrefixes {
+			if inventoryReportDir.MatchString(strings.TrimSuffix(strings.TrimPrefix(dir, base), "/")) {
+				dirs = append(dirs, dir)
+			}
+		}
+		if !result.IsTruncated {
+			break
+		}
+		token = result.NextContinuationToken
+	}
+
+	// The names are sortable by the time
+	sort.Sort(sort.Reverse(sort.StringSlice(dirs)))
+	for _, dir := range dirs {
+		manifest, err := GetInventoryManifest(bucket, dir+"manifest.json", options...)
+		if err == nil {
+			return manifest, nil
+		}
+		if srvErr, ok := err.(ServiceError); !ok || srvErr.Code != "NoSuchKey" {
+			return manifest, err
+		}
+	}
+	return InventoryManifest{}, fmt.Errorf("oss: no inventory report found under %s", base)
+}
+
+// InventoryReader reads the records of an inventory report
+type InventoryReader struct {
+	bucket   *Bucket
+	manifest InventoryManifest
+	fields   []string
+	options  []Option
+
+	file    int // The index of the next file to open
+	body    io.ReadCloser
+	hasher  hash.Hash
+	counted *inventoryCountingReader
+	csv     *csv.Reader
+}
+
+// NewInventoryReader creates the reader of the report. The data files are streamed one by one, and the MD5 and the size
+// of a file are verified at its end, so the records of a corrupt file are returned before the error.
+//
+// bucket    the destination bucket of the inventory.
+// manifest    the manifest of the report, such as the result of LatestInventoryManifest.
+// options    the options of GetObject.
+//
+// *InventoryReader    the reader.
+func NewInventoryReader(bucket *Bucket, manifest InventoryManifest, options ...Option) *InventoryReader {
+	return &InventoryReader{bucket: bucket, manifest: manifest, fields: manifest.Fields(), options: options}
+}
+
+// OpenInventoryReader opens the latest report of the inventory of the bucket, the destination is got by GetBucketInventory
+//
+// bucketName    the bucket name.
+// inventoryID    the inventory ID.
+//
+// *InventoryReader    the reader.
+// error    it's nil if no error, otherwise it's an error object.
+func (client Client) OpenInventoryReader(bucketName, inventoryID string, options ...Option) (*InventoryReader, error) {
+	config, err := client.GetBucketInventory(bucketName, inventoryID, options...)
+	if err != nil {
+		return nil, err
+	}
+	destination := config.OSSBucketDestination
+	// The destination is acs:oss:::bucket
+	destBucket := destination.Bucket[strings.LastIndex(destination.Bucket, ":")+1:]
+	bucket, err := client.Bucket(destBucket)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := LatestInventoryManifest(bucket, destination.Prefix, bucketName, inventoryID, options...)
+	if err != nil {
+		return nil, err
+	}
+	return NewInventoryReader(bucket, manifest, options...), nil
+}
+
+// Manifest returns the manifest of the report
+func (r *InventoryReader) Manifest() InventoryManifest {
+	return r.manifest
+}
+
+// Next reads the next record, io.EOF is returned after the last record
+func (r *InventoryReader) Next() (InventoryRecord, error) {
+	for {
+		if r.csv == nil {
+			if r.file >= len(r.manifest.Files) {
+				return InventoryRecord{}, io.EOF
+			}
+			if err := r.openFile(); err != nil {
+				return InventoryRecord{}, err
+			}
+		}
+
+		values, err := r.csv.Read()
+		if err == io.EOF {
+			if err = r.closeFile(); err != nil {
+				return InventoryRecord{}, err
+			}
+			continue
+		}
+		if err != nil {
+			return InventoryRecord{}, fmt.Errorf("oss: read inventory file %s failed: %s", r.manifest.Files[r.file-1].Key, err.Error())
+		}
+		return r.record(values)
+	}
+}
+
+// Close closes the data file being read
+func (r *InventoryReader) Close() error {
+	if r.body == nil {
+		return nil
+	}
+	err := r.body.Close()
+	r.body, r.csv = nil, nil
+	return err
+}
+
+func (r *InventoryReader) openFile() error {
+	file := r.manifest.Files[r.file]
+	body, err := r.bucket.GetObject(file.Key, r.options...)
+	if err != nil {
+		return err
+	}
+	r.file++
+	r.body, r.hasher = body, md5.New()
+	r.counted = &inventoryCountingReader{reader: io.TeeReader(body, r.hasher)}
+	gz, err := gzip.NewReader(r.counted)
+	if err != nil {
+		r.Close()
+		return fmt.Errorf("oss: read inventory file %s failed: %s", file.Key, err.Error())
+	}
+	r.csv = csv.NewReader(gz)
+	r.csv.FieldsPerRecord = len(r.fields)
+	return nil
+}
+
+// closeFile verifies the file after its records are read
+func (r *InventoryReader) closeFile() error {
+	file := r.manifest.Files[r.file-1]
+	// The bytes after the gzip stream are counted too
+	_, err := io.Copy(ioutil.Discard, r.counted)
+	r.Close()
+	if err != nil {
+		return err
+	}
+	if file.Size > 0 && r.counted.n != file.Size {
+		return fmt.Errorf("oss: inventory file %s size mismatch, expect %d, got %d", file.Key, file.Size, r.counted.n)
+	}
+	if sum := hex.EncodeToString(r.hasher.Sum(nil)); file.MD5checksum != "" && !strings.EqualFold(sum, file.MD5checksum) {
+		return fmt.Errorf("oss: inventory file %s MD5 mismatch, expect %s, got %s", file.Key, file.MD5checksum, sum)
+	}
+	return nil
+}
+
+func (r *InventoryReader) record(values []string) (InventoryRecord, error) {
+	record := InventoryRecord{File: r.file - 1}
+	for i, field := range r.fields {
+		value := values[i]
+		var err error
+		switch field {
+		case InventoryFieldBucket:
+			record.Bucket = value
+		case InventoryFieldKey:
+			record.Key, err = UnescapeObjectKey(value)
+		case InventoryFieldVersionId:
+			record.VersionId = value
+		case InventoryFieldIsLatest:
+			record.IsLatest, err = parseInventoryBool(value)
+		case InventoryFieldIsDeleteMarker:
+			record.IsDeleteMarker, err = parseInventoryBool(value)
+		case InventoryFieldSize:
+			if value != "" {
+				record.Size, err = strconv.ParseInt(value, 10, 64)
+			}
+		case InventoryFieldLastModifiedDate:
+			record.LastModifiedDate, err = parseInventoryTime(value)
+		case InventoryFieldETag:
+			record.ETag = value
+		case InventoryFieldStorageClass:
+			record.StorageClass = value
+		case InventoryFieldIsMultipartUploaded:
+			record.IsMultipartUploaded, err = parseInventoryBool(value)
+		case InventoryFieldEncryptionStatus:
+			record.EncryptionStatus, err = parseInventoryBool(value)
+		default:
+			if record.Extra == nil {
+				record.Extra = map[string]string{}
+			}
+			record.Extra[field] = value
+		}
+		if err != nil {
+			return InventoryRecord{}, fmt.Errorf("oss: invalid inventory field %s: %s", field, value)
+		}
+	}
+	return record, nil
+}
+
+func parseInventoryBool(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+func parseInventoryTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02T15-04-05Z", value)
+}
+
+type inventoryCountingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+func (r *inventoryCountingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,159 @@
+package oss
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type OssInventoryReaderSuite struct{}
+
+var _ = Suite(&OssInventoryReaderSuite{})
+
+func gzipInventoryFile(c *C, lines ...string) []byte {
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	_, err := writer.Write([]byte(strings.Join(lines, "\n") + "\n"))
+	c.Assert(err, IsNil)
+	c.Assert(writer.Close(), IsNil)
+	return buffer.Bytes()
+}
+
+// newInventoryServer serves the inventory report1 of the bucket src, the reports are in the bucket dest with the prefix inv
+func newInventoryServer(c *C, files map[string][]byte, manifest InventoryManifest) *httptest.Server {
+	manifestData, err := json.Marshal(manifest)
+	c.Assert(err, IsNil)
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch {
+		case r.URL.Path == "/src/" || r.URL.Path == "/src":
+			c.Assert(query.Get("inventoryId"), Equals, "report1")
+			w.Write([]byte("<InventoryConfiguration><Id>report1</Id><Destination><OSSBucketDestination><Format>CSV</Format>" +
+				"<Bucket>acs:oss:::dest</Bucket><Prefix>inv</Prefix></OSSBucketDestination></Destination></InventoryConfiguration>"))
+		case r.URL.Path == "/dest/" || r.URL.Path == "/dest":
+			c.Assert(query.Get("prefix"), Equals, "inv/src/report1/")
+			c.Assert(query.Get("delimiter"), Equals, "/")
+			w.Write([]byte("<ListBucketResult><IsTruncated>false</IsTruncated>" +
+				"<CommonPrefixes><Prefix>inv/src/report1/2024-01-01T00-00Z/</Prefix></CommonPrefixes>" +
+				"<CommonPrefixes><Prefix>inv/src/report1/2024-01-02T00-00Z/</Prefix></CommonPrefixes>" +
+				"<CommonPrefixes><Prefix>inv/src/report1/data/</Prefix></CommonPrefixes></ListBucketResult>"))
+		case r.URL.Path == "/dest/inv/src/report1/2024-01-01T00-00Z/manifest.json":
+			w.Write(manifestData)
+		default:
+			data, ok := files[strings.TrimPrefix(r.URL.Path, "/dest/")]
+			if !ok {
+				// The report of 2024-01-02 is being written
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte("<Error><Code>NoSuchKey</Code></Error>"))
+				return
+			}
+			w.Write(data)
+		}
+	}))
+}
+
+func inventoryManifestFor(files map[string][]byte, keys ...string) InventoryManifest {
+	manifest := InventoryManifest{
+		DestinationBucket: "dest",
+		FileFormat:        "CSV",
+		FileSchema:        "Bucket, Key, Size, LastModifiedDate, ETag, StorageClass, IsMultipartUploaded, EncryptionStatus, ObjectAcl",
+		SourceBucket:      "src",
+		Version:           "2019-09-01",
+	}
+	for _, key := range keys {
+		sum := md5.Sum(files[key])
+		manifest.Files = append(manifest.Files, InventoryFile{Key: key, Size: int64(len(files[key])), MD5checksum: strings.ToUpper(hex.EncodeToString(sum[:]))})
+	}
+	return manifest
+}
+
+func (s *OssInventoryReaderSuite) TestInventoryReader(c *C) {
+	files := map[string][]byte{
+		"inv/src/report1/data/a.csv.gz": gzipInventoryFile(c,
+			`"src","a%2Fb%20c+d.txt","10","2024-01-01T01:02:03Z","""ETAG1""","Standard","false","true","default"`,
+			`"src","d.txt","20","2024-01-01T01:02:04Z","ETAG2","IA","true","false","private"`),
+		"inv/src/report1/data/b.csv.gz": gzipInventoryFile(c,
+			`"src","e.txt","0","2024-01-01T01:02:05Z","ETAG3","Archive","false","false","default"`),
+	}
+	manifest := inventoryManifestFor(files, "inv/src/report1/data/a.csv.gz", "inv/src/report1/data/b.csv.gz")
+	server := newInventoryServer(c, files, manifest)
+	defer server.Close()
+	client, err := New(server.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+
+	reader, err := client.OpenInventoryReader("src", "report1")
+	c.Assert(err, IsNil)
+	defer reader.Close()
+	c.Assert(reader.Manifest().Key, Equals, "inv/src/report1/2024-01-01T00-00Z/manifest.json")
+
+	var records []InventoryRecord
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		c.Assert(err, IsNil)
+		records = append(records, record)
+	}
+	c.Assert(len(records), Equals, 3)
+	c.Assert(records[0].Bucket, Equals, "src")
+	c.Assert(records[0].Key, Equals, "a/b c+d.txt")
+	c.Assert(records[0].Size, Equals, int64(10))
+	c.Assert(records[0].LastModifiedDate.Equal(time.Date(2024, 1, 1, 1, 2, 3, 0, time.UTC)), Equals, true)
+	c.Assert(records[0].ETag, Equals, `"ETAG1"`)
+	c.Assert(records[0].EncryptionStatus, Equals, true)
+	c.Assert(records[0].Extra, DeepEquals, map[string]string{"ObjectAcl": "default"})
+	c.Assert(records[1].StorageClass, Equals, "IA")
+	c.Assert(records[1].IsMultipartUploaded, Equals, true)
+	c.Assert(records[2].Key, Equals, "e.txt")
+	c.Assert(records[2].File, Equals, 1)
+
+	_, err = reader.Next()
+	c.Assert(err, Equals, io.EOF)
+}
+
+func (s *OssInventoryReaderSuite) TestInventoryReaderErrors(c *C) {
+	files := map[string][]byte{
+		"data/a.csv.gz": gzipInventoryFile(c, `"src","a.txt","10","2024-01-01T01:02:03Z","E","Standard","false","false","default"`),
+		"data/b.csv.gz": gzipInventoryFile(c, `"src","b.txt","10"`),
+	}
+	server := newInventoryServer(c, files, InventoryManifest{})
+	defer server.Close()
+	client, err := New(server.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("dest")
+	c.Assert(err, IsNil)
+
+	// The MD5 is verified at the end of the file
+	manifest := inventoryManifestFor(files, "data/a.csv.gz")
+	manifest.Files[0].MD5checksum = "00000000000000000000000000000000"
+	reader := NewInventoryReader(bucket, manifest)
+	_, err = reader.Next()
+	c.Assert(err, IsNil)
+	_, err = reader.Next()
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "MD5 mismatch"), Equals, true)
+
+	// The record doesn't match the schema
+	reader = NewInventoryReader(bucket, inventoryManifestFor(files, "data/b.csv.gz"))
+	_, err = reader.Next()
+	c.Assert(err, NotNil)
+	c.Assert(reader.Close(), IsNil)
+
+	// The data file is missing
+	reader = NewInventoryReader(bucket, inventoryManifestFor(files, "data/c.csv.gz"))
+	_, err = reader.Next()
+	c.Assert(err.(ServiceError).Code, Equals, "NoSuchKey")
+
+	_, err = LatestInventoryManifest(bucket, "inv/", "src", "report1")
+	c.Assert(err, IsNil)
+}
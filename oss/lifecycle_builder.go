@@ -0,0 +1,650 @@
+package oss
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lifecycleStorageRanks is the order of the storage classes, objects only transition to a colder class
+var lifecycleStorageRanks = map[StorageClassType]int{
+	StorageIA:              1,
+	StorageArchive:         2,
+	StorageColdArchive:     3,
+	StorageDeepColdArchive: 4,
+}
+
+// maxLifecycleRules is the max count of rules in a lifecycle configuration
+const maxLifecycleRules = 1000
+
+// LifecycleRuleBuilder builds a LifecycleRule.
+// The parameters are validated when they are added and the first error is returned by Build.
+//
+//	rule, err := NewLifecycleRule("logs", "logs/").
+//		TransitionAfterDays(30, StorageIA).
+//		TransitionAfterDays(180, StorageArchive).
+//		ExpireAfterDays(365).
+//		Build()
+type LifecycleRuleBuilder struct {
+	rule LifecycleRule
+	err  error
+}
+
+// NewLifecycleRule creates an enabled rule for the objects with the prefix, empty prefix means the whole bucket
+func NewLifecycleRule(id, prefix string) *LifecycleRuleBuilder {
+	return &LifecycleRuleBuilder{rule: LifecycleRule{ID: id, Prefix: prefix, Status: "Enabled"}}
+}
+
+// Disabled disables the rule
+func (b *LifecycleRuleBuilder) Disabled() *LifecycleRuleBuilder {
+	b.rule.Status = "Disabled"
+	return b
+}
+
+// Tag limits the rule to the objects with the tag, all the tags must match
+func (b *LifecycleRuleBuilder) Tag(key, value string) *LifecycleRuleBuilder {
+	if key == "" {
+		b.setErr(fmt.Errorf("oss: lifecycle tag key is empty"))
+	}
+	b.rule.Tags = append(b.rule.Tags, Tag{Key: key, Value: value})
+	return b
+}
+
+// ExcludePrefix excludes the objects with the prefix by LifecycleFilterNot, it must be longer than the prefix of the rule
+func (b *LifecycleRuleBuilder) ExcludePrefix(prefix string) *LifecycleRuleBuilder {
+	b.filter().Not = append(b.filter().Not, LifecycleFilterNot{Prefix: prefix})
+	return b
+}
+
+// ExcludeTag excludes the objects with the prefix and the tag by LifecycleFilterNot
+func (b *LifecycleRuleBuilder) ExcludeTag(prefix, key, value string) *LifecycleRuleBuilder {
+	if key == "" {
+		b.setErr(fmt.Errorf("oss: lifecycle tag key is empty"))
+	}
+	b.filter().Not = append(b.filter().Not, LifecycleFilterNot{Prefix: prefix, Tag: &Tag{Key: key, Value: value}})
+	return b
+}
+
+// ObjectSizeGreaterThan limits the rule to the objects larger than size bytes
+func (b *LifecycleRuleBuilder) ObjectSizeGreaterThan(size int64) *LifecycleRuleBuilder {
+	b.checkPositive("object size", size)
+	b.filter().ObjectSizeGreaterThan = &size
+	return b
+}
+
+// ObjectSizeLessThan limits the rule to the objects smaller than size bytes
+func (b *LifecycleRuleBuilder) ObjectSizeLessThan(size int64) *LifecycleRuleBuilder {
+	b.checkPositive("object size", size)
+	b.filter().ObjectSizeLessThan = &size
+	return b
+}
+
+// ExpireAfterDays deletes the objects the days after the last modified time
+func (b *LifecycleRuleBuilder) ExpireAfterDays(days int) *LifecycleRuleBuilder {
+	b.checkPositive("expiration days", int64(days))
+	b.expiration().Days = days
+	return b
+}
+
+// ExpireOnDate deletes the objects last modified before the date, only the date part of t is used
+func (b *LifecycleRuleBuilder) ExpireOnDate(t time.Time) *LifecycleRuleBuilder {
+	b.expiration().Date = lifecycleDate(t)
+	return b
+}
+
+// ExpireCreatedBefore deletes the objects created before the date, only the date part of t is used
+func (b *LifecycleRuleBuilder) ExpireCreatedBefore(t time.Time) *LifecycleRuleBuilder {
+	b.expiration().CreatedBeforeDate = lifecycleDate(t)
+	return b
+}
+
+// ExpireDeleteMarker removes the expired delete markers of the versioned objects
+func (b *LifecycleRuleBuilder) ExpireDeleteMarker() *LifecycleRuleBuilder {
+	enabled := true
+	b.expiration().ExpiredObjectDeleteMarker = &enabled
+	return b
+}
+
+// TransitionAfterDays transitions the objects to the storage class the days after the last modified time
+func (b *LifecycleRuleBuilder) TransitionAfterDays(days int, storageClass StorageClassType) *LifecycleRuleBuilder {
+	b.checkPositive("transition days", int64(days))
+	b.checkStorageClass(storageClass)
+	b.rule.Transitions = append(b.rule.Transitions, LifecycleTransition{Days: days, StorageClass: storageClass})
+	return b
+}
+
+// TransitionAfterAccessDays transitions the objects to the storage class the days after the last access time,
+// it requires the access monitor of the bucket.
+//
+// days    the days after the last access time.
+// storageClass    the target storage class.
+// returnToStdWhenVisit    whether the object returns to Standard when it's visited again.
+func (b *LifecycleRuleBuilder) TransitionAfterAccessDays(days int, storageClass StorageClassType, returnToStdWhenVisit bool) *LifecycleRuleBuilder {
+	b.checkPositive("transition days", int64(days))
+	b.checkStorageClass(storageClass)
+	isAccessTime := true
+	b.rule.Transitions = append(b.rule.Transitions, LifecycleTransition{Days: days, StorageClass: storageClass,
+		IsAccessTime: &isAccessTime, ReturnToStdWhenVisit: &returnToStdWhenVisit})
+	return b
+}
+
+// TransitionCreatedBefore transitions the objects created before the date to the storage class, only the date part of t is used
+func (b *LifecycleRuleBuilder) TransitionCreatedBefore(t time.Time, storageClass StorageClassType) *LifecycleRuleBuilder {
+	b.checkStorageClass(storageClass)
+	b.rule.Transitions = append(b.rule.Transitions, LifecycleTransition{CreatedBeforeDate: lifecycleDate(t), StorageClass: storageClass})
+	return b
+}
+
+// NoncurrentExpireAfterDays deletes the noncurrent versions the days after they become noncurrent
+func (b *LifecycleRuleBuilder) NoncurrentExpireAfterDays(days int) *LifecycleRuleBuilder {
+	b.checkPositive("noncurrent expiration days", int64(days))
+	b.rule.NonVersionExpiration = &LifecycleVersionExpiration{NoncurrentDays: days}
+	return b
+}
+
+// NoncurrentTransitionAfterDays transitions the noncurrent versions to the storage class the days after they become noncurrent
+func (b *LifecycleRuleBuilder) NoncurrentTransitionAfterDays(days int, storageClass StorageClassType) *LifecycleRuleBuilder {
+	b.checkPositive("noncurrent transition days", int64(days))
+	b.checkStorageClass(storageClass)
+	b.rule.NonVersionTransitions = append(b.rule.NonVersionTransitions,
+		LifecycleVersionTransition{NoncurrentDays: days, StorageClass: storageClass})
+	return b
+}
+
+// NoncurrentTransitionAfterAccessDays transitions the noncurrent versions to the storage class the days after the last access time,
+// it requires the access monitor of the bucket.
+func (b *LifecycleRuleBuilder) NoncurrentTransitionAfterAccessDays(days int, storageClass StorageClassType, returnToStdWhenVisit bool) *LifecycleRuleBuilder {
+	b.checkPositive("noncurrent transition days", int64(days))
+	b.checkStorageClass(storageClass)
+	isAccessTime := true
+	b.rule.NonVersionTransitions = append(b.rule.NonVersionTransitions, LifecycleVersionTransition{NoncurrentDays: days,
+		StorageClass: storageClass, IsAccessTime: &isAccessTime, ReturnToStdWhenVisit: &returnToStdWhenVisit})
+	return b
+}
+
+// AbortMultipartAfterDays aborts the multipart uploads the days after they are initiated
+func (b *LifecycleRuleBuilder) AbortMultipartAfterDays(days int) *LifecycleRuleBuilder {
+	b.checkPositive("abort multipart upload days", int64(days))
+	b.rule.AbortMultipartUpload = &LifecycleAbortMultipartUpload{Days: days}
+	return b
+}
+
+// AbortMultipartCreatedBefore aborts the multipart uploads initiated before the date, only the date part of t is used
+func (b *LifecycleRuleBuilder) AbortMultipartCreatedBefore(t time.Time) *LifecycleRuleBuilder {
+	b.rule.AbortMultipartUpload = &LifecycleAbortMultipartUpload{CreatedBeforeDate: lifecycleDate(t)}
+	return b
+}
+
+// Build returns the rule, the rule itself is checked by ValidateLifecycle
+//
+// LifecycleRule    the rule.
+// error    it's nil if no error, otherwise it's the first invalid parameter.
+func (b *LifecycleRuleBuilder) Build() (LifecycleRule, error) {
+	return b.rule, b.err
+}
+
+func (b *LifecycleRuleBuilder) expiration() *LifecycleExpiration {
+	if b.rule.Expiration == nil {
+		b.rule.Expiration = &LifecycleExpiration{}
+	}
+	return b.rule.Expiration
+}
+
+func (b *LifecycleRuleBuilder) filter() *LifecycleFilter {
+	if b.rule.Filter == nil {
+		b.rule.Filter = &LifecycleFilter{}
+	}
+	return b.rule.Filter
+}
+
+func (b *LifecycleRuleBuilder) checkPositive(name string, value int64) {
+	if value <= 0 {
+		b.setErr(fmt.Errorf("oss: invalid lifecycle %s %d, it must be positive", name, value))
+	}
+}
+
+func (b *LifecycleRuleBuilder) checkStorageClass(storageClass StorageClassType) {
+	if _, ok := lifecycleStorageRanks[storageClass]; !ok {
+		b.setErr(fmt.Errorf("oss: invalid lifecycle transition storage class %s", storageClass))
+	}
+}
+
+func (b *LifecycleRuleBuilder) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// lifecycleDate formats the date part of t as the lifecycle date, such as 2023-01-01T00:00:00.000Z
+func lifecycleDate(t time.Time) string {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).Format(iso8601DateFormat)
+}
+
+// LifecycleBuilder builds a LifecycleConfiguration and validates it by ValidateLifecycle.
+//
+//	config, err := NewLifecycleBuilder().
+//		AccessMonitor(true).
+//		AddRule(NewLifecycleRule("logs", "logs/").ExpireAfterDays(30)).
+//		AddRule(NewLifecycleRule("mpu", "").AbortMultipartAfterDays(7)).
+//		Build()
+//	...
+//	err = client.SetBucketLifecycle(bucketName, config.Rules)
+type LifecycleBuilder struct {
+	rules         []*LifecycleRuleBuilder
+	accessMonitor bool
+}
+
+// NewLifecycleBuilder creates an empty lifecycle builder
+func NewLifecycleBuilder() *LifecycleBuilder {
+	return &LifecycleBuilder{}
+}
+
+// AccessMonitor sets whether the access monitor of the bucket is enabled, which is required by the access time transitions
+func (b *LifecycleBuilder) AccessMonitor(enabled bool) *LifecycleBuilder {
+	b.accessMonitor = enabled
+	return b
+}
+
+// AddRule adds a rule
+func (b *LifecycleBuilder) AddRule(rule *LifecycleRuleBuilder) *LifecycleBuilder {
+	b.rules = append(b.rules, rule)
+	return b
+}
+
+// Validate checks the rules offline, see ValidateLifecycle
+func (b *LifecycleBuilder) Validate() error {
+	_, err := b.Build()
+	return err
+}
+
+// Build validates and returns the configuration
+//
+// LifecycleConfiguration    the configuration, the rules are used by SetBucketLifecycle.
+// error    it's nil if no error, otherwise it's the first invalid parameter or rule.
+func (b *LifecycleBuilder) Build() (LifecycleConfiguration, error) {
+	config := LifecycleConfiguration{}
+	for _, builder := range b.rules {
+		rule, err := builder.Build()
+		if err != nil {
+			return LifecycleConfiguration{}, err
+		}
+		config.Rules = append(config.Rules, rule)
+	}
+	if err := ValidateLifecycle(config, b.accessMonitor); err != nil {
+		return LifecycleConfiguration{}, err
+	}
+	return config, nil
+}
+
+// ValidateLifecycle checks the lifecycle configuration offline, the errors are otherwise returned by OSS as InvalidArgument.
+//
+// Every rule must be Enabled or Disabled and have an action. Every action must be set by either days or date.
+// The transitions must go to colder storage classes later, that is IA before Archive before ColdArchive before DeepColdArchive,
+// and the expiration must be later than the transitions. The access time transitions require the access monitor.
+// The rule IDs must be unique, and the enabled rules whose objects overlap must not set the same action differently.
+//
+// config    the lifecycle configuration.
+// accessMonitorEnabled    whether the access monitor of the bucket is enabled, see GetBucketAccessMonitor.
+//
+// error    it's nil if the configuration is valid, otherwise it describes the first problem.
+func ValidateLifecycle(config LifecycleConfiguration, accessMonitorEnabled bool) error {
+	if len(config.Rules) == 0 {
+		return fmt.Errorf("oss: lifecycle configuration has no rule")
+	}
+	if len(config.Rules) > maxLifecycleRules {
+		return fmt.Errorf("oss: lifecycle configuration has %d rules, at most %d", len(config.Rules), maxLifecycleRules)
+	}
+
+	ids := map[string]bool{}
+	for i, rule := range config.Rules {
+		if rule.ID != "" {
+			if ids[rule.ID] {
+				return fmt.Errorf("oss: duplicate lifecycle rule ID %s", rule.ID)
+			}
+			ids[rule.ID] = true
+		}
+		if err := validateLifecycleRule(rule, accessMonitorEnabled); err != nil {
+			return fmt.Errorf("oss: lifecycle rule %s: %s", lifecycleRuleName(rule, i), err.Error())
+		}
+	}
+
+	for i := range config.Rules {
+		for j := i + 1; j < len(config.Rules); j++ {
+			a, b := config.Rules[i], config.Rules[j]
+			if a.Status != "Enabled" || b.Status != "Enabled" || !lifecycleRulesOverlap(a, b) {
+				continue
+			}
+			if action := lifecycleConflict(a, b); action != "" {
+				return fmt.Errorf("oss: lifecycle rules %s and %s overlap with conflicting %s",
+					lifecycleRuleName(a, i), lifecycleRuleName(b, j), action)
+			}
+		}
+	}
+	return nil
+}
+
+func lifecycleRuleName(rule LifecycleRule, index int) string {
+	if rule.ID != "" {
+		return rule.ID
+	}
+	return "#" + strconv.Itoa(index)
+}
+
+// lifecycleTransition is the common part of the current and the noncurrent transitions
+type lifecycleTransition struct {
+	days              int
+	createdBeforeDate string
+	storageClass      StorageClassType
+	isAccessTime      bool
+	returnToStd       bool
+}
+
+func validateLifecycleRule(rule LifecycleRule, accessMonitorEnabled bool) error {
+	if rule.Status != "Enabled" && rule.Status != "Disabled" {
+		return fmt.Errorf("invalid status %s, it must be Enabled or Disabled", rule.Status)
+	}
+	if rule.NonVersionTransition != nil && len(rule.NonVersionTransitions) > 0 {
+		return fmt.Errorf("NonVersionTransition and NonVersionTransitions cannot both have values")
+	}
+	rule = normalizeLifecycleRule(rule)
+	if rule.Expiration == nil && len(rule.Transitions) == 0 && rule.AbortMultipartUpload == nil &&
+		rule.NonVersionExpiration == nil && len(rule.NonVersionTransitions) == 0 {
+		return fmt.Errorf("no action")
+	}
+
+	tagKeys := map[string]bool{}
+	for _, tag := range rule.Tags {
+		if tagKeys[tag.Key] {
+			return fmt.Errorf("duplicate tag key %s", tag.Key)
+		}
+		tagKeys[tag.Key] = true
+	}
+	if err := validateLifecycleFilter(rule); err != nil {
+		return err
+	}
+
+	if exp := rule.Expiration; exp != nil {
+		set := 0
+		for _, ok := range []bool{exp.Days != 0, exp.Date != "", exp.CreatedBeforeDate != "", exp.ExpiredObjectDeleteMarker != nil} {
+			if ok {
+				set++
+			}
+		}
+		if set != 1 {
+			return fmt.Errorf("expiration must set one of Days, Date, CreatedBeforeDate and ExpiredObjectDeleteMarker")
+		}
+		if exp.Days < 0 {
+			return fmt.Errorf("invalid expiration days %d", exp.Days)
+		}
+		for _, date := range []string{exp.Date, exp.CreatedBeforeDate} {
+			if err := validateLifecycleDate(date); err != nil {
+				return err
+			}
+		}
+	}
+
+	if abort := rule.AbortMultipartUpload; abort != nil {
+		if (abort.Days != 0) == (abort.CreatedBeforeDate != "") {
+			return fmt.Errorf("abort multipart upload must set one of CreatedBeforeDate and Days")
+		}
+		if err := validateLifecycleDate(abort.CreatedBeforeDate); err != nil {
+			return err
+		}
+	}
+
+	transitions := make([]lifecycleTransition, 0, len(rule.Transitions))
+	for _, t := range rule.Transitions {
+		transitions = append(transitions, lifecycleTransition{days: t.Days, createdBeforeDate: t.CreatedBeforeDate,
+			storageClass: t.StorageClass, isAccessTime: isTrue(t.IsAccessTime), returnToStd: isTrue(t.ReturnToStdWhenVisit)})
+	}
+	expirationDays, expirationDate := 0, ""
+	if rule.Expiration != nil {
+		expirationDays, expirationDate = rule.Expiration.Days, rule.Expiration.CreatedBeforeDate
+	}
+	if err := validateLifecycleTransitions("transition", transitions, expirationDays, expirationDate, accessMonitorEnabled); err != nil {
+		return err
+	}
+
+	transitions = transitions[:0]
+	for _, t := range rule.NonVersionTransitions {
+		transitions = append(transitions, lifecycleTransition{days: t.NoncurrentDays, storageClass: t.StorageClass,
+			isAccessTime: isTrue(t.IsAccessTime), returnToStd: isTrue(t.ReturnToStdWhenVisit)})
+	}
+	expirationDays = 0
+	if rule.NonVersionExpiration != nil {
+		if rule.NonVersionExpiration.NoncurrentDays <= 0 {
+			return fmt.Errorf("noncurrent version expiration must set NoncurrentDays")
+		}
+		expirationDays = rule.NonVersionExpiration.NoncurrentDays
+	}
+	return validateLifecycleTransitions("noncurrent version transition", transitions, expirationDays, "", accessMonitorEnabled)
+}
+
+func validateLifecycleFilter(rule LifecycleRule) error {
+	filter := rule.Filter
+	if filter == nil {
+		return nil
+	}
+	for _, not := range filter.Not {
+		if !strings.HasPrefix(not.Prefix, rule.Prefix) || (not.Prefix == rule.Prefix && not.Tag == nil) {
+			return fmt.Errorf("the prefix %s of Not must be longer than and start with the prefix %s of the rule", not.Prefix, rule.Prefix)
+		}
+	}
+	if filter.ObjectSizeGreaterThan != nil && *filter.ObjectSizeGreaterThan < 0 {
+		return fmt.Errorf("invalid ObjectSizeGreaterThan %d", *filter.ObjectSizeGreaterThan)
+	}
+	if filter.ObjectSizeLessThan != nil && *filter.ObjectSizeLessThan <= 0 {
+		return fmt.Errorf("invalid ObjectSizeLessThan %d", *filter.ObjectSizeLessThan)
+	}
+	if filter.ObjectSizeGreaterThan != nil && filter.ObjectSizeLessThan != nil && *filter.ObjectSizeGreaterThan >= *filter.ObjectSizeLessThan {
+		return fmt.Errorf("ObjectSizeGreaterThan %d must be less than ObjectSizeLessThan %d",
+			*filter.ObjectSizeGreaterThan, *filter.ObjectSizeLessThan)
+	}
+	return nil
+}
+
+// validateLifecycleTransitions checks the transitions are set by days or date, and the colder classes are later
+func validateLifecycleTransitions(name string, transitions []lifecycleTransition, expirationDays int, expirationDate string, accessMonitorEnabled bool) error {
+	classes := map[StorageClassType]bool{}
+	for _, t := range transitions {
+		if _, ok := lifecycleStorageRanks[t.storageClass]; !ok {
+			return fmt.Errorf("invalid %s storage class %s", name, t.storageClass)
+		}
+		if classes[t.storageClass] {
+			return fmt.Errorf("duplicate %s to %s", name, t.storageClass)
+		}
+		classes[t.storageClass] = true
+		if (t.days != 0) == (t.createdBeforeDate != "") {
+			return fmt.Errorf("%s must set one of CreatedBeforeDate and Days", name)
+		}
+		if t.days < 0 {
+			return fmt.Errorf("invalid %s days %d", name, t.days)
+		}
+		if err := validateLifecycleDate(t.createdBeforeDate); err != nil {
+			return err
+		}
+		if t.isAccessTime {
+			if !accessMonitorEnabled {
+				return fmt.Errorf("%s by access time requires the access monitor of the bucket", name)
+			}
+			if t.storageClass != StorageIA {
+				return fmt.Errorf("%s by access time only supports IA, not %s", name, t.storageClass)
+			}
+		} else if t.returnToStd {
+			return fmt.Errorf("ReturnToStdWhenVisit of %s requires IsAccessTime", name)
+		}
+	}
+
+	// Sort by the storage class, the transitions are few
+	sorted := append([]lifecycleTransition{}, transitions...)
+	for i := 1; i < len(sorted); i++ {
+		for j := i; j > 0 && lifecycleStorageRanks[sorted[j].storageClass] < lifecycleStorageRanks[sorted[j-1].storageClass]; j-- {
+			sorted[j], sorted[j-1] = sorted[j-1], sorted[j]
+		}
+	}
+	for i := 1; i < len(sorted); i++ {
+		prev, cur := sorted[i-1], sorted[i]
+		if (prev.days != 0) != (cur.days != 0) {
+			return fmt.Errorf("%ss must all be set by Days or all by CreatedBeforeDate", name)
+		}
+		if prev.days != 0 && prev.days >= cur.days {
+			return fmt.Errorf("%s to %s after %d days must be earlier than the one to %s after %d days",
+				name, prev.storageClass, prev.days, cur.storageClass, cur.days)
+		}
+		if prev.createdBeforeDate != "" && prev.createdBeforeDate <= cur.createdBeforeDate {
+			return fmt.Errorf("%s to %s created before %s must cover more objects than the one to %s created before %s",
+				name, prev.storageClass, prev.createdBeforeDate, cur.storageClass, cur.createdBeforeDate)
+		}
+	}
+
+	for _, t := range transitions {
+		if expirationDays != 0 && t.days != 0 && t.days >= expirationDays {
+			return fmt.Errorf("%s to %s after %d days must be earlier than the expiration after %d days",
+				name, t.storageClass, t.days, expirationDays)
+		}
+		if expirationDate != "" && t.createdBeforeDate != "" && t.createdBeforeDate <= expirationDate {
+			return fmt.Errorf("%s to %s created before %s must cover more objects than the expiration created before %s",
+				name, t.storageClass, t.createdBeforeDate, expirationDate)
+		}
+	}
+	return nil
+}
+
+func validateLifecycleDate(date string) error {
+	if date == "" {
+		return nil
+	}
+	t, err := time.Parse(iso8601DateFormat, date)
+	if err != nil || !t.Equal(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)) {
+		return fmt.Errorf("invalid date %s, it must be midnight in UTC, such as 2023-01-01T00:00:00.000Z", date)
+	}
+	return nil
+}
+
+// lifecycleRulesOverlap checks if an object could match both rules.
+// The rules are disjoint if the prefixes diverge or they require different values of a tag.
+func lifecycleRulesOverlap(a, b LifecycleRule) bool {
+	if !strings.HasPrefix(a.Prefix, b.Prefix) && !strings.HasPrefix(b.Prefix, a.Prefix) {
+		return false
+	}
+	for _, ta := range a.Tags {
+		for _, tb := range b.Tags {
+			if ta.Key == tb.Key && ta.Value != tb.Value {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// lifecycleConflict returns the action both rules set differently, or empty if there is none
+func lifecycleConflict(a, b LifecycleRule) string {
+	a, b = normalizeLifecycleRule(a), normalizeLifecycleRule(b)
+	if a.Expiration != nil && b.Expiration != nil && !xmlEqual(a.Expiration, b.Expiration) {
+		return "Expiration"
+	}
+	if a.AbortMultipartUpload != nil && b.AbortMultipartUpload != nil && !xmlEqual(a.AbortMultipartUpload, b.AbortMultipartUpload) {
+		return "AbortMultipartUpload"
+	}
+	if a.NonVersionExpiration != nil && b.NonVersionExpiration != nil && !xmlEqual(a.NonVersionExpiration, b.NonVersionExpiration) {
+		return "NoncurrentVersionExpiration"
+	}
+	for _, ta := range a.Transitions {
+		for _, tb := range b.Transitions {
+			if ta.StorageClass == tb.StorageClass && !xmlEqual(ta, tb) {
+				return "Transition to " + string(ta.StorageClass)
+			}
+		}
+	}
+	for _, ta := range a.NonVersionTransitions {
+		for _, tb := range b.NonVersionTransitions {
+			if ta.StorageClass == tb.StorageClass && !xmlEqual(ta, tb) {
+				return "NoncurrentVersionTransition to " + string(ta.StorageClass)
+			}
+		}
+	}
+	return ""
+}
+
+// normalizeLifecycleRule moves the deprecated NonVersionTransition into NonVersionTransitions
+func normalizeLifecycleRule(rule LifecycleRule) LifecycleRule {
+	if rule.NonVersionTransition != nil && len(rule.NonVersionTransitions) == 0 {
+		rule.NonVersionTransitions = []LifecycleVersionTransition{*rule.NonVersionTransition}
+	}
+	rule.NonVersionTransition = nil
+	return rule
+}
+
+// xmlEqual compares the configurations by their XML
+func xmlEqual(a, b interface{}) bool {
+	xa, erra := xml.Marshal(a)
+	xb, errb := xml.Marshal(b)
+	return erra == nil && errb == nil && string(xa) == string(xb)
+}
+
+func isTrue(b *bool) bool {
+	return b != nil && *b
+}
+
+// LifecycleDiff is the difference between two lifecycle configurations, the rules are matched by ID.
+// The rules without ID are matched by the whole content, so they're never Changed.
+type LifecycleDiff struct {
+	Added   []LifecycleRule       // The rules only in the new configuration
+	Removed []LifecycleRule       // The rules only in the old configuration
+	Changed []LifecycleRuleChange // The rules with the same ID and different content
+}
+
+// LifecycleRuleChange is a rule changed between two lifecycle configurations
+type LifecycleRuleChange struct {
+	ID  string
+	Old LifecycleRule
+	New LifecycleRule
+}
+
+// IsEmpty checks if the configurations are the same
+func (diff LifecycleDiff) IsEmpty() bool {
+	return len(diff.Added) == 0 && len(diff.Removed) == 0 && len(diff.Changed) == 0
+}
+
+// DiffLifecycle compares two lifecycle configurations, such as the result of GetBucketLifecycle and a built one.
+// The deprecated NonVersionTransition is compared as NonVersionTransitions.
+//
+// oldConfig    the old configuration.
+// newConfig    the new configuration.
+//
+// LifecycleDiff    the added, removed and changed rules in the order of the configurations.
+func DiffLifecycle(oldConfig, newConfig LifecycleConfiguration) LifecycleDiff {
+	key := func(rule LifecycleRule) string {
+		if rule.ID != "" {
+			return "id:" + rule.ID
+		}
+		data, _ := xml.Marshal(normalizeLifecycleRule(rule))
+		return "xml:" + string(data)
+	}
+
+	oldRules := map[string]LifecycleRule{}
+	for _, rule := range oldConfig.Rules {
+		oldRules[key(rule)] = rule
+	}
+	newKeys := map[string]bool{}
+
+	diff := LifecycleDiff{}
+	for _, rule := range newConfig.Rules {
+		k := key(rule)
+		newKeys[k] = true
+		old, ok := oldRules[k]
+		if !ok {
+			diff.Added = append(diff.Added, rule)
+		} else if !xmlEqual(normalizeLifecycleRule(old), normalizeLifecycleRule(rule)) {
+			diff.Changed = append(diff.Changed, LifecycleRuleChange{ID: rule.ID, Old: old, New: rule})
+		}
+	}
+	for _, rule := range oldConfig.Rules {
+		if !newKeys[key(rule)] {
+			diff.Removed = append(diff.Removed, rule)
+		}
+	}
+	return diff
+}
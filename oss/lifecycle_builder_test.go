@@ -0,0 +1,164 @@
+package oss
+
+import (
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type OssLifecycleBuilderSuite struct{}
+
+var _ = Suite(&OssLifecycleBuilderSuite{})
+
+func (s *OssLifecycleBuilderSuite) TestBuildLifecycle(c *C) {
+	date := time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC)
+	config, err := NewLifecycleBuilder().
+		AccessMonitor(true).
+		AddRule(NewLifecycleRule("logs", "logs/").
+			TransitionAfterAccessDays(30, StorageIA, true).
+			TransitionAfterDays(180, StorageArchive).
+			ExpireAfterDays(365).
+			ExcludePrefix("logs/keep/").
+			ObjectSizeGreaterThan(1024)).
+		AddRule(NewLifecycleRule("versions", "data/").
+			Tag("env", "test").
+			NoncurrentTransitionAfterDays(10, StorageIA).
+			NoncurrentExpireAfterDays(20).
+			ExpireDeleteMarker()).
+		AddRule(NewLifecycleRule("mpu", "").AbortMultipartCreatedBefore(date).Disabled()).
+		Build()
+	c.Assert(err, IsNil)
+	c.Assert(len(config.Rules), Equals, 3)
+
+	rule := config.Rules[0]
+	c.Assert(rule.Status, Equals, "Enabled")
+	c.Assert(len(rule.Transitions), Equals, 2)
+	c.Assert(*rule.Transitions[0].IsAccessTime, Equals, true)
+	c.Assert(*rule.Transitions[0].ReturnToStdWhenVisit, Equals, true)
+	c.Assert(rule.Expiration.Days, Equals, 365)
+	c.Assert(rule.Filter.Not[0].Prefix, Equals, "logs/keep/")
+	c.Assert(*rule.Filter.ObjectSizeGreaterThan, Equals, int64(1024))
+	c.Assert(config.Rules[1].Tags, DeepEquals, []Tag{{Key: "env", Value: "test"}})
+	c.Assert(config.Rules[1].NonVersionExpiration.NoncurrentDays, Equals, 20)
+	c.Assert(config.Rules[2].Status, Equals, "Disabled")
+	c.Assert(config.Rules[2].AbortMultipartUpload.CreatedBeforeDate, Equals, "2023-01-02T00:00:00.000Z")
+
+	// The parameter errors are returned by Build
+	_, err = NewLifecycleRule("a", "").ExpireAfterDays(0).Build()
+	c.Assert(err, NotNil)
+	_, err = NewLifecycleRule("a", "").TransitionAfterDays(10, StorageStandard).Build()
+	c.Assert(err, NotNil)
+	err = NewLifecycleBuilder().AddRule(NewLifecycleRule("a", "").ObjectSizeLessThan(-1)).Validate()
+	c.Assert(err, NotNil)
+}
+
+func (s *OssLifecycleBuilderSuite) TestValidateLifecycle(c *C) {
+	invalid := map[string]*LifecycleBuilder{
+		"no rule":   NewLifecycleBuilder(),
+		"no action": NewLifecycleBuilder().AddRule(NewLifecycleRule("a", "")),
+		"duplicate lifecycle rule ID": NewLifecycleBuilder().
+			AddRule(NewLifecycleRule("a", "x/").ExpireAfterDays(1)).
+			AddRule(NewLifecycleRule("a", "y/").ExpireAfterDays(1)),
+		"to Archive after 30 days must be earlier than the one to ColdArchive": NewLifecycleBuilder().
+			AddRule(NewLifecycleRule("a", "").TransitionAfterDays(30, StorageColdArchive).TransitionAfterDays(30, StorageArchive)),
+		"to IA after 60 days must be earlier than the one to Archive": NewLifecycleBuilder().
+			AddRule(NewLifecycleRule("a", "").TransitionAfterDays(30, StorageArchive).TransitionAfterDays(60, StorageIA)),
+		"must be earlier than the expiration": NewLifecycleBuilder().
+			AddRule(NewLifecycleRule("a", "").TransitionAfterDays(30, StorageIA).ExpireAfterDays(30)),
+		"requires the access monitor": NewLifecycleBuilder().
+			AddRule(NewLifecycleRule("a", "").TransitionAfterAccessDays(30, StorageIA, false)),
+		"by access time only supports IA": NewLifecycleBuilder().AccessMonitor(true).
+			AddRule(NewLifecycleRule("a", "").TransitionAfterAccessDays(30, StorageArchive, false)),
+		"noncurrent version transition to IA after 20 days": NewLifecycleBuilder().
+			AddRule(NewLifecycleRule("a", "").NoncurrentTransitionAfterDays(20, StorageIA).NoncurrentExpireAfterDays(10)),
+		"must set one of Days, Date": NewLifecycleBuilder().
+			AddRule(NewLifecycleRule("a", "").ExpireAfterDays(10).ExpireDeleteMarker()),
+		"must be longer than and start with": NewLifecycleBuilder().
+			AddRule(NewLifecycleRule("a", "logs/").ExcludePrefix("data/").ExpireAfterDays(1)),
+		"must be less than ObjectSizeLessThan": NewLifecycleBuilder().
+			AddRule(NewLifecycleRule("a", "").ObjectSizeGreaterThan(100).ObjectSizeLessThan(10).ExpireAfterDays(1)),
+		"overlap with conflicting Expiration": NewLifecycleBuilder().
+			AddRule(NewLifecycleRule("a", "logs/").ExpireAfterDays(30)).
+			AddRule(NewLifecycleRule("b", "logs/app/").ExpireAfterDays(60)),
+		"overlap with conflicting Transition to IA": NewLifecycleBuilder().
+			AddRule(NewLifecycleRule("a", "").TransitionAfterDays(30, StorageIA)).
+			AddRule(NewLifecycleRule("b", "x").Tag("k", "v").TransitionAfterDays(60, StorageIA)),
+	}
+	for message, builder := range invalid {
+		err := builder.Validate()
+		c.Assert(err, NotNil, Commentf(message))
+		c.Assert(strings.Contains(err.Error(), message), Equals, true, Commentf(err.Error()))
+	}
+
+	valid := []*LifecycleBuilder{
+		// The prefixes diverge
+		NewLifecycleBuilder().
+			AddRule(NewLifecycleRule("a", "logs/").ExpireAfterDays(30)).
+			AddRule(NewLifecycleRule("b", "data/").ExpireAfterDays(60)),
+		// The tags are disjoint
+		NewLifecycleBuilder().
+			AddRule(NewLifecycleRule("a", "").Tag("k", "1").ExpireAfterDays(30)).
+			AddRule(NewLifecycleRule("b", "").Tag("k", "2").ExpireAfterDays(60)),
+		// The disabled rules don't conflict
+		NewLifecycleBuilder().
+			AddRule(NewLifecycleRule("a", "").ExpireAfterDays(30)).
+			AddRule(NewLifecycleRule("b", "").ExpireAfterDays(60).Disabled()),
+		// The colder class is created before an earlier date
+		NewLifecycleBuilder().
+			AddRule(NewLifecycleRule("a", "").
+				TransitionCreatedBefore(time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC), StorageIA).
+				TransitionCreatedBefore(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), StorageArchive).
+				ExpireCreatedBefore(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))),
+	}
+	for i, builder := range valid {
+		c.Assert(builder.Validate(), IsNil, Commentf("valid %d", i))
+	}
+
+	// The rules from GetBucketLifecycle are validated directly, the deprecated field is supported
+	config := LifecycleConfiguration{Rules: []LifecycleRule{{
+		Prefix: "", Status: "Enabled",
+		NonVersionTransition: &LifecycleVersionTransition{NoncurrentDays: 10, StorageClass: StorageIA},
+	}}}
+	c.Assert(ValidateLifecycle(config, false), IsNil)
+	config.Rules[0].Status = "enabled"
+	err := ValidateLifecycle(config, false)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "#0"), Equals, true)
+}
+
+func (s *OssLifecycleBuilderSuite) TestDiffLifecycle(c *C) {
+	oldConfig, err := NewLifecycleBuilder().
+		AddRule(NewLifecycleRule("same", "a/").ExpireAfterDays(10)).
+		AddRule(NewLifecycleRule("changed", "b/").ExpireAfterDays(10)).
+		AddRule(NewLifecycleRule("removed", "c/").ExpireAfterDays(10)).
+		AddRule(NewLifecycleRule("", "d/").ExpireAfterDays(10)).
+		Build()
+	c.Assert(err, IsNil)
+	newConfig, err := NewLifecycleBuilder().
+		AddRule(NewLifecycleRule("changed", "b/").ExpireAfterDays(20)).
+		AddRule(NewLifecycleRule("same", "a/").ExpireAfterDays(10)).
+		AddRule(NewLifecycleRule("added", "e/").AbortMultipartAfterDays(1)).
+		AddRule(NewLifecycleRule("", "d/").ExpireAfterDays(10)).
+		Build()
+	c.Assert(err, IsNil)
+
+	diff := DiffLifecycle(oldConfig, newConfig)
+	c.Assert(diff.IsEmpty(), Equals, false)
+	c.Assert(len(diff.Added), Equals, 1)
+	c.Assert(diff.Added[0].ID, Equals, "added")
+	c.Assert(len(diff.Removed), Equals, 1)
+	c.Assert(diff.Removed[0].ID, Equals, "removed")
+	c.Assert(len(diff.Changed), Equals, 1)
+	c.Assert(diff.Changed[0].ID, Equals, "changed")
+	c.Assert(diff.Changed[0].Old.Expiration.Days, Equals, 10)
+	c.Assert(diff.Changed[0].New.Expiration.Days, Equals, 20)
+
+	c.Assert(DiffLifecycle(newConfig, newConfig).IsEmpty(), Equals, true)
+
+	// The deprecated field is the same as NonVersionTransitions
+	transition := LifecycleVersionTransition{NoncurrentDays: 10, StorageClass: StorageIA}
+	a := LifecycleConfiguration{Rules: []LifecycleRule{{ID: "r", Status: "Enabled", NonVersionTransition: &transition}}}
+	b := LifecycleConfiguration{Rules: []LifecycleRule{{ID: "r", Status: "Enabled", NonVersionTransitions: []LifecycleVersionTransition{transition}}}}
+	c.Assert(DiffLifecycle(a, b).IsEmpty(), Equals, true)
+}
@@ -6,6 +6,7 @@
 package oss
 
 import (
+	"context"
 	"fmt"
 	"io"
 )
@@ -21,6 +22,23 @@ type LimitSpeedReader struct {
 	io.ReadCloser
 	reader     io.Reader
 	ossLimiter *OssLimiter
+	limiter    BandwidthLimiter
+	ctx        context.Context
+}
+
+func NewBandwidthLimiter(speed int) *OssLimiter {
+	return &OssLimiter{}
+}
+
+func (l *OssLimiter) SetLimit(speed int) {
+}
+
+func (l *OssLimiter) Limit() int {
+	return 0
+}
+
+func (l *OssLimiter) WaitN(ctx context.Context, n int) error {
+	return nil
 }
 
 func GetOssLimiter(uploadSpeed int) (ossLimiter *OssLimiter, err error) {
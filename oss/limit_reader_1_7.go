@@ -4,6 +4,7 @@
 package oss
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"math"
@@ -14,6 +15,7 @@ import (
 
 const (
 	perTokenBandwidthSize int = 1024
+	limitReadSize         int = 16 * perTokenBandwidthSize // The max bytes read before waiting for the bandwidth
 )
 
 // OssLimiter wrapper rate.Limiter
@@ -34,49 +36,95 @@ func GetOssLimiter(uploadSpeed int) (ossLimiter *OssLimiter, err error) {
 	}, nil
 }
 
+// NewBandwidthLimiter creates the token bucket limiter, it could be shared by the clients and the transfers.
+//
+// speed    the bandwidth in KB/s, 0 is unlimited.
+//
+// *OssLimiter    the limiter.
+func NewBandwidthLimiter(speed int) *OssLimiter {
+	if speed <= 0 {
+		return &OssLimiter{limiter: rate.NewLimiter(rate.Inf, 0)}
+	}
+	ossLimiter, _ := GetOssLimiter(speed)
+	return ossLimiter
+}
+
+// SetLimit changes the bandwidth, it's safe while the transfers run, such as by the business hours schedule.
+//
+// speed    the bandwidth in KB/s, 0 is unlimited.
+func (l *OssLimiter) SetLimit(speed int) {
+	if speed <= 0 {
+		l.limiter.SetLimit(rate.Inf)
+		return
+	}
+	l.limiter.SetBurst(speed)
+	l.limiter.SetLimit(rate.Limit(speed))
+}
+
+// Limit returns the bandwidth in KB/s, 0 is unlimited
+func (l *OssLimiter) Limit() int {
+	if l.limiter.Limit() == rate.Inf {
+		return 0
+	}
+	return int(l.limiter.Limit())
+}
+
+// WaitN implements BandwidthLimiter, the bytes are waited in the tokens of 1KB
+func (l *OssLimiter) WaitN(ctx context.Context, n int) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	tokens := int(math.Ceil(float64(n) / float64(perTokenBandwidthSize)))
+	for tokens > 0 {
+		if l.limiter.Limit() == rate.Inf {
+			return nil
+		}
+		// The tokens more than the burst are never allowed at once
+		tc := l.limiter.Burst()
+		if tc <= 0 || tc > tokens {
+			tc = tokens
+		}
+		if err := l.limiter.WaitN(ctx, tc); err != nil {
+			return fmt.Errorf("LimitSpeedReader.Read() failure, %s", err.Error())
+		}
+		tokens -= tc
+	}
+	return nil
+}
+
 // LimitSpeedReader for limit bandwidth upload
 type LimitSpeedReader struct {
 	io.ReadCloser
 	reader     io.Reader
 	ossLimiter *OssLimiter
+	limiter    BandwidthLimiter // It overrides the ossLimiter if it's not nil
+	ctx        context.Context
 }
 
-// Read
+// Read reads no more than the burst of the limiter, and waits for the bandwidth of the bytes read
 func (r *LimitSpeedReader) Read(p []byte) (n int, err error) {
-	n = 0
-	err = nil
-	start := 0
-	burst := r.ossLimiter.limiter.Burst()
-	var end int
-	var tmpN int
-	var tc int
-	for start < len(p) {
-		if start+burst*perTokenBandwidthSize < len(p) {
-			end = start + burst*perTokenBandwidthSize
-		} else {
-			end = len(p)
-		}
-
-		tmpN, err = r.reader.Read(p[start:end])
-		if tmpN > 0 {
-			n += tmpN
-			start = n
-		}
-
-		if err != nil {
-			return
+	var limiter BandwidthLimiter
+	size := limitReadSize
+	if r.limiter != nil {
+		limiter = r.limiter
+	} else if r.ossLimiter != nil {
+		limiter = r.ossLimiter
+		if burst := r.ossLimiter.limiter.Burst() * perTokenBandwidthSize; burst > 0 && burst < size {
+			size = burst
 		}
+	}
+	if limiter == nil {
+		return r.reader.Read(p)
+	}
 
-		tc = int(math.Ceil(float64(tmpN) / float64(perTokenBandwidthSize)))
-		now := time.Now()
-		re := r.ossLimiter.limiter.ReserveN(now, tc)
-		if !re.OK() {
-			err = fmt.Errorf("LimitSpeedReader.Read() failure,ReserveN error,start:%d,end:%d,burst:%d,perTokenBandwidthSize:%d",
-				start, end, burst, perTokenBandwidthSize)
-			return
+	if len(p) > size {
+		p = p[:size]
+	}
+	n, err = r.reader.Read(p)
+	if n > 0 {
+		if errWait := limiter.WaitN(r.ctx, n); errWait != nil {
+			return n, errWait
 		}
-		timeDelay := re.Delay()
-		time.Sleep(timeDelay)
 	}
 	return
 }
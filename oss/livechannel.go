@@ -251,7 +251,7 @@ func (bucket Bucket) SignRtmpURL(channelName, playlistName string, expires int64
 	if expires <= 0 {
 		return "", fmt.Errorf("invalid argument: %d, expires must greater than 0", expires)
 	}
-	expiration := time.Now().Unix() + expires
+	expiration := bucket.Client.Conn.now().Unix() + expires
 
 	return bucket.Client.Conn.signRtmpURL(bucket.BucketName, channelName, playlistName, expiration), nil
 }
@@ -13,6 +13,7 @@ type Response struct {
 	Body       io.ReadCloser
 	ClientCRC  uint64
 	ServerCRC  uint64
+	Endpoint   string // The endpoint which served the request if Config.Endpoints is set
 }
 
 func (r *Response) Read(p []byte) (n int, err error) {
@@ -7,9 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"net/http"
 	"os"
-	"strconv"
 )
 
 // CopyFile is multipart copy object
@@ -150,22 +148,17 @@ func (bucket Bucket) copyFile(srcBucketName, srcObjectKey, destBucketName, destO
 
 	// choice valid options
 	headerOptions := ChoiceHeadObjectOption(options)
-	partOptions := ChoiceTransferPartOption(options)
+	partOptions := choiceCopyPartOption(options)
 	completeOptions := ChoiceCompletePartOption(options)
 	abortOptions := ChoiceAbortPartOption(options)
 
-	meta, err := srcBucket.GetObjectDetailedMeta(srcObjectKey, headerOptions...)
-	if err != nil {
-		return err
-	}
-
-	objectSize, err := strconv.ParseInt(meta.Get(HTTPHeaderContentLength), 10, 0)
+	meta, err := srcBucket.headObject(srcObjectKey, headerOptions...)
 	if err != nil {
 		return err
 	}
 
 	// Get copy parts
-	parts := getCopyParts(objectSize, partSize)
+	parts := getCopyParts(meta.ContentLength, partSize)
 	// Initialize the multipart upload
 	imur, err := descBucket.InitiateMultipartUpload(destObjectKey, options...)
 	if err != nil {
@@ -247,7 +240,7 @@ type copyCheckpoint struct {
 }
 
 // isValid checks if the data is valid which means CP is valid and object is not updated.
-func (cp copyCheckpoint) isValid(meta http.Header) (bool, error) {
+func (cp copyCheckpoint) isValid(meta *ObjectMeta) (bool, error) {
 	// Compare CP's magic number and the MD5.
 	cpb := cp
 	cpb.MD5 = ""
@@ -259,15 +252,10 @@ func (cp copyCheckpoint) isValid(meta http.Header) (bool, error) {
 		return false, nil
 	}
 
-	objectSize, err := strconv.ParseInt(meta.Get(HTTPHeaderContentLength), 10, 64)
-	if err != nil {
-		return false, err
-	}
-
 	// Compare the object size and last modified time and etag.
-	if cp.ObjStat.Size != objectSize ||
-		cp.ObjStat.LastModified != meta.Get(HTTPHeaderLastModified) ||
-		cp.ObjStat.Etag != meta.Get(HTTPHeaderEtag) {
+	if cp.ObjStat.Size != meta.ContentLength ||
+		cp.ObjStat.LastModified != meta.Headers.Get(HTTPHeaderLastModified) ||
+		cp.ObjStat.Etag != meta.ETag {
 		return false, nil
 	}
 
@@ -338,7 +326,7 @@ func (cp copyCheckpoint) getCompletedBytes() int64 {
 }
 
 // prepare initializes the multipart upload
-func (cp *copyCheckpoint) prepare(meta http.Header, srcBucket *Bucket, srcObjectKey string, destBucket *Bucket, destObjectKey string,
+func (cp *copyCheckpoint) prepare(meta *ObjectMeta, srcBucket *Bucket, srcObjectKey string, destBucket *Bucket, destObjectKey string,
 	partSize int64, options []Option) error {
 	// CP
 	cp.Magic = copyCpMagic
@@ -347,17 +335,12 @@ func (cp *copyCheckpoint) prepare(meta http.Header, srcBucket *Bucket, srcObject
 	cp.DestBucketName = destBucket.BucketName
 	cp.DestObjectKey = destObjectKey
 
-	objectSize, err := strconv.ParseInt(meta.Get(HTTPHeaderContentLength), 10, 64)
-	if err != nil {
-		return err
-	}
-
-	cp.ObjStat.Size = objectSize
-	cp.ObjStat.LastModified = meta.Get(HTTPHeaderLastModified)
-	cp.ObjStat.Etag = meta.Get(HTTPHeaderEtag)
+	cp.ObjStat.Size = meta.ContentLength
+	cp.ObjStat.LastModified = meta.Headers.Get(HTTPHeaderLastModified)
+	cp.ObjStat.Etag = meta.ETag
 
 	// Parts
-	cp.Parts = getCopyParts(objectSize, partSize)
+	cp.Parts = getCopyParts(meta.ContentLength, partSize)
 	cp.PartStat = make([]bool, len(cp.Parts))
 	for i := range cp.PartStat {
 		cp.PartStat[i] = false
@@ -401,10 +384,10 @@ func (bucket Bucket) copyFileWithCp(srcBucketName, srcObjectKey, destBucketName,
 
 	// choice valid options
 	headerOptions := ChoiceHeadObjectOption(options)
-	partOptions := ChoiceTransferPartOption(options)
+	partOptions := choiceCopyPartOption(options)
 	completeOptions := ChoiceCompletePartOption(options)
 
-	meta, err := srcBucket.GetObjectDetailedMeta(srcObjectKey, headerOptions...)
+	meta, err := srcBucket.headObject(srcObjectKey, headerOptions...)
 	if err != nil {
 		return err
 	}
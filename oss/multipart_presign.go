@@ -0,0 +1,190 @@
+package oss
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxPartNumber is the max number of the parts of a multipart upload
+const maxPartNumber = 10000
+
+// PresignedPart is a part uploaded by the presigned URL
+type PresignedPart struct {
+	PartNumber int
+	Offset     int64  // The offset of the part in the object
+	Size       int64  // The size of the part
+	URL        string // The presigned URL of UploadPart, the part is uploaded by PUT
+}
+
+// PresignedMultipartUpload is a multipart upload whose requests are presigned, so the clients such as the browsers
+// could upload the object directly without the AccessKey.
+//
+// The parts are uploaded by PUT to the URLs of Parts, and the ETag headers of the responses are the ETags of the parts.
+// The upload is completed by POST to the CompleteURL with the CompleteMultipartUpload XML body, or by
+// Bucket.CompletePresignedMultipartUpload which validates the parts. The part URLs are signed with PartContentType,
+// so the parts must be sent with the Content-Type, or without the header if it's empty.
+type PresignedMultipartUpload struct {
+	InitiateMultipartUploadResult
+	Size            int64
+	PartSize        int64
+	PartContentType string    // The Content-Type the part URLs are signed with, set by PresignPartContentType
+	Expires         time.Time // The time the URLs expire
+	Parts           []PresignedPart
+	CompleteURL     string // The presigned URL of CompleteMultipartUpload, the request is POST
+	AbortURL        string // The presigned URL of AbortMultipartUpload, the request is DELETE
+}
+
+// PresignPartContentType is an option of PresignMultipartUpload to sign the part URLs with the Content-Type, since the
+// browsers send the header with the parts, such as the type of the Blob.
+func PresignPartContentType(contentType string) Option {
+	return addArg(partContentType, contentType)
+}
+
+// PresignMultipartUpload initiates a multipart upload and presigns the URLs to upload the parts, complete and abort it.
+//
+// objectKey    the object to upload.
+// size    the size of the object.
+// partSize    the size of the parts except the last one, in the range [100KB, 5GB].
+// expiredInSec    the seconds the URLs are valid for.
+// options    the options of InitiateMultipartUpload, such as ContentType, ObjectStorageClass and Meta, and PresignPartContentType.
+//
+// PresignedMultipartUpload    the upload, only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func (bucket Bucket) PresignMultipartUpload(objectKey string, size, partSize, expiredInSec int64, options ...Option) (PresignedMultipartUpload, error) {
+	var out PresignedMultipartUpload
+	if size < 0 {
+		return out, fmt.Errorf("oss: invalid size %d", size)
+	}
+	if partSize < MinPartSize || partSize > MaxPartSize {
+		return out, fmt.Errorf("oss: part size invalid range [%d, %d]", MinPartSize, MaxPartSize)
+	}
+	partNum := (size + partSize - 1) / partSize
+	if partNum == 0 {
+		// The empty object is a part of 0 bytes
+		partNum = 1
+	}
+	if partNum > maxPartNumber {
+		return out, fmt.Errorf("oss: too many parts %d, please increase the part size", partNum)
+	}
+	if expiredInSec < 0 {
+		return out, fmt.Errorf("oss: invalid expires: %d, expires must bigger than 0", expiredInSec)
+	}
+	contentType, err := FindOption(options, partContentType, "")
+	if err != nil {
+		return out, err
+	}
+
+	imur, err := bucket.InitiateMultipartUpload(objectKey, options...)
+	if err != nil {
+		return out, err
+	}
+	out.InitiateMultipartUploadResult = imur
+	out.Size, out.PartSize = size, partSize
+	out.PartContentType = contentType.(string)
+	// The URLs are signed by the clock corrected by the server time
+	out.Expires = bucket.Client.Conn.now().Add(time.Duration(expiredInSec) * time.Second)
+
+	if err = bucket.presignMultipartURLs(&out, objectKey, partNum, expiredInSec); err != nil {
+		// The upload could not be used without the URLs
+		bucket.AbortMultipartUpload(imur)
+		return PresignedMultipartUpload{}, err
+	}
+	return out, nil
+}
+
+// presignMultipartURLs signs the URLs of the parts, CompleteMultipartUpload and AbortMultipartUpload
+func (bucket Bucket) presignMultipartURLs(out *PresignedMultipartUpload, objectKey string, partNum, expiredInSec int64) error {
+	var err error
+	uploadID := AddParam("uploadId", out.UploadID)
+	partOptions := []Option{uploadID}
+	if out.PartContentType != "" {
+		partOptions = append(partOptions, ContentType(out.PartContentType))
+	}
+	for i := int64(0); i < partNum; i++ {
+		part := PresignedPart{PartNumber: int(i + 1), Offset: i * out.PartSize, Size: out.PartSize}
+		if part.Offset+part.Size > out.Size {
+			part.Size = out.Size - part.Offset
+		}
+		options := append(partOptions, AddParam("partNumber", strconv.Itoa(part.PartNumber)))
+		if part.URL, err = bucket.SignURL(objectKey, HTTPPut, expiredInSec, options...); err != nil {
+			return err
+		}
+		out.Parts = append(out.Parts, part)
+	}
+	if out.CompleteURL, err = bucket.SignURL(objectKey, HTTPPost, expiredInSec, uploadID); err != nil {
+		return err
+	}
+	out.AbortURL, err = bucket.SignURL(objectKey, HTTPDelete, expiredInSec, uploadID)
+	return err
+}
+
+// CompletePresignedMultipartUpload completes the presigned multipart upload by the uploaded parts. The parts are listed
+// by ListUploadedParts, all the parts must be uploaded with the expected sizes, and the ETags must be the same as
+// the ETags reported by the client if any.
+//
+// upload    the return value of PresignMultipartUpload.
+// parts    the parts reported by the client, the ETags are compared with the uploaded parts. It could be nil.
+// options    the options of CompleteMultipartUpload, such as Callback.
+//
+// CompleteMultipartUploadResult    the return value when the call succeeds. Only valid when the error is nil.
+// error    it's nil if the operation succeeds, otherwise it's an error object.
+func (bucket Bucket) CompletePresignedMultipartUpload(upload PresignedMultipartUpload, parts []UploadPart,
+	options ...Option) (CompleteMultipartUploadResult, error) {
+	var out CompleteMultipartUploadResult
+	uploaded, err := bucket.listAllUploadedParts(upload.InitiateMultipartUploadResult)
+	if err != nil {
+		return out, err
+	}
+
+	expected := map[int]string{}
+	for _, part := range parts {
+		expected[part.PartNumber] = part.ETag
+	}
+	completed := make([]UploadPart, 0, len(upload.Parts))
+	for _, part := range upload.Parts {
+		got, ok := uploaded[part.PartNumber]
+		if !ok {
+			return out, fmt.Errorf("oss: part %d isn't uploaded", part.PartNumber)
+		}
+		if int64(got.Size) != part.Size {
+			return out, fmt.Errorf("oss: the size of part %d is %d, expect %d", part.PartNumber, got.Size, part.Size)
+		}
+		if etag, ok := expected[part.PartNumber]; ok && !strings.EqualFold(strings.Trim(etag, "\""), strings.Trim(got.ETag, "\"")) {
+			return out, fmt.Errorf("oss: the ETag of part %d is %s, expect %s", part.PartNumber, got.ETag, etag)
+		}
+		completed = append(completed, UploadPart{PartNumber: part.PartNumber, ETag: got.ETag})
+	}
+	return bucket.CompleteMultipartUpload(upload.InitiateMultipartUploadResult, completed, options...)
+}
+
+// AbortPresignedMultipartUpload aborts the presigned multipart upload, the uploaded parts are deleted.
+//
+// upload    the return value of PresignMultipartUpload.
+//
+// error    it's nil if the operation succeeds, otherwise it's an error object.
+func (bucket Bucket) AbortPresignedMultipartUpload(upload PresignedMultipartUpload, options ...Option) error {
+	return bucket.AbortMultipartUpload(upload.InitiateMultipartUploadResult, options...)
+}
+
+// listAllUploadedParts lists all the pages of the uploaded parts by the part numbers
+func (bucket Bucket) listAllUploadedParts(imur InitiateMultipartUploadResult) (map[int]UploadedPart, error) {
+	parts := map[int]UploadedPart{}
+	marker := 0
+	for {
+		result, err := bucket.ListUploadedParts(imur, PartNumberMarker(marker))
+		if err != nil {
+			return nil, err
+		}
+		for _, part := range result.UploadedParts {
+			parts[part.PartNumber] = part
+		}
+		if !result.IsTruncated {
+			return parts, nil
+		}
+		if marker, err = strconv.Atoi(result.NextPartNumberMarker); err != nil {
+			return nil, fmt.Errorf("oss: invalid NextPartNumberMarker %s", result.NextPartNumberMarker)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package oss
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type OssMultipartPresignSuite struct{}
+
+var _ = Suite(&OssMultipartPresignSuite{})
+
+// newPresignedUploadServer serves the multipart upload of bucket/video.mp4, the parts are listed one per page
+func newPresignedUploadServer(c *C, parts map[int][]byte, completed *string, aborted *bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.URL.Path, Equals, "/bucket/video.mp4")
+		query := r.URL.Query()
+		switch {
+		case r.Method == "POST" && r.URL.RawQuery == "uploads":
+			c.Assert(r.Header.Get(HTTPHeaderContentType), Equals, "video/mp4")
+			w.Write([]byte("<InitiateMultipartUploadResult><Bucket>bucket</Bucket><Key>video.mp4</Key><UploadId>upload1</UploadId></InitiateMultipartUploadResult>"))
+		case r.Method == "PUT":
+			c.Assert(query.Get("uploadId"), Equals, "upload1")
+			c.Assert(query.Get("Signature"), Not(Equals), "")
+			data, err := ioutil.ReadAll(r.Body)
+			c.Assert(err, IsNil)
+			var number int
+			fmt.Sscanf(query.Get("partNumber"), "%d", &number)
+			parts[number] = data
+			sum := md5.Sum(data)
+			w.Header().Set(HTTPHeaderEtag, "\""+strings.ToUpper(hex.EncodeToString(sum[:]))+"\"")
+		case r.Method == "GET":
+			c.Assert(query.Get("uploadId"), Equals, "upload1")
+			var marker int
+			fmt.Sscanf(query.Get("part-number-marker"), "%d", &marker)
+			body := "<ListPartsResult><Bucket>bucket</Bucket><Key>video.mp4</Key><UploadId>upload1</UploadId>"
+			if data, ok := parts[marker+1]; ok {
+				sum := md5.Sum(data)
+				body += fmt.Sprintf("<IsTruncated>true</IsTruncated><NextPartNumberMarker>%d</NextPartNumberMarker>"+
+					"<Part><PartNumber>%d</PartNumber><ETag>\"%s\"</ETag><Size>%d</Size></Part>",
+					marker+1, marker+1, strings.ToUpper(hex.EncodeToString(sum[:])), len(data))
+			} else {
+				body += "<IsTruncated>false</IsTruncated>"
+			}
+			w.Write([]byte(body + "</ListPartsResult>"))
+		case r.Method == "POST":
+			c.Assert(query.Get("uploadId"), Equals, "upload1")
+			data, err := ioutil.ReadAll(r.Body)
+			c.Assert(err, IsNil)
+			*completed = string(data)
+			w.Write([]byte("<CompleteMultipartUploadResult><Bucket>bucket</Bucket><Key>video.mp4</Key><ETag>\"E-3\"</ETag></CompleteMultipartUploadResult>"))
+		case r.Method == "DELETE":
+			c.Assert(query.Get("uploadId"), Equals, "upload1")
+			*aborted = true
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+}
+
+func (s *OssMultipartPresignSuite) TestPresignMultipartUpload(c *C) {
+	parts := map[int][]byte{}
+	var completed string
+	var aborted bool
+	server := newPresignedUploadServer(c, parts, &completed, &aborted)
+	defer server.Close()
+	client, err := New(server.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("bucket")
+	c.Assert(err, IsNil)
+
+	data := bytes.Repeat([]byte("0123456789"), 25*1024)
+	upload, err := bucket.PresignMultipartUpload("video.mp4", int64(len(data)), 100*1024, 3600, ContentType("video/mp4"))
+	c.Assert(err, IsNil)
+	c.Assert(upload.UploadID, Equals, "upload1")
+	c.Assert(len(upload.Parts), Equals, 3)
+	c.Assert(upload.Parts[2].Offset, Equals, int64(200*1024))
+	c.Assert(upload.Parts[2].Size, Equals, int64(50*1024))
+	completeURL, err := url.Parse(upload.CompleteURL)
+	c.Assert(err, IsNil)
+	c.Assert(completeURL.Query().Get("uploadId"), Equals, "upload1")
+	c.Assert(completeURL.Query().Get("partNumber"), Equals, "")
+
+	// The client uploads the parts by the URLs
+	var reported []UploadPart
+	for _, part := range upload.Parts {
+		req, err := http.NewRequest("PUT", part.URL, bytes.NewReader(data[part.Offset:part.Offset+part.Size]))
+		c.Assert(err, IsNil)
+		resp, err := http.DefaultClient.Do(req)
+		c.Assert(err, IsNil)
+		resp.Body.Close()
+		reported = append(reported, UploadPart{PartNumber: part.PartNumber, ETag: resp.Header.Get(HTTPHeaderEtag)})
+	}
+	c.Assert(len(parts), Equals, 3)
+
+	// The ETags reported by the client are validated
+	wrong := append([]UploadPart{}, reported...)
+	wrong[1].ETag = "\"BAD\""
+	_, err = bucket.CompletePresignedMultipartUpload(upload, wrong)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "ETag of part 2"), Equals, true)
+	c.Assert(completed, Equals, "")
+
+	result, err := bucket.CompletePresignedMultipartUpload(upload, reported)
+	c.Assert(err, IsNil)
+	c.Assert(result.ETag, Equals, "\"E-3\"")
+	c.Assert(strings.Count(completed, "<Part>"), Equals, 3)
+	c.Assert(strings.Contains(completed, strings.Trim(reported[2].ETag, "\"")), Equals, true)
+
+	// The missing and the truncated parts
+	parts[3] = parts[3][:10]
+	_, err = bucket.CompletePresignedMultipartUpload(upload, nil)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "size of part 3"), Equals, true)
+	delete(parts, 2)
+	_, err = bucket.CompletePresignedMultipartUpload(upload, nil)
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "part 2 isn't uploaded"), Equals, true)
+
+	c.Assert(bucket.AbortPresignedMultipartUpload(upload), IsNil)
+	c.Assert(aborted, Equals, true)
+
+	// The part URLs are signed with the Content-Type
+	typed, err := bucket.PresignMultipartUpload("video.mp4", int64(len(data)), 100*1024, 3600, ContentType("video/mp4"),
+		PresignPartContentType("video/mp4"))
+	c.Assert(err, IsNil)
+	c.Assert(typed.PartContentType, Equals, "video/mp4")
+	typedURL, err := url.Parse(typed.Parts[0].URL)
+	c.Assert(err, IsNil)
+	expected, err := bucket.SignURL("video.mp4", HTTPPut, 3600, AddParam("uploadId", "upload1"), AddParam("partNumber", "1"),
+		ContentType("video/mp4"))
+	c.Assert(err, IsNil)
+	expectedURL, err := url.Parse(expected)
+	c.Assert(err, IsNil)
+	if typedURL.Query().Get("Expires") == expectedURL.Query().Get("Expires") {
+		c.Assert(typedURL.Query().Get("Signature"), Equals, expectedURL.Query().Get("Signature"))
+	}
+	c.Assert(typed.CompleteURL, Not(Equals), "")
+
+	// The expiry is measured by the clock the URLs are signed with
+	client.Config.clockOffset.set(time.Hour)
+	skewed, err := bucket.PresignMultipartUpload("video.mp4", 10, 100*1024, 60)
+	c.Assert(err, IsNil)
+	skewedURL, err := url.Parse(skewed.Parts[0].URL)
+	c.Assert(err, IsNil)
+	expires, err := strconv.ParseInt(skewedURL.Query().Get(HTTPParamExpires), 10, 64)
+	c.Assert(err, IsNil)
+	diff := expires - skewed.Expires.Unix()
+	c.Assert(diff >= -1 && diff <= 1, Equals, true, Commentf("diff %d", diff))
+	client.Config.clockOffset.set(0)
+
+	_, err = bucket.PresignMultipartUpload("video.mp4", 10, 1024, 3600)
+	c.Assert(err, NotNil)
+	_, err = bucket.PresignMultipartUpload("video.mp4", 10001*100*1024, 100*1024, 3600)
+	c.Assert(err, NotNil)
+}
+
+func (s *OssMultipartPresignSuite) TestPresignMultipartUploadAbort(c *C) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method)
+		if r.Method == "POST" {
+			w.Write([]byte("<InitiateMultipartUploadResult><Bucket>bucket</Bucket><Key>?video</Key><UploadId>upload1</UploadId></InitiateMultipartUploadResult>"))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	client, err := New(server.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("bucket")
+	c.Assert(err, IsNil)
+
+	// The key could be uploaded but not signed, the upload is aborted
+	_, err = bucket.PresignMultipartUpload("?video", 1024, 100*1024, 3600)
+	c.Assert(err, NotNil)
+	c.Assert(requests, DeepEquals, []string{"POST", "DELETE"})
+}
@@ -0,0 +1,205 @@
+package oss
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// MultiRegionClient routes the requests of the buckets to the clients of their regions. The region of a bucket is
+// resolved by GetBucketLocation or by the Endpoint of the error once, and it's cached. The clients of the regions are
+// created when they're first used, and they share the credentials, the options and the http.Client of the default
+// client.
+type MultiRegionClient struct {
+	Client *Client // The client of the default region, which resolves the bucket regions
+
+	resolver        EndpointResolver
+	accessKeyID     string
+	accessKeySecret string
+	options         []ClientOption
+
+	mu      sync.Mutex
+	regions map[string]string  // bucket -> region
+	clients map[string]*Client // region -> client
+}
+
+// NewMultiRegionClient creates a client of the buckets in all the regions.
+//
+// resolver    the resolver of the region endpoints, such as EndpointResolver{Scheme: "https"}.
+// region    the default region, such as cn-hangzhou.
+// accessKeyID    access key Id.
+// accessKeySecret    access key secret.
+// options    the options of the clients of all the regions.
+//
+// *MultiRegionClient    the new client, the returned value is valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func NewMultiRegionClient(resolver EndpointResolver, region, accessKeyID, accessKeySecret string,
+	options ...ClientOption) (*MultiRegionClient, error) {
+	mc := &MultiRegionClient{
+		resolver:        resolver,
+		accessKeyID:     accessKeyID,
+		accessKeySecret: accessKeySecret,
+		options:         options,
+		regions:         map[string]string{},
+		clients:         map[string]*Client{},
+	}
+	client, err := mc.RegionClient(region)
+	if err != nil {
+		return nil, err
+	}
+	mc.Client = client
+	return mc, nil
+}
+
+// RegionClient returns the client of the region, it's created if it doesn't exist.
+//
+// region    the region ID, such as cn-hangzhou.
+//
+// *Client    the client of the region, only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func (mc *MultiRegionClient) RegionClient(region string) (*Client, error) {
+	region = strings.TrimPrefix(region, "oss-")
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if client, ok := mc.clients[region]; ok {
+		return client, nil
+	}
+
+	endpoint, err := mc.resolver.Resolve(region)
+	if err != nil {
+		return nil, err
+	}
+	options := append([]ClientOption{Region(region)}, mc.options...)
+	if mc.Client != nil {
+		// The connections are shared by the regions
+		options = append(options, HTTPClient(mc.Client.Conn.client))
+	}
+	client, err := New(endpoint, mc.accessKeyID, mc.accessKeySecret, options...)
+	if err != nil {
+		return nil, err
+	}
+	mc.clients[region] = client
+	return client, nil
+}
+
+// BucketRegion returns the region of the bucket, such as cn-hangzhou. It's resolved by GetBucketLocation, or by the
+// Endpoint of the error if the bucket must be accessed by the endpoint of its region.
+//
+// bucketName    the bucket name.
+//
+// string    the region ID, only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func (mc *MultiRegionClient) BucketRegion(bucketName string) (string, error) {
+	mc.mu.Lock()
+	region, ok := mc.regions[bucketName]
+	mc.mu.Unlock()
+	if ok {
+		return region, nil
+	}
+
+	location, err := mc.Client.GetBucketLocation(bucketName)
+	if err != nil {
+		srvErr, ok := err.(ServiceError)
+		if !ok || (srvErr.Code != "AccessDenied" && srvErr.Code != "SecondLevelDomainForbidden") {
+			return "", err
+		}
+		if location, _ = signingScopeFromHost(srvErr.Endpoint); location == "" {
+			return "", err
+		}
+	}
+	region = strings.TrimPrefix(location, "oss-")
+	if !IsValidRegion(region) {
+		return "", fmt.Errorf("oss: invalid location %q of bucket %s", location, bucketName)
+	}
+	mc.SetBucketRegion(bucketName, region)
+	return region, nil
+}
+
+// SetBucketRegion sets the region of the bucket, so the region isn't resolved again. It could also update the region
+// after the bucket is recreated in another region.
+//
+// bucketName    the bucket name.
+// region    the region ID, such as cn-hangzhou.
+func (mc *MultiRegionClient) SetBucketRegion(bucketName, region string) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.regions[bucketName] = strings.TrimPrefix(region, "oss-")
+}
+
+// Bucket returns the bucket by the client of its region.
+//
+// bucketName    the bucket name.
+//
+// *Bucket    the bucket object, only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func (mc *MultiRegionClient) Bucket(bucketName string) (*Bucket, error) {
+	if err := CheckBucketName(bucketName); err != nil {
+		return nil, err
+	}
+	region, err := mc.BucketRegion(bucketName)
+	if err != nil {
+		return nil, err
+	}
+	client, err := mc.RegionClient(region)
+	if err != nil {
+		return nil, err
+	}
+	return client.Bucket(bucketName)
+}
+
+// CopyObject copies the object between the buckets by the client of the target bucket. OSS copies the objects in
+// the same region only, so the buckets must be in the same region.
+//
+// srcBucketName    source bucket name.
+// srcObjectKey    source object name.
+// destBucketName    target bucket name.
+// destObjectKey    target object name.
+// options    copy options, check out parameter options in function CopyObject for more details.
+//
+// CopyObjectResult    the result object, only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func (mc *MultiRegionClient) CopyObject(srcBucketName, srcObjectKey, destBucketName, destObjectKey string,
+	options ...Option) (CopyObjectResult, error) {
+	bucket, err := mc.copyBucket(srcBucketName, destBucketName)
+	if err != nil {
+		return CopyObjectResult{}, err
+	}
+	return bucket.CopyObjectFrom(srcBucketName, srcObjectKey, destObjectKey, options...)
+}
+
+// CopyFile copies the object between the buckets by multipart copy, check out Bucket.CopyFile for more details. The
+// buckets must be in the same region.
+//
+// srcBucketName    source bucket name.
+// srcObjectKey    source object name.
+// destBucketName    target bucket name.
+// destObjectKey    target object name.
+// partSize    the part size in byte.
+// options    object's contraints. Check out function InitiateMultipartUpload.
+//
+// error    it's nil if the operation succeeds, otherwise it's an error object.
+func (mc *MultiRegionClient) CopyFile(srcBucketName, srcObjectKey, destBucketName, destObjectKey string, partSize int64,
+	options ...Option) error {
+	bucket, err := mc.copyBucket(srcBucketName, destBucketName)
+	if err != nil {
+		return err
+	}
+	return bucket.CopyFile(srcBucketName, srcObjectKey, destObjectKey, partSize, options...)
+}
+
+// copyBucket returns the target bucket of the copy, it checks the buckets are in the same region
+func (mc *MultiRegionClient) copyBucket(srcBucketName, destBucketName string) (*Bucket, error) {
+	srcRegion, err := mc.BucketRegion(srcBucketName)
+	if err != nil {
+		return nil, err
+	}
+	destRegion, err := mc.BucketRegion(destBucketName)
+	if err != nil {
+		return nil, err
+	}
+	if srcRegion != destRegion {
+		return nil, fmt.Errorf("oss: can't copy from bucket %s in %s to bucket %s in %s", srcBucketName, srcRegion,
+			destBucketName, destRegion)
+	}
+	return mc.Bucket(destBucketName)
+}
@@ -0,0 +1,99 @@
+package oss
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+type OssMultiRegionSuite struct{}
+
+var _ = Suite(&OssMultiRegionSuite{})
+
+// hostRecorder sends the requests of all the hosts to the test server, and records the hosts and the requests
+type hostRecorder struct {
+	server   *url.URL
+	mu       sync.Mutex
+	requests []string
+}
+
+func (h *hostRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	h.mu.Lock()
+	h.requests = append(h.requests, req.Method+" "+req.URL.Host+req.URL.Path+"?"+req.URL.RawQuery)
+	h.mu.Unlock()
+	out := *req
+	u := *req.URL
+	u.Scheme, u.Host = h.server.Scheme, h.server.Host
+	out.URL = &u
+	out.Header = http.Header{"X-Test-Host": {req.URL.Host}}
+	for k, v := range req.Header {
+		out.Header[k] = v
+	}
+	return http.DefaultTransport.RoundTrip(&out)
+}
+
+func (s *OssMultiRegionSuite) TestRouting(c *C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Header.Get("X-Test-Host")
+		switch {
+		case r.URL.RawQuery == "location" && strings.HasPrefix(host, "moved."):
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("<Error><Code>AccessDenied</Code><Message>The bucket you are attempting to access must be addressed using the specified endpoint.</Message>" +
+				"<Endpoint>oss-us-west-1.aliyuncs.com</Endpoint></Error>"))
+		case r.URL.RawQuery == "location" && strings.HasPrefix(host, "missing."):
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("<Error><Code>NoSuchBucket</Code></Error>"))
+		case r.URL.RawQuery == "location" && strings.HasPrefix(host, "hzb."):
+			w.Write([]byte("<LocationConstraint>oss-cn-hangzhou</LocationConstraint>"))
+		case r.URL.RawQuery == "location":
+			w.Write([]byte("<LocationConstraint>oss-cn-beijing</LocationConstraint>"))
+		case r.Method == "PUT":
+			w.Write([]byte("<CopyObjectResult><ETag>\"E\"</ETag></CopyObjectResult>"))
+		}
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+	recorder := &hostRecorder{server: serverURL}
+	httpClient := &http.Client{Transport: recorder}
+
+	mc, err := NewMultiRegionClient(EndpointResolver{}, "cn-hangzhou", "ak", "sk", HTTPClient(httpClient), AuthVersion(AuthV4))
+	c.Assert(err, IsNil)
+	c.Assert(mc.Client.Config.Endpoint, Equals, "oss-cn-hangzhou.aliyuncs.com")
+
+	// The bucket is routed to its region, and the region is cached
+	bucket, err := mc.Bucket("bjb")
+	c.Assert(err, IsNil)
+	c.Assert(bucket.Client.Config.Endpoint, Equals, "oss-cn-beijing.aliyuncs.com")
+	c.Assert(bucket.Client.Config.Region, Equals, "cn-beijing")
+	_, err = mc.Bucket("bjb")
+	c.Assert(err, IsNil)
+	c.Assert(recorder.requests, DeepEquals, []string{"GET bjb.oss-cn-hangzhou.aliyuncs.com/?location"})
+	client, err := mc.RegionClient("oss-cn-beijing")
+	c.Assert(err, IsNil)
+	c.Assert(client.Conn == bucket.Client.Conn, Equals, true)
+	c.Assert(client.Conn.client == mc.Client.Conn.client, Equals, true)
+
+	// The region of the error endpoint
+	region, err := mc.BucketRegion("moved")
+	c.Assert(err, IsNil)
+	c.Assert(region, Equals, "us-west-1")
+	_, err = mc.Bucket("missing")
+	c.Assert(err.(ServiceError).Code, Equals, "NoSuchBucket")
+	mc.SetBucketRegion("missing", "oss-cn-shanghai")
+	bucket, err = mc.Bucket("missing")
+	c.Assert(err, IsNil)
+	c.Assert(bucket.Client.Config.Endpoint, Equals, "oss-cn-shanghai.aliyuncs.com")
+
+	// The copy is sent to the region of the target bucket
+	recorder.requests = nil
+	_, err = mc.CopyObject("bjb", "a", "bjb2", "b")
+	c.Assert(err, IsNil)
+	c.Assert(recorder.requests[len(recorder.requests)-1], Equals, "PUT bjb2.oss-cn-beijing.aliyuncs.com/b?")
+	_, err = mc.CopyObject("hzb", "a", "bjb", "b")
+	c.Assert(err, NotNil)
+	c.Assert(mc.CopyFile("bjb", "a", "moved", "b", MinPartSize), NotNil)
+}
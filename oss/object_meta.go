@@ -0,0 +1,253 @@
+package oss
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RestoreStatus is the parsed value of the x-oss-restore header
+type RestoreStatus struct {
+	OngoingRequest bool      // True while the restore is still in progress
+	ExpiryDate     time.Time // The time when the restored copy expires, zero while restoring
+}
+
+// ObjectMeta defines the object metadata returned by HeadObject
+type ObjectMeta struct {
+	ContentLength             int64             // Object size in bytes
+	ContentType               string            // Content-Type
+	ContentEncoding           string            // Content-Encoding
+	ContentDisposition        string            // Content-Disposition
+	ContentLanguage           string            // Content-Language
+	CacheControl              string            // Cache-Control
+	Expires                   string            // Expires, kept as the raw header value
+	ContentMD5                string            // Content-MD5, only set for objects uploaded by PutObject
+	ETag                      string            // ETag, including the quotes
+	LastModified              time.Time         // Last-Modified
+	ObjectType                string            // Normal, Appendable, Multipart or Symlink
+	StorageClass              string            // Standard, IA, Archive, ColdArchive or DeepColdArchive
+	Restore                   *RestoreStatus    // Restore status, nil if the object is not being or has not been restored
+	HashCRC64                 uint64            // CRC64 of the object, 0 if the server did not return it
+	VersionId                 string            // Version id, empty if versioning is not enabled
+	ServerSideEncryption      string            // Server side encryption algorithm
+	ServerSideEncryptionKeyID string            // KMS key id
+	ServerSideDataEncryption  string            // Data encryption algorithm when KMS is used
+	TaggingCount              int               // Number of tags attached to the object
+	TransitionTime            *time.Time        // The time when the storage class was changed by a lifecycle rule
+	UserMeta                  map[string]string // User metadata without the x-oss-meta- prefix, keys are lower case
+	Headers                   http.Header       // The raw response headers
+}
+
+// parseObjectMeta builds ObjectMeta from the headers of a HEAD response. The invalid headers except Content-Length,
+// such as Last-Modified, x-oss-hash-crc64ecma and x-oss-tagging-count, are ignored if strict is false, since the
+// transfers compare Last-Modified by the raw header.
+func parseObjectMeta(header http.Header, strict bool) (*ObjectMeta, error) {
+	meta := &ObjectMeta{
+		ContentType:               header.Get(HTTPHeaderContentType),
+		ContentEncoding:           header.Get(HTTPHeaderContentEncoding),
+		ContentDisposition:        header.Get(HTTPHeaderContentDisposition),
+		ContentLanguage:           header.Get(HTTPHeaderContentLanguage),
+		CacheControl:              header.Get(HTTPHeaderCacheControl),
+		Expires:                   header.Get(HTTPHeaderExpires),
+		ContentMD5:                header.Get(HTTPHeaderContentMD5),
+		ETag:                      header.Get(HTTPHeaderEtag),
+		ObjectType:                header.Get(HTTPHeaderOssObjectType),
+		StorageClass:              header.Get(HTTPHeaderOssStorageClass),
+		VersionId:                 header.Get(HTTPHeaderOssVersionID),
+		ServerSideEncryption:      header.Get(HTTPHeaderOssServerSideEncryption),
+		ServerSideEncryptionKeyID: header.Get(HTTPHeaderOssServerSideEncryptionKeyID),
+		ServerSideDataEncryption:  header.Get(HTTPHeaderOssServerSideDataEncryption),
+		UserMeta:                  map[string]string{},
+		Headers:                   header,
+	}
+
+	var err error
+	if v := header.Get(HTTPHeaderContentLength); v != "" {
+		if meta.ContentLength, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return nil, err
+		}
+	}
+
+	if v := header.Get(HTTPHeaderLastModified); v != "" {
+		t, err := http.ParseTime(v)
+		if err != nil && strict {
+			return nil, err
+		}
+		meta.LastModified = t
+	}
+
+	if v := header.Get(HTTPHeaderOssCRC64); v != "" {
+		crc, err := strconv.ParseUint(v, 10, 64)
+		if err != nil && strict {
+			return nil, err
+		}
+		meta.HashCRC64 = crc
+	}
+
+	if v := header.Get(HTTPHeaderOssTaggingCount); v != "" {
+		count, err := strconv.Atoi(v)
+		if err != nil && strict {
+			return nil, err
+		}
+		meta.TaggingCount = count
+	}
+
+	if v := header.Get(HTTPHeaderOssTransitionTime); v != "" {
+		t, err := http.ParseTime(v)
+		if err != nil && strict {
+			return nil, err
+		}
+		if err == nil {
+			meta.TransitionTime = &t
+		}
+	}
+
+	if v := header.Get(HTTPHeaderOssRestore); v != "" {
+		restore, err := parseRestoreStatus(v)
+		if err != nil && strict {
+			return nil, err
+		}
+		meta.Restore = restore
+	}
+
+	prefix := strings.ToLower(HTTPHeaderOssMetaPrefix)
+	for k, v := range header {
+		lowKey := strings.ToLower(k)
+		if strings.HasPrefix(lowKey, prefix) && len(v) > 0 {
+			meta.UserMeta[lowKey[len(prefix):]] = v[0]
+		}
+	}
+
+	return meta, nil
+}
+
+// parseRestoreStatus parses the x-oss-restore header, such as
+// ongoing-request="false", expiry-date="Sun, 16 Apr 2017 08:12:33 GMT"
+func parseRestoreStatus(value string) (*RestoreStatus, error) {
+	status := &RestoreStatus{}
+	if v, ok := restoreHeaderValue(value, "ongoing-request"); ok {
+		status.OngoingRequest = strings.EqualFold(v, "true")
+	}
+
+	if v, ok := restoreHeaderValue(value, "expiry-date"); ok && v != "" {
+		t, err := http.ParseTime(v)
+		if err != nil {
+			return nil, err
+		}
+		status.ExpiryDate = t
+	}
+	return status, nil
+}
+
+// restoreHeaderValue returns the quoted value of the key in the x-oss-restore header
+func restoreHeaderValue(header, key string) (string, bool) {
+	pos := strings.Index(strings.ToLower(header), key+"=")
+	if pos == -1 {
+		return "", false
+	}
+	v := strings.TrimSpace(header[pos+len(key)+1:])
+	if strings.HasPrefix(v, "\"") {
+		v = v[1:]
+		if end := strings.Index(v, "\""); end != -1 {
+			v = v[:end]
+		}
+	} else if end := strings.Index(v, ","); end != -1 {
+		v = v[:end]
+	}
+	return strings.TrimSpace(v), true
+}
+
+// ObjectMetaPatch defines the changes applied to an existing object by UpdateObjectMeta.
+// Metadata keys are the user metadata names without the x-oss-meta- prefix.
+type ObjectMetaPatch struct {
+	AddMeta       map[string]string // User metadata to add, existing values are overwritten
+	ReplaceMeta   map[string]string // User metadata to replace, the keys must already exist
+	RemoveMeta    []string          // User metadata keys to remove
+	SetHeaders    map[string]string // Standard headers to set, such as Cache-Control and Content-Type
+	RemoveHeaders []string          // Standard headers to remove
+}
+
+// patchableHeaders are the standard headers which could be changed by ObjectMetaPatch
+var patchableHeaders = []string{
+	HTTPHeaderContentType,
+	HTTPHeaderCacheControl,
+	HTTPHeaderContentDisposition,
+	HTTPHeaderContentEncoding,
+	HTTPHeaderContentLanguage,
+	HTTPHeaderExpires,
+}
+
+// canonicalPatchHeader returns the canonical name of a patchable header
+func canonicalPatchHeader(name string) (string, error) {
+	for _, h := range patchableHeaders {
+		if strings.EqualFold(h, name) {
+			return h, nil
+		}
+	}
+	return "", fmt.Errorf("oss: header %s can not be updated, valid headers are %s", name, strings.Join(patchableHeaders, ", "))
+}
+
+// apply merges the patch into the current metadata and returns the options
+// describing the complete new set of user metadata and standard headers.
+func (patch ObjectMetaPatch) apply(meta *ObjectMeta) ([]Option, error) {
+	userMeta := map[string]string{}
+	for k, v := range meta.UserMeta {
+		userMeta[k] = v
+	}
+	for k, v := range patch.AddMeta {
+		userMeta[strings.ToLower(k)] = v
+	}
+	for k, v := range patch.ReplaceMeta {
+		key := strings.ToLower(k)
+		if _, ok := userMeta[key]; !ok {
+			return nil, fmt.Errorf("oss: user meta %s does not exist and can not be replaced", k)
+		}
+		userMeta[key] = v
+	}
+	for _, k := range patch.RemoveMeta {
+		delete(userMeta, strings.ToLower(k))
+	}
+
+	headers := map[string]string{}
+	for _, h := range patchableHeaders {
+		if v := meta.Headers.Get(h); v != "" {
+			headers[h] = v
+		}
+	}
+	for k, v := range patch.SetHeaders {
+		h, err := canonicalPatchHeader(k)
+		if err != nil {
+			return nil, err
+		}
+		headers[h] = v
+	}
+	for _, k := range patch.RemoveHeaders {
+		h, err := canonicalPatchHeader(k)
+		if err != nil {
+			return nil, err
+		}
+		delete(headers, h)
+	}
+
+	options := []Option{}
+	for _, k := range sortedKeys(headers) {
+		options = append(options, SetHeader(k, headers[k]))
+	}
+	for _, k := range sortedKeys(userMeta) {
+		options = append(options, Meta(k, userMeta[k]))
+	}
+	return options, nil
+}
+
+// sortedKeys returns the keys of the map in order, so the options are built deterministically
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
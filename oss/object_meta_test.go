@@ -0,0 +1,235 @@
+package oss
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type OssObjectMetaSuite struct{}
+
+var _ = Suite(&OssObjectMetaSuite{})
+
+func (s *OssObjectMetaSuite) TestParseObjectMeta(c *C) {
+	header := http.Header{}
+	header.Set(HTTPHeaderContentLength, "344606")
+	header.Set(HTTPHeaderContentType, "image/jpeg")
+	header.Set(HTTPHeaderEtag, "\"5B3C1A2E053D763E1B002CC607C5A0FE\"")
+	header.Set(HTTPHeaderLastModified, "Fri, 24 Feb 2012 06:07:48 GMT")
+	header.Set(HTTPHeaderOssObjectType, "Normal")
+	header.Set(HTTPHeaderOssStorageClass, "Archive")
+	header.Set(HTTPHeaderOssCRC64, "3129476284917346876")
+	header.Set(HTTPHeaderOssVersionID, "CAEQNhiBgMDJgZCA0BYiIDc4MGZjZGI2OTBjOTRmNTE5NmU5NmFhZjhjYmY0****")
+	header.Set(HTTPHeaderOssServerSideEncryption, "KMS")
+	header.Set(HTTPHeaderOssServerSideEncryptionKeyID, "9468da86-3509-4f8d-a61e-6eab1eac****")
+	header.Set(HTTPHeaderOssTaggingCount, "2")
+	header.Set(HTTPHeaderOssTransitionTime, "Thu, 10 Dec 2020 03:18:00 GMT")
+	header.Set(HTTPHeaderOssRestore, "ongoing-request=\"false\", expiry-date=\"Sun, 16 Apr 2017 08:12:33 GMT\"")
+	header.Set("X-Oss-Meta-Author", "baymax")
+	header.Set("x-oss-meta-Location", "hangzhou")
+
+	meta, err := parseObjectMeta(header, true)
+	c.Assert(err, IsNil)
+	c.Assert(meta.ContentLength, Equals, int64(344606))
+	c.Assert(meta.ContentType, Equals, "image/jpeg")
+	c.Assert(meta.ETag, Equals, "\"5B3C1A2E053D763E1B002CC607C5A0FE\"")
+	c.Assert(meta.LastModified.Equal(time.Date(2012, time.February, 24, 6, 7, 48, 0, time.UTC)), Equals, true)
+	c.Assert(meta.ObjectType, Equals, "Normal")
+	c.Assert(meta.StorageClass, Equals, "Archive")
+	c.Assert(meta.HashCRC64, Equals, uint64(3129476284917346876))
+	c.Assert(meta.VersionId, Equals, "CAEQNhiBgMDJgZCA0BYiIDc4MGZjZGI2OTBjOTRmNTE5NmU5NmFhZjhjYmY0****")
+	c.Assert(meta.ServerSideEncryption, Equals, "KMS")
+	c.Assert(meta.ServerSideEncryptionKeyID, Equals, "9468da86-3509-4f8d-a61e-6eab1eac****")
+	c.Assert(meta.TaggingCount, Equals, 2)
+	c.Assert(meta.TransitionTime, NotNil)
+	c.Assert(meta.TransitionTime.Equal(time.Date(2020, time.December, 10, 3, 18, 0, 0, time.UTC)), Equals, true)
+	c.Assert(meta.Restore, NotNil)
+	c.Assert(meta.Restore.OngoingRequest, Equals, false)
+	c.Assert(meta.Restore.ExpiryDate.Equal(time.Date(2017, time.April, 16, 8, 12, 33, 0, time.UTC)), Equals, true)
+	c.Assert(len(meta.UserMeta), Equals, 2)
+	c.Assert(meta.UserMeta["author"], Equals, "baymax")
+	c.Assert(meta.UserMeta["location"], Equals, "hangzhou")
+
+	// ongoing restore
+	header = http.Header{}
+	header.Set(HTTPHeaderOssRestore, "ongoing-request=\"true\"")
+	meta, err = parseObjectMeta(header, true)
+	c.Assert(err, IsNil)
+	c.Assert(meta.Restore.OngoingRequest, Equals, true)
+	c.Assert(meta.Restore.ExpiryDate.IsZero(), Equals, true)
+	c.Assert(meta.TransitionTime, IsNil)
+	c.Assert(meta.ContentLength, Equals, int64(0))
+
+	// invalid values
+	header = http.Header{}
+	header.Set(HTTPHeaderContentLength, "abc")
+	_, err = parseObjectMeta(header, true)
+	c.Assert(err, NotNil)
+
+	header = http.Header{}
+	header.Set(HTTPHeaderLastModified, "yesterday")
+	_, err = parseObjectMeta(header, true)
+	c.Assert(err, NotNil)
+
+	header = http.Header{}
+	header.Set(HTTPHeaderOssCRC64, "-1")
+	_, err = parseObjectMeta(header, true)
+	c.Assert(err, NotNil)
+	header = http.Header{}
+	header.Set(HTTPHeaderOssRestore, "ongoing-request=\"false\", expiry-date=\"invalid\"")
+	_, err = parseObjectMeta(header, true)
+	c.Assert(err, NotNil)
+
+	// the invalid optional headers are ignored by the transfers
+	header.Set(HTTPHeaderContentLength, "10")
+	header.Set(HTTPHeaderOssTaggingCount, "abc")
+	header.Set(HTTPHeaderOssTransitionTime, "invalid")
+	header.Set(HTTPHeaderLastModified, "yesterday")
+	header.Set(HTTPHeaderOssCRC64, "-1")
+	meta, err = parseObjectMeta(header, false)
+	c.Assert(err, IsNil)
+	c.Assert(meta.ContentLength, Equals, int64(10))
+	c.Assert(meta.LastModified.IsZero(), Equals, true)
+	c.Assert(meta.HashCRC64, Equals, uint64(0))
+	c.Assert(meta.Headers.Get(HTTPHeaderLastModified), Equals, "yesterday")
+	c.Assert(meta.TaggingCount, Equals, 0)
+	c.Assert(meta.TransitionTime, IsNil)
+	c.Assert(meta.Restore, IsNil)
+
+	header.Set(HTTPHeaderContentLength, "abc")
+	_, err = parseObjectMeta(header, false)
+	c.Assert(err, NotNil)
+}
+
+func (s *OssObjectMetaSuite) TestHeadObject(c *C) {
+	var method, path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		w.Header().Set(HTTPHeaderContentLength, "10")
+		w.Header().Set(HTTPHeaderOssObjectType, "Appendable")
+		w.Header().Set("X-Oss-Meta-Key", "value")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := New(server.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("bucket")
+	c.Assert(err, IsNil)
+
+	meta, err := bucket.HeadObject("object")
+	c.Assert(err, IsNil)
+	c.Assert(method, Equals, "HEAD")
+	c.Assert(path, Equals, "/bucket/object")
+	c.Assert(meta.ContentLength, Equals, int64(10))
+	c.Assert(meta.ObjectType, Equals, "Appendable")
+	c.Assert(meta.UserMeta["key"], Equals, "value")
+	c.Assert(meta.Headers.Get("X-Oss-Meta-Key"), Equals, "value")
+}
+
+func (s *OssObjectMetaSuite) TestObjectMetaPatchApply(c *C) {
+	header := http.Header{}
+	header.Set(HTTPHeaderContentType, "text/plain")
+	header.Set(HTTPHeaderCacheControl, "no-cache")
+	header.Set(HTTPHeaderContentLanguage, "zh-CN")
+	header.Set("X-Oss-Meta-A", "1")
+	header.Set("X-Oss-Meta-B", "2")
+	header.Set("X-Oss-Meta-C", "3")
+	meta, err := parseObjectMeta(header, true)
+	c.Assert(err, IsNil)
+
+	patch := ObjectMetaPatch{
+		AddMeta:       map[string]string{"D": "4", "a": "11"},
+		ReplaceMeta:   map[string]string{"b": "22"},
+		RemoveMeta:    []string{"C"},
+		SetHeaders:    map[string]string{"cache-control": "max-age=60"},
+		RemoveHeaders: []string{HTTPHeaderContentLanguage},
+	}
+	options, err := patch.apply(meta)
+	c.Assert(err, IsNil)
+
+	headers := map[string]string{}
+	err = handleOptions(headers, options)
+	c.Assert(err, IsNil)
+	c.Assert(len(headers), Equals, 5)
+	c.Assert(headers[HTTPHeaderContentType], Equals, "text/plain")
+	c.Assert(headers[HTTPHeaderCacheControl], Equals, "max-age=60")
+	c.Assert(headers[HTTPHeaderOssMetaPrefix+"a"], Equals, "11")
+	c.Assert(headers[HTTPHeaderOssMetaPrefix+"b"], Equals, "22")
+	c.Assert(headers[HTTPHeaderOssMetaPrefix+"d"], Equals, "4")
+
+	// the options are built in order
+	var keys []string
+	for _, option := range options {
+		single := map[string]optionValue{}
+		c.Assert(option(single), IsNil)
+		for k := range single {
+			keys = append(keys, k)
+		}
+	}
+	c.Assert(keys, DeepEquals, []string{HTTPHeaderCacheControl, HTTPHeaderContentType,
+		HTTPHeaderOssMetaPrefix + "a", HTTPHeaderOssMetaPrefix + "b", HTTPHeaderOssMetaPrefix + "d"})
+
+	// the source condition is only sent by the part copy
+	partOptions := []Option{CopySourceIfMatch("\"etag\"")}
+	ifMatch, _ := FindOption(ChoiceTransferPartOption(partOptions), HTTPHeaderOssCopySourceIfMatch, nil)
+	c.Assert(ifMatch, IsNil)
+	ifMatch, _ = FindOption(choiceCopyPartOption(partOptions), HTTPHeaderOssCopySourceIfMatch, nil)
+	c.Assert(ifMatch, Equals, "\"etag\"")
+
+	// replace a missing key
+	patch = ObjectMetaPatch{ReplaceMeta: map[string]string{"x": "1"}}
+	_, err = patch.apply(meta)
+	c.Assert(err, NotNil)
+
+	// unsupported header
+	patch = ObjectMetaPatch{SetHeaders: map[string]string{HTTPHeaderOssStorageClass: "IA"}}
+	_, err = patch.apply(meta)
+	c.Assert(err, NotNil)
+}
+
+func (s *OssObjectMetaSuite) TestUpdateObjectMeta(c *C) {
+	var copyHeader http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, isACL := r.URL.Query()["acl"]
+		switch {
+		case r.Method == "HEAD":
+			w.Header().Set(HTTPHeaderContentLength, "1024")
+			w.Header().Set(HTTPHeaderContentType, "text/plain")
+			w.Header().Set(HTTPHeaderEtag, "\"etag\"")
+			w.Header().Set(HTTPHeaderOssStorageClass, "IA")
+			w.Header().Set("X-Oss-Meta-Keep", "yes")
+			w.WriteHeader(http.StatusOK)
+		case r.Method == "GET" && isACL:
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("<AccessControlPolicy><AccessControlList><Grant>public-read</Grant></AccessControlList></AccessControlPolicy>"))
+		case r.Method == "PUT":
+			copyHeader = r.Header
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("<CopyObjectResult><ETag>\"etag2\"</ETag></CopyObjectResult>"))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	client, err := New(server.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("bucket")
+	c.Assert(err, IsNil)
+
+	err = bucket.UpdateObjectMeta("object", ObjectMetaPatch{AddMeta: map[string]string{"new": "value"}})
+	c.Assert(err, IsNil)
+	c.Assert(copyHeader, NotNil)
+	c.Assert(copyHeader.Get(HTTPHeaderOssCopySource), Equals, "/bucket/object")
+	c.Assert(copyHeader.Get(HTTPHeaderOssCopySourceIfMatch), Equals, "\"etag\"")
+	c.Assert(copyHeader.Get(HTTPHeaderOssMetadataDirective), Equals, string(MetaReplace))
+	c.Assert(copyHeader.Get(HTTPHeaderOssTaggingDirective), Equals, string(TaggingCopy))
+	c.Assert(copyHeader.Get(HTTPHeaderOssObjectACL), Equals, "public-read")
+	c.Assert(copyHeader.Get(HTTPHeaderOssStorageClass), Equals, "IA")
+	c.Assert(copyHeader.Get(HTTPHeaderContentType), Equals, "text/plain")
+	c.Assert(copyHeader.Get("X-Oss-Meta-Keep"), Equals, "yes")
+	c.Assert(copyHeader.Get("X-Oss-Meta-New"), Equals, "value")
+}
@@ -33,6 +33,16 @@ const (
 	objectHashFunc     = "object-hash-func"
 	responseBody       = "x-response-body"
 	contextArg         = "x-context-arg"
+	waiterMinDelay     = "x-waiter-min-delay"
+	waiterMaxDelay     = "x-waiter-max-delay"
+	waiterMaxWait      = "x-waiter-max-wait"
+	selectUnordered    = "x-select-unordered"
+	selectRetries      = "x-select-retries"
+	signEndpoint       = "x-sign-endpoint"
+	hedgeArg           = "x-hedge"
+	uploadLimiterArg   = "x-upload-limiter"
+	downloadLimiterArg = "x-download-limiter"
+	partContentType    = "x-part-content-type"
 )
 
 type (
@@ -454,6 +464,12 @@ func ObjectHashFunc(value ObjecthashFuncType) Option {
 	return addArg(objectHashFunc, value)
 }
 
+// SignEndpoint is an option of SignURL, the signed URL targets the endpoint instead of the endpoint of the client,
+// such as one of Config.Endpoints.
+func SignEndpoint(endpoint string) Option {
+	return addArg(signEndpoint, endpoint)
+}
+
 // WithContext returns an option that sets the context for requests.
 func WithContext(ctx context.Context) Option {
 	return addArg(contextArg, ctx)
@@ -481,6 +497,16 @@ func Routines(n int) Option {
 	return addArg(routineNum, n)
 }
 
+// SelectUnordered lets SelectObjectParallel write the splits in the order they complete instead of the object order
+func SelectUnordered(unordered bool) Option {
+	return addArg(selectUnordered, unordered)
+}
+
+// SelectSplitRetries sets the retry times of a failed split for SelectObjectParallel
+func SelectSplitRetries(n int) Option {
+	return addArg(selectRetries, n)
+}
+
 // InitCRC Init AppendObject CRC
 func InitCRC(initCRC uint64) Option {
 	return addArg(initCRC64, initCRC)
@@ -571,6 +597,24 @@ func addParam(key string, value interface{}) Option {
 	}
 }
 
+// WaiterDelay is an option to set the min and max delay between two polls of a waiter.
+// The delay starts from minDelay and doubles after every poll until it reaches maxDelay.
+func WaiterDelay(minDelay, maxDelay time.Duration) Option {
+	return func(params map[string]optionValue) error {
+		if minDelay <= 0 || maxDelay < minDelay {
+			return fmt.Errorf("oss: invalid waiter delay, min: %v, max: %v", minDelay, maxDelay)
+		}
+		params[waiterMinDelay] = optionValue{minDelay, optionArg}
+		params[waiterMaxDelay] = optionValue{maxDelay, optionArg}
+		return nil
+	}
+}
+
+// WaiterMaxWait is an option to set the max total time of a waiter, 0 means waiting until the context is done
+func WaiterMaxWait(maxWait time.Duration) Option {
+	return addArg(waiterMaxWait, maxWait)
+}
+
 func addArg(key string, value interface{}) Option {
 	return func(params map[string]optionValue) error {
 		if value == nil {
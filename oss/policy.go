@@ -0,0 +1,384 @@
+package oss
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Effects of a policy statement
+const (
+	PolicyEffectAllow = "Allow"
+	PolicyEffectDeny  = "Deny"
+)
+
+// Common condition keys of bucket policies
+const (
+	PolicyKeySourceIP        = "acs:SourceIp"
+	PolicyKeySourceVpc       = "acs:SourceVpc"
+	PolicyKeySecureTransport = "acs:SecureTransport"
+	PolicyKeyCurrentTime     = "acs:CurrentTime"
+	PolicyKeyUserAgent       = "acs:UserAgent"
+	PolicyKeyReferer         = "acs:Referer"
+	PolicyKeyPrefix          = "oss:Prefix"
+	PolicyKeyDelimiter       = "oss:Delimiter"
+)
+
+// PolicyDocument is the bucket policy used by SetBucketPolicyDocument and GetBucketPolicyDocument.
+//
+// The documents decoded by ParsePolicy are encoded in the same form, a single string stays a string and
+// a boolean condition value stays a boolean, as long as the values are not changed.
+type PolicyDocument struct {
+	Version   string            `json:"Version"`
+	Statement []PolicyStatement `json:"Statement"`
+}
+
+// PolicyStatement is a statement of the bucket policy
+type PolicyStatement struct {
+	Sid         string                         `json:"Sid,omitempty"`
+	Effect      string                         `json:"Effect"`                // PolicyEffectAllow or PolicyEffectDeny
+	Principal   []string                       `json:"Principal,omitempty"`   // The account or RAM user IDs, * means everyone
+	Action      []string                       `json:"Action,omitempty"`      // The actions, such as oss:GetObject or oss:Get*
+	NotAction   []string                       `json:"NotAction,omitempty"`   // The actions excluded
+	Resource    []string                       `json:"Resource,omitempty"`    // The resources, such as acs:oss:*:*:bucket/prefix*
+	NotResource []string                       `json:"NotResource,omitempty"` // The resources excluded
+	Condition   map[string]map[string][]string `json:"Condition,omitempty"`   // Operator to condition key to values, such as StringLike: {oss:Prefix: [a/*]}
+
+	raw map[string]json.RawMessage // The original values by field or condition path, which are kept when they're not changed
+}
+
+// policyStatementJSON is the JSON form of PolicyStatement, the values are decoded by decodePolicyValues
+type policyStatementJSON struct {
+	Sid         string                                `json:"Sid,omitempty"`
+	Effect      string                                `json:"Effect"`
+	Principal   json.RawMessage                       `json:"Principal,omitempty"`
+	Action      json.RawMessage                       `json:"Action,omitempty"`
+	NotAction   json.RawMessage                       `json:"NotAction,omitempty"`
+	Resource    json.RawMessage                       `json:"Resource,omitempty"`
+	NotResource json.RawMessage                       `json:"NotResource,omitempty"`
+	Condition   map[string]map[string]json.RawMessage `json:"Condition,omitempty"`
+}
+
+// UnmarshalJSON decodes the statement, the values could be a string or an array
+func (s *PolicyStatement) UnmarshalJSON(data []byte) error {
+	var in policyStatementJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	out := PolicyStatement{Sid: in.Sid, Effect: in.Effect, raw: map[string]json.RawMessage{}}
+	fields := []struct {
+		name   string
+		raw    json.RawMessage
+		values *[]string
+	}{
+		{"Principal", in.Principal, &out.Principal},
+		{"Action", in.Action, &out.Action},
+		{"NotAction", in.NotAction, &out.NotAction},
+		{"Resource", in.Resource, &out.Resource},
+		{"NotResource", in.NotResource, &out.NotResource},
+	}
+	for _, field := range fields {
+		if field.raw == nil {
+			continue
+		}
+		values, err := decodePolicyValues(field.raw)
+		if err != nil {
+			return fmt.Errorf("oss: invalid policy %s: %s", field.name, err.Error())
+		}
+		*field.values = values
+		out.raw[field.name] = field.raw
+	}
+
+	if in.Condition != nil {
+		out.Condition = map[string]map[string][]string{}
+		for operator, keys := range in.Condition {
+			out.Condition[operator] = map[string][]string{}
+			for key, raw := range keys {
+				values, err := decodePolicyValues(raw)
+				if err != nil {
+					return fmt.Errorf("oss: invalid policy condition %s %s: %s", operator, key, err.Error())
+				}
+				out.Condition[operator][key] = values
+				out.raw[policyConditionPath(operator, key)] = raw
+			}
+		}
+	}
+	*s = out
+	return nil
+}
+
+// MarshalJSON encodes the statement, the values are arrays unless they're decoded from another form and not changed
+func (s PolicyStatement) MarshalJSON() ([]byte, error) {
+	out := policyStatementJSON{
+		Sid:         s.Sid,
+		Effect:      s.Effect,
+		Principal:   s.encodeValues("Principal", s.Principal),
+		Action:      s.encodeValues("Action", s.Action),
+		NotAction:   s.encodeValues("NotAction", s.NotAction),
+		Resource:    s.encodeValues("Resource", s.Resource),
+		NotResource: s.encodeValues("NotResource", s.NotResource),
+	}
+	if s.Condition != nil {
+		out.Condition = map[string]map[string]json.RawMessage{}
+		for operator, keys := range s.Condition {
+			out.Condition[operator] = map[string]json.RawMessage{}
+			for key, values := range keys {
+				out.Condition[operator][key] = s.encodeValues(policyConditionPath(operator, key), values)
+			}
+		}
+	}
+	return json.Marshal(out)
+}
+
+func (s PolicyStatement) encodeValues(path string, values []string) json.RawMessage {
+	if values == nil {
+		return nil
+	}
+	if raw, ok := s.raw[path]; ok {
+		if original, err := decodePolicyValues(raw); err == nil && reflect.DeepEqual(original, values) {
+			return raw
+		}
+	}
+	data, _ := json.Marshal(values)
+	return data
+}
+
+func policyConditionPath(operator, key string) string {
+	return "Condition\x00" + operator + "\x00" + key
+}
+
+// decodePolicyValues decodes a string, a boolean, a number or an array of them into strings.
+// The principal in the form of {"RAM": [...]} is decoded into the values of the object.
+func decodePolicyValues(raw json.RawMessage) ([]string, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return nil, fmt.Errorf("empty value")
+	}
+	switch raw[0] {
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil, err
+		}
+		values := make([]string, 0, len(items))
+		for _, item := range items {
+			value, err := decodePolicyScalar(item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		return values, nil
+	case '{':
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &object); err != nil {
+			return nil, err
+		}
+		keys := make([]string, 0, len(object))
+		for k := range object {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		values := []string{}
+		for _, k := range keys {
+			v, err := decodePolicyValues(object[k])
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v...)
+		}
+		return values, nil
+	}
+	value, err := decodePolicyScalar(raw)
+	if err != nil {
+		return nil, err
+	}
+	return []string{value}, nil
+}
+
+func decodePolicyScalar(raw json.RawMessage) (string, error) {
+	var v interface{}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&v); err != nil {
+		return "", err
+	}
+	switch value := v.(type) {
+	case string:
+		return value, nil
+	case bool:
+		if value {
+			return "true", nil
+		}
+		return "false", nil
+	case json.Number:
+		return value.String(), nil
+	}
+	return "", fmt.Errorf("unsupported value %s", string(raw))
+}
+
+// ParsePolicy decodes the policy returned by GetBucketPolicy
+//
+// policy    the policy in JSON.
+//
+// PolicyDocument    the policy document.
+// error    it's nil if no error, otherwise it's an error object.
+func ParsePolicy(policy string) (PolicyDocument, error) {
+	var doc PolicyDocument
+	if err := json.Unmarshal([]byte(policy), &doc); err != nil {
+		return PolicyDocument{}, err
+	}
+	return doc, nil
+}
+
+// JSON encodes the policy used by SetBucketPolicy
+func (doc PolicyDocument) JSON() (string, error) {
+	buf := new(bytes.Buffer)
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(doc); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// Validate checks the structure of the policy, OSS checks the rest
+func (doc PolicyDocument) Validate() error {
+	if doc.Version != "1" {
+		return fmt.Errorf("oss: invalid policy version %s, it must be 1", doc.Version)
+	}
+	if len(doc.Statement) == 0 {
+		return fmt.Errorf("oss: policy has no statement")
+	}
+	for i, s := range doc.Statement {
+		name := s.Sid
+		if name == "" {
+			name = fmt.Sprintf("#%d", i)
+		}
+		if s.Effect != PolicyEffectAllow && s.Effect != PolicyEffectDeny {
+			return fmt.Errorf("oss: policy statement %s: invalid effect %s, it must be Allow or Deny", name, s.Effect)
+		}
+		if (len(s.Action) == 0) == (len(s.NotAction) == 0) {
+			return fmt.Errorf("oss: policy statement %s: must set one of Action and NotAction", name)
+		}
+		if (len(s.Resource) == 0) == (len(s.NotResource) == 0) {
+			return fmt.Errorf("oss: policy statement %s: must set one of Resource and NotResource", name)
+		}
+		for operator := range s.Condition {
+			if _, ok := policyOperator(operator); !ok {
+				return fmt.Errorf("oss: policy statement %s: unsupported condition operator %s", name, operator)
+			}
+		}
+	}
+	return nil
+}
+
+// PolicyResource returns the resource of the bucket, or the objects if the pattern isn't empty, such as acs:oss:*:*:bucket/logs/*
+func PolicyResource(bucketName, objectPattern string) string {
+	if objectPattern == "" {
+		return "acs:oss:*:*:" + bucketName
+	}
+	return "acs:oss:*:*:" + bucketName + "/" + objectPattern
+}
+
+// PolicyBuilder builds the bucket policy of the common patterns.
+//
+//	doc, err := NewPolicyBuilder().
+//		PublicReadPrefix(bucketName, "static/").
+//		DenyInsecureTransport(bucketName).
+//		Build()
+//	...
+//	err = client.SetBucketPolicyDocument(bucketName, doc)
+type PolicyBuilder struct {
+	doc PolicyDocument
+}
+
+// NewPolicyBuilder creates a builder of the policy version 1
+func NewPolicyBuilder() *PolicyBuilder {
+	return &PolicyBuilder{doc: PolicyDocument{Version: "1"}}
+}
+
+// Statement adds a statement
+func (b *PolicyBuilder) Statement(statement PolicyStatement) *PolicyBuilder {
+	b.doc.Statement = append(b.doc.Statement, statement)
+	return b
+}
+
+// PublicReadPrefix allows everyone to get the objects with the prefix and list them
+func (b *PolicyBuilder) PublicReadPrefix(bucketName, prefix string) *PolicyBuilder {
+	b.Statement(PolicyStatement{
+		Effect:    PolicyEffectAllow,
+		Principal: []string{"*"},
+		Action:    []string{"oss:GetObject"},
+		Resource:  []string{PolicyResource(bucketName, prefix+"*")},
+	})
+	return b.Statement(PolicyStatement{
+		Effect:    PolicyEffectAllow,
+		Principal: []string{"*"},
+		Action:    []string{"oss:ListObjects"},
+		Resource:  []string{PolicyResource(bucketName, "")},
+		Condition: map[string]map[string][]string{"StringLike": {PolicyKeyPrefix: {prefix + "*"}}},
+	})
+}
+
+// VpcOnly denies the access to the bucket and the objects from outside of the VPCs
+func (b *PolicyBuilder) VpcOnly(bucketName string, vpcIDs ...string) *PolicyBuilder {
+	return b.Statement(PolicyStatement{
+		Effect:    PolicyEffectDeny,
+		Principal: []string{"*"},
+		Action:    []string{"oss:*"},
+		Resource:  []string{PolicyResource(bucketName, ""), PolicyResource(bucketName, "*")},
+		Condition: map[string]map[string][]string{"StringNotEquals": {PolicyKeySourceVpc: vpcIDs}},
+	})
+}
+
+// DenyInsecureTransport denies the access to the bucket and the objects by HTTP
+func (b *PolicyBuilder) DenyInsecureTransport(bucketName string) *PolicyBuilder {
+	return b.Statement(PolicyStatement{
+		Effect:    PolicyEffectDeny,
+		Principal: []string{"*"},
+		Action:    []string{"oss:*"},
+		Resource:  []string{PolicyResource(bucketName, ""), PolicyResource(bucketName, "*")},
+		Condition: map[string]map[string][]string{"Bool": {PolicyKeySecureTransport: {"false"}}},
+	})
+}
+
+// Build validates and returns the policy
+func (b *PolicyBuilder) Build() (PolicyDocument, error) {
+	if err := b.doc.Validate(); err != nil {
+		return PolicyDocument{}, err
+	}
+	return b.doc, nil
+}
+
+// GetBucketPolicyDocument gets the policy of the bucket and decodes it
+//
+// bucketName    the bucket name.
+//
+// PolicyDocument    the policy, it's only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func (client Client) GetBucketPolicyDocument(bucketName string, options ...Option) (PolicyDocument, error) {
+	policy, err := client.GetBucketPolicy(bucketName, options...)
+	if err != nil {
+		return PolicyDocument{}, err
+	}
+	return ParsePolicy(policy)
+}
+
+// SetBucketPolicyDocument encodes the policy and sets it to the bucket
+//
+// bucketName    the bucket name.
+// doc    the policy.
+//
+// error    it's nil if no error, otherwise it's an error object.
+func (client Client) SetBucketPolicyDocument(bucketName string, doc PolicyDocument, options ...Option) error {
+	policy, err := doc.JSON()
+	if err != nil {
+		return err
+	}
+	return client.SetBucketPolicy(bucketName, policy, options...)
+}
@@ -0,0 +1,284 @@
+package oss
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PolicyDecision is the result of evaluating a policy
+type PolicyDecision string
+
+const (
+	// PolicyAllowed an Allow statement matches and no Deny statement matches
+	PolicyAllowed PolicyDecision = "Allowed"
+
+	// PolicyExplicitDenied a Deny statement matches
+	PolicyExplicitDenied PolicyDecision = "ExplicitDenied"
+
+	// PolicyImplicitDenied no statement matches
+	PolicyImplicitDenied PolicyDecision = "ImplicitDenied"
+)
+
+// PolicyRequest is the request evaluated by PolicyDocument.Evaluate
+type PolicyRequest struct {
+	Principal string            // The account or RAM user ID, empty means anonymous, which only matches *
+	Action    string            // The action, such as oss:GetObject
+	Resource  string            // The resource, such as PolicyResource(bucketName, objectKey)
+	Context   map[string]string // The condition keys, such as PolicyKeySourceIP: 192.168.0.1 or PolicyKeySecureTransport: true
+}
+
+// PolicyEvaluation is the result of PolicyDocument.Evaluate
+type PolicyEvaluation struct {
+	Decision  PolicyDecision
+	Statement int // The index of the statement deciding the result, -1 for PolicyImplicitDenied
+}
+
+// policyOperatorSpec is a condition operator, the IfExists suffix matches when the key is missing
+type policyOperatorSpec struct {
+	base     string
+	negate   bool
+	ifExists bool
+}
+
+var policyBaseOperators = map[string]bool{
+	"StringEquals": true, "StringNotEquals": true, "StringEqualsIgnoreCase": true, "StringNotEqualsIgnoreCase": true,
+	"StringLike": true, "StringNotLike": true,
+	"NumericEquals": true, "NumericNotEquals": true, "NumericLessThan": true, "NumericLessThanEquals": true,
+	"NumericGreaterThan": true, "NumericGreaterThanEquals": true,
+	"DateEquals": true, "DateNotEquals": true, "DateLessThan": true, "DateLessThanEquals": true,
+	"DateGreaterThan": true, "DateGreaterThanEquals": true,
+	"Bool": true, "IpAddress": true, "NotIpAddress": true,
+}
+
+func policyOperator(name string) (policyOperatorSpec, bool) {
+	spec := policyOperatorSpec{base: name}
+	if strings.HasSuffix(name, "IfExists") {
+		spec.base, spec.ifExists = strings.TrimSuffix(name, "IfExists"), true
+	}
+	if !policyBaseOperators[spec.base] {
+		return spec, false
+	}
+	spec.negate = strings.Contains(spec.base, "Not")
+	return spec, true
+}
+
+// Evaluate evaluates the policy offline, it's useful for the unit tests of the policies.
+// A matched Deny statement wins, then a matched Allow statement, otherwise the request is implicitly denied.
+// The policy of the bucket is only a part of the permission, the RAM policies and the ACL are not evaluated.
+//
+// req    the request.
+//
+// PolicyEvaluation    the decision and the statement deciding it.
+// error    it's nil if no error, otherwise it's an invalid condition in the policy or the context.
+func (doc PolicyDocument) Evaluate(req PolicyRequest) (PolicyEvaluation, error) {
+	result := PolicyEvaluation{Decision: PolicyImplicitDenied, Statement: -1}
+	for i, s := range doc.Statement {
+		matched, err := s.matches(req)
+		if err != nil {
+			return PolicyEvaluation{}, fmt.Errorf("oss: policy statement #%d: %s", i, err.Error())
+		}
+		if !matched {
+			continue
+		}
+		if s.Effect == PolicyEffectDeny {
+			return PolicyEvaluation{Decision: PolicyExplicitDenied, Statement: i}, nil
+		}
+		if s.Effect == PolicyEffectAllow && result.Decision == PolicyImplicitDenied {
+			result = PolicyEvaluation{Decision: PolicyAllowed, Statement: i}
+		}
+	}
+	return result, nil
+}
+
+// IsAllowed checks if the policy allows the request, see Evaluate
+func (doc PolicyDocument) IsAllowed(req PolicyRequest) (bool, error) {
+	result, err := doc.Evaluate(req)
+	return result.Decision == PolicyAllowed, err
+}
+
+func (s PolicyStatement) matches(req PolicyRequest) (bool, error) {
+	if len(s.Principal) > 0 && !matchPrincipal(s.Principal, req.Principal) {
+		return false, nil
+	}
+	if len(s.Action) > 0 && !matchPolicyPatterns(s.Action, req.Action, true) {
+		return false, nil
+	}
+	if len(s.NotAction) > 0 && matchPolicyPatterns(s.NotAction, req.Action, true) {
+		return false, nil
+	}
+	if len(s.Resource) > 0 && !matchPolicyPatterns(s.Resource, req.Resource, false) {
+		return false, nil
+	}
+	if len(s.NotResource) > 0 && matchPolicyPatterns(s.NotResource, req.Resource, false) {
+		return false, nil
+	}
+
+	for operator, keys := range s.Condition {
+		spec, ok := policyOperator(operator)
+		if !ok {
+			return false, fmt.Errorf("unsupported condition operator %s", operator)
+		}
+		for key, values := range keys {
+			matched, err := matchPolicyCondition(spec, lookupPolicyContext(req.Context, key), values)
+			if err != nil {
+				return false, fmt.Errorf("condition %s %s: %s", operator, key, err.Error())
+			}
+			if !matched {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+func matchPrincipal(principals []string, principal string) bool {
+	for _, p := range principals {
+		if p == "*" || (principal != "" && matchWildcard(p, principal, false)) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchPolicyPatterns(patterns []string, value string, ignoreCase bool) bool {
+	for _, pattern := range patterns {
+		if matchWildcard(pattern, value, ignoreCase) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupPolicyContext finds the condition key case insensitively, nil means missing
+func lookupPolicyContext(context map[string]string, key string) *string {
+	for k, v := range context {
+		if strings.EqualFold(k, key) {
+			value := v
+			return &value
+		}
+	}
+	return nil
+}
+
+// matchPolicyCondition checks if the value matches any of the condition values, the negated operators match none of them.
+// A missing key only matches the negated operators and the IfExists operators.
+func matchPolicyCondition(spec policyOperatorSpec, value *string, conditionValues []string) (bool, error) {
+	if value == nil {
+		return spec.negate || spec.ifExists, nil
+	}
+
+	for _, conditionValue := range conditionValues {
+		matched, err := matchPolicyValue(spec.base, *value, conditionValue)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			return !spec.negate, nil
+		}
+	}
+	return spec.negate, nil
+}
+
+// matchPolicyValue compares a value by the operator, the negated operators compare as the positive ones
+func matchPolicyValue(operator, value, conditionValue string) (bool, error) {
+	switch operator {
+	case "StringEquals", "StringNotEquals":
+		return value == conditionValue, nil
+	case "StringEqualsIgnoreCase", "StringNotEqualsIgnoreCase":
+		return strings.EqualFold(value, conditionValue), nil
+	case "StringLike", "StringNotLike":
+		return matchWildcard(conditionValue, value, false), nil
+	case "Bool":
+		return strings.EqualFold(value, conditionValue), nil
+	case "IpAddress", "NotIpAddress":
+		return matchIPAddress(value, conditionValue)
+	}
+
+	if strings.HasPrefix(operator, "Numeric") {
+		a, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return false, fmt.Errorf("invalid number %s", value)
+		}
+		b, err := strconv.ParseFloat(conditionValue, 64)
+		if err != nil {
+			return false, fmt.Errorf("invalid number %s", conditionValue)
+		}
+		return compareByOperator(strings.TrimPrefix(operator, "Numeric"), a-b), nil
+	}
+
+	a, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return false, fmt.Errorf("invalid date %s", value)
+	}
+	b, err := time.Parse(time.RFC3339, conditionValue)
+	if err != nil {
+		return false, fmt.Errorf("invalid date %s", conditionValue)
+	}
+	return compareByOperator(strings.TrimPrefix(operator, "Date"), float64(a.Sub(b))), nil
+}
+
+func compareByOperator(comparison string, diff float64) bool {
+	switch comparison {
+	case "LessThan":
+		return diff < 0
+	case "LessThanEquals":
+		return diff <= 0
+	case "GreaterThan":
+		return diff > 0
+	case "GreaterThanEquals":
+		return diff >= 0
+	}
+	// Equals and NotEquals
+	return diff == 0
+}
+
+// matchIPAddress checks if the IP is in the CIDR or equals to the IP
+func matchIPAddress(value, conditionValue string) (bool, error) {
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return false, fmt.Errorf("invalid IP %s", value)
+	}
+	if strings.Contains(conditionValue, "/") {
+		_, network, err := net.ParseCIDR(conditionValue)
+		if err != nil {
+			return false, fmt.Errorf("invalid CIDR %s", conditionValue)
+		}
+		return network.Contains(ip), nil
+	}
+	conditionIP := net.ParseIP(conditionValue)
+	if conditionIP == nil {
+		return false, fmt.Errorf("invalid IP %s", conditionValue)
+	}
+	return conditionIP.Equal(ip), nil
+}
+
+// matchWildcard matches the value by the pattern, * matches any characters including / and ? matches one character
+func matchWildcard(pattern, value string, ignoreCase bool) bool {
+	if ignoreCase {
+		pattern, value = strings.ToLower(pattern), strings.ToLower(value)
+	}
+	p, v := 0, 0
+	starP, starV := -1, 0
+	for v < len(value) {
+		switch {
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == value[v]):
+			p++
+			v++
+		case p < len(pattern) && pattern[p] == '*':
+			starP, starV = p, v
+			p++
+		case starP != -1:
+			starV++
+			p, v = starP+1, starV
+		default:
+			return false
+		}
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
@@ -0,0 +1,187 @@
+package oss
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	. "gopkg.in/check.v1"
+)
+
+type OssPolicySuite struct{}
+
+var _ = Suite(&OssPolicySuite{})
+
+func (s *OssPolicySuite) TestPolicyRoundTrip(c *C) {
+	policy := `{"Version":"1","Statement":[{"Sid":"read","Effect":"Allow","Principal":"*","Action":["oss:GetObject","oss:ListObjects"],` +
+		`"Resource":"acs:oss:*:*:bucket/*","Condition":{"Bool":{"acs:SecureTransport":true},"IpAddress":{"acs:SourceIp":["10.0.0.0/8","192.168.1.1"]}}},` +
+		`{"Effect":"Deny","Principal":{"RAM":["acs:ram::123:root"]},"NotAction":["oss:Get*"],"NotResource":["acs:oss:*:*:bucket/public/*"]}]}`
+
+	doc, err := ParsePolicy(policy)
+	c.Assert(err, IsNil)
+	c.Assert(doc.Version, Equals, "1")
+	c.Assert(len(doc.Statement), Equals, 2)
+	c.Assert(doc.Statement[0].Principal, DeepEquals, []string{"*"})
+	c.Assert(doc.Statement[0].Resource, DeepEquals, []string{"acs:oss:*:*:bucket/*"})
+	c.Assert(doc.Statement[0].Condition["Bool"]["acs:SecureTransport"], DeepEquals, []string{"true"})
+	c.Assert(doc.Statement[0].Condition["IpAddress"]["acs:SourceIp"], DeepEquals, []string{"10.0.0.0/8", "192.168.1.1"})
+	c.Assert(doc.Statement[1].Principal, DeepEquals, []string{"acs:ram::123:root"})
+	c.Assert(doc.Validate(), IsNil)
+
+	// The unchanged values keep their forms
+	out, err := doc.JSON()
+	c.Assert(err, IsNil)
+	c.Assert(jsonEquals(c, out, policy), Equals, true)
+
+	// The changed values are arrays
+	doc.Statement[0].Resource = append(doc.Statement[0].Resource, "acs:oss:*:*:bucket")
+	doc.Statement[0].Condition["Bool"]["acs:SecureTransport"] = []string{"false"}
+	out, err = doc.JSON()
+	c.Assert(err, IsNil)
+	var decoded map[string]interface{}
+	c.Assert(json.Unmarshal([]byte(out), &decoded), IsNil)
+	statement := decoded["Statement"].([]interface{})[0].(map[string]interface{})
+	c.Assert(statement["Principal"], Equals, "*")
+	c.Assert(statement["Resource"], DeepEquals, []interface{}{"acs:oss:*:*:bucket/*", "acs:oss:*:*:bucket"})
+	c.Assert(statement["Condition"].(map[string]interface{})["Bool"], DeepEquals, map[string]interface{}{"acs:SecureTransport": []interface{}{"false"}})
+
+	_, err = ParsePolicy(`{"Version":"1","Statement":[{"Effect":"Allow","Action":[{"a":1}]}]}`)
+	c.Assert(err, NotNil)
+	doc.Statement[1].Effect = "allow"
+	c.Assert(doc.Validate(), NotNil)
+	c.Assert(PolicyDocument{Version: "1"}.Validate(), NotNil)
+}
+
+func jsonEquals(c *C, a, b string) bool {
+	var va, vb interface{}
+	c.Assert(json.Unmarshal([]byte(a), &va), IsNil)
+	c.Assert(json.Unmarshal([]byte(b), &vb), IsNil)
+	ja, _ := json.Marshal(va)
+	jb, _ := json.Marshal(vb)
+	return string(ja) == string(jb)
+}
+
+func (s *OssPolicySuite) TestPolicyBuilderAndEvaluate(c *C) {
+	doc, err := NewPolicyBuilder().
+		PublicReadPrefix("bucket", "static/").
+		VpcOnly("bucket", "vpc-1", "vpc-2").
+		Build()
+	c.Assert(err, IsNil)
+
+	vpc := map[string]string{PolicyKeySourceVpc: "vpc-1"}
+	cases := []struct {
+		req      PolicyRequest
+		decision PolicyDecision
+	}{
+		{PolicyRequest{Action: "oss:GetObject", Resource: PolicyResource("bucket", "static/a.png"), Context: vpc}, PolicyAllowed},
+		{PolicyRequest{Action: "oss:getobject", Resource: PolicyResource("bucket", "static/a/b.png"), Context: vpc}, PolicyAllowed},
+		{PolicyRequest{Action: "oss:GetObject", Resource: PolicyResource("bucket", "private/a.png"), Context: vpc}, PolicyImplicitDenied},
+		{PolicyRequest{Action: "oss:PutObject", Resource: PolicyResource("bucket", "static/a.png"), Context: vpc}, PolicyImplicitDenied},
+		// Outside of the VPCs, the missing key matches StringNotEquals
+		{PolicyRequest{Action: "oss:GetObject", Resource: PolicyResource("bucket", "static/a.png")}, PolicyExplicitDenied},
+		{PolicyRequest{Action: "oss:GetObject", Resource: PolicyResource("bucket", "static/a.png"),
+			Context: map[string]string{"ACS:SourceVpc": "vpc-3"}}, PolicyExplicitDenied},
+		{PolicyRequest{Action: "oss:ListObjects", Resource: PolicyResource("bucket", ""),
+			Context: map[string]string{PolicyKeySourceVpc: "vpc-2", PolicyKeyPrefix: "static/img/"}}, PolicyAllowed},
+		{PolicyRequest{Action: "oss:ListObjects", Resource: PolicyResource("bucket", ""),
+			Context: map[string]string{PolicyKeySourceVpc: "vpc-2", PolicyKeyPrefix: "other/"}}, PolicyImplicitDenied},
+		{PolicyRequest{Action: "oss:GetObject", Resource: PolicyResource("other", "static/a.png"), Context: vpc}, PolicyImplicitDenied},
+	}
+	for i, t := range cases {
+		result, err := doc.Evaluate(t.req)
+		c.Assert(err, IsNil)
+		c.Assert(result.Decision, Equals, t.decision, Commentf("case %d", i))
+	}
+	result, _ := doc.Evaluate(cases[4].req)
+	c.Assert(result.Statement, Equals, 2)
+
+	// Principals, IP, numeric, date and IfExists conditions
+	doc = PolicyDocument{Version: "1", Statement: []PolicyStatement{
+		{
+			Effect:    PolicyEffectAllow,
+			Principal: []string{"123", "456*"},
+			Action:    []string{"oss:*"},
+			Resource:  []string{"acs:oss:*:*:bucket/*"},
+			Condition: map[string]map[string][]string{
+				"IpAddress":             {PolicyKeySourceIP: {"10.0.0.0/8", "192.168.1.1"}},
+				"DateLessThan":          {PolicyKeyCurrentTime: {"2030-01-01T00:00:00Z"}},
+				"NumericLessThanEquals": {"oss:MaxKeys": {"100"}},
+				"StringLikeIfExists":    {PolicyKeyReferer: {"https://*.example.com/*"}},
+			},
+		},
+		{
+			Effect:    PolicyEffectDeny,
+			Action:    []string{"oss:DeleteObject"},
+			Resource:  []string{"*"},
+			Principal: []string{"*"},
+		},
+	}}
+	context := map[string]string{PolicyKeySourceIP: "10.1.2.3", PolicyKeyCurrentTime: "2024-01-01T00:00:00Z", "oss:MaxKeys": "100"}
+	req := PolicyRequest{Principal: "4567", Action: "oss:GetObject", Resource: "acs:oss:*:*:bucket/a", Context: context}
+	allowed, err := doc.IsAllowed(req)
+	c.Assert(err, IsNil)
+	c.Assert(allowed, Equals, true)
+
+	req.Action = "oss:DeleteObject"
+	allowed, _ = doc.IsAllowed(req)
+	c.Assert(allowed, Equals, false)
+	req.Action = "oss:GetObject"
+
+	for _, change := range []map[string]string{
+		{PolicyKeySourceIP: "11.0.0.1"},
+		{PolicyKeyCurrentTime: "2031-01-01T00:00:00Z"},
+		{"oss:MaxKeys": "1000"},
+		{PolicyKeyReferer: "https://evil.com/"},
+	} {
+		changed := map[string]string{}
+		for k, v := range context {
+			changed[k] = v
+		}
+		for k, v := range change {
+			changed[k] = v
+		}
+		req.Context = changed
+		allowed, err = doc.IsAllowed(req)
+		c.Assert(err, IsNil)
+		c.Assert(allowed, Equals, false, Commentf("%v", change))
+	}
+
+	req.Context = context
+	req.Principal = ""
+	allowed, _ = doc.IsAllowed(req)
+	c.Assert(allowed, Equals, false)
+
+	req.Principal = "123"
+	req.Context = map[string]string{PolicyKeySourceIP: "not-ip", PolicyKeyCurrentTime: "2024-01-01T00:00:00Z", "oss:MaxKeys": "1"}
+	_, err = doc.Evaluate(req)
+	c.Assert(err, NotNil)
+}
+
+func (s *OssPolicySuite) TestBucketPolicyDocument(c *C) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.URL.RawQuery, Equals, "policy")
+		if r.Method == "PUT" {
+			data, _ := ioutil.ReadAll(r.Body)
+			body = string(data)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	client, err := New(server.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+	doc, err := NewPolicyBuilder().DenyInsecureTransport("bucket").Build()
+	c.Assert(err, IsNil)
+	c.Assert(client.SetBucketPolicyDocument("bucket", doc), IsNil)
+
+	got, err := client.GetBucketPolicyDocument("bucket")
+	c.Assert(err, IsNil)
+	c.Assert(got.Statement[0].Condition["Bool"][PolicyKeySecureTransport], DeepEquals, []string{"false"})
+	allowed, err := got.IsAllowed(PolicyRequest{Action: "oss:GetObject", Resource: PolicyResource("bucket", "a"),
+		Context: map[string]string{PolicyKeySecureTransport: "false"}})
+	c.Assert(err, IsNil)
+	c.Assert(allowed, Equals, false)
+}
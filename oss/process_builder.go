@@ -0,0 +1,418 @@
+package oss
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Resize modes of image/resize
+const (
+	ResizeModeLfit  = "lfit"
+	ResizeModeMfit  = "mfit"
+	ResizeModeFill  = "fill"
+	ResizeModePad   = "pad"
+	ResizeModeFixed = "fixed"
+)
+
+// Positions of crop and watermark
+const (
+	GravityNorthWest = "nw"
+	GravityNorth     = "north"
+	GravityNorthEast = "ne"
+	GravityWest      = "west"
+	GravityCenter    = "center"
+	GravityEast      = "east"
+	GravitySouthWest = "sw"
+	GravitySouth     = "south"
+	GravitySouthEast = "se"
+)
+
+var (
+	validResizeModes  = []string{ResizeModeLfit, ResizeModeMfit, ResizeModeFill, ResizeModePad, ResizeModeFixed}
+	validGravities    = []string{GravityNorthWest, GravityNorth, GravityNorthEast, GravityWest, GravityCenter, GravityEast, GravitySouthWest, GravitySouth, GravitySouthEast}
+	validImageFormats = []string{"jpg", "png", "webp", "bmp", "gif", "tiff", "heic", "avif"}
+)
+
+// ResizeOptions defines the parameters of image/resize, the zero values are not sent
+type ResizeOptions struct {
+	Mode       string // Resize mode, such as ResizeModeLfit
+	Width      int    // Target width, 1-16384
+	Height     int    // Target height, 1-16384
+	Long       int    // Target length of the long side, 1-16384
+	Short      int    // Target length of the short side, 1-16384
+	Percentage int    // Resize by percentage, 1-1000
+	NoLimit    bool   // Allow the target to be larger than the source image
+	Color      string // Fill color of pad mode, such as FFFFFF
+}
+
+// TextWatermark defines the parameters of a text watermark
+type TextWatermark struct {
+	Text         string // Watermark text, at most 64 characters
+	Font         string // Font name, such as wqy-zenhei
+	Color        string // Text color, such as 000000
+	Size         int    // Font size, 1-1000
+	Position     string // Position, such as GravitySouthEast
+	X            int    // Horizontal margin, 0-4096
+	Y            int    // Vertical margin, 0-4096
+	Transparency int    // Opacity percentage, 0-100, 0 means not set
+}
+
+// ImageWatermark defines the parameters of an image watermark
+type ImageWatermark struct {
+	Object       string // Object key of the watermark image in the same bucket, such as panda.png?x-oss-process=image/resize,P_30
+	Position     string // Position, such as GravitySouthEast
+	X            int    // Horizontal margin, 0-4096
+	Y            int    // Vertical margin, 0-4096
+	Transparency int    // Opacity percentage, 0-100, 0 means not set
+}
+
+// VideoSnapshotOptions defines the parameters of video/snapshot
+type VideoSnapshotOptions struct {
+	Time   int64  // Snapshot time in milliseconds
+	Width  int    // Snapshot width, 0 means auto
+	Height int    // Snapshot height, 0 means auto
+	Format string // jpg or png
+	Fast   bool   // Take the nearest key frame before Time
+}
+
+// ProcessBuilder builds the x-oss-process string used by Process, ProcessObject and AsyncProcessObject.
+// The parameters are validated when they are added and the first error is returned by Build.
+type ProcessBuilder struct {
+	kind   string
+	steps  []string
+	saveAs string
+	err    error
+}
+
+// NewImageProcess creates a builder of image processing, such as image/resize,w_100/rotate,90
+func NewImageProcess() *ProcessBuilder {
+	return &ProcessBuilder{kind: "image"}
+}
+
+// NewStyleProcess creates a builder which references a style created by PutBucketStyle, such as style/small
+func NewStyleProcess(styleName string) *ProcessBuilder {
+	p := &ProcessBuilder{kind: "style"}
+	if styleName == "" {
+		p.err = fmt.Errorf("oss: style name is empty")
+	}
+	p.steps = append(p.steps, styleName)
+	return p
+}
+
+// NewVideoSnapshot creates a builder of video snapshot, such as video/snapshot,t_7000,f_jpg,w_800,h_600,m_fast
+func NewVideoSnapshot(options VideoSnapshotOptions) *ProcessBuilder {
+	p := &ProcessBuilder{kind: "video"}
+	if options.Time < 0 {
+		p.setErr(fmt.Errorf("oss: invalid snapshot time %d", options.Time))
+	}
+	if options.Width != 0 {
+		p.checkRange("w", options.Width, 1, 16384)
+	}
+	if options.Height != 0 {
+		p.checkRange("h", options.Height, 1, 16384)
+	}
+	if options.Format != "jpg" && options.Format != "png" {
+		p.setErr(fmt.Errorf("oss: invalid snapshot format %s, valid formats are jpg, png", options.Format))
+	}
+
+	params := []string{"snapshot", "t_" + strconv.FormatInt(options.Time, 10), "f_" + options.Format}
+	if options.Width != 0 {
+		params = append(params, "w_"+strconv.Itoa(options.Width))
+	}
+	if options.Height != 0 {
+		params = append(params, "h_"+strconv.Itoa(options.Height))
+	}
+	if options.Fast {
+		params = append(params, "m_fast")
+	}
+	p.steps = append(p.steps, strings.Join(params, ","))
+	return p
+}
+
+// Resize adds image/resize
+func (p *ProcessBuilder) Resize(options ResizeOptions) *ProcessBuilder {
+	if !p.checkKind("resize") {
+		return p
+	}
+	params := []string{"resize"}
+	if options.Mode != "" {
+		p.checkEnum("resize mode", options.Mode, validResizeModes)
+		params = append(params, "m_"+options.Mode)
+	}
+	for _, v := range []struct {
+		name  string
+		value int
+	}{{"w", options.Width}, {"h", options.Height}, {"l", options.Long}, {"s", options.Short}} {
+		if v.value != 0 {
+			p.checkRange(v.name, v.value, 1, 16384)
+			params = append(params, v.name+"_"+strconv.Itoa(v.value))
+		}
+	}
+	if options.Percentage != 0 {
+		p.checkRange("p", options.Percentage, 1, 1000)
+		params = append(params, "p_"+strconv.Itoa(options.Percentage))
+	}
+	if len(params) == 1 || (len(params) == 2 && options.Mode != "") {
+		p.setErr(fmt.Errorf("oss: resize requires a width, height, long, short or percentage"))
+	}
+	if options.NoLimit {
+		params = append(params, "limit_0")
+	}
+	if options.Color != "" {
+		p.checkColor(options.Color)
+		params = append(params, "color_"+options.Color)
+	}
+	p.steps = append(p.steps, strings.Join(params, ","))
+	return p
+}
+
+// Crop adds image/crop, width or height 0 means cropping to the edge of the image
+func (p *ProcessBuilder) Crop(x, y, width, height int, gravity string) *ProcessBuilder {
+	if !p.checkKind("crop") {
+		return p
+	}
+	p.checkRange("x", x, 0, 16384)
+	p.checkRange("y", y, 0, 16384)
+	params := []string{"crop", "x_" + strconv.Itoa(x), "y_" + strconv.Itoa(y)}
+	if width != 0 {
+		p.checkRange("w", width, 1, 16384)
+		params = append(params, "w_"+strconv.Itoa(width))
+	}
+	if height != 0 {
+		p.checkRange("h", height, 1, 16384)
+		params = append(params, "h_"+strconv.Itoa(height))
+	}
+	if gravity != "" {
+		p.checkEnum("gravity", gravity, validGravities)
+		params = append(params, "g_"+gravity)
+	}
+	p.steps = append(p.steps, strings.Join(params, ","))
+	return p
+}
+
+// Rotate adds image/rotate, degrees is 0-360 clockwise
+func (p *ProcessBuilder) Rotate(degrees int) *ProcessBuilder {
+	if !p.checkKind("rotate") {
+		return p
+	}
+	p.checkRange("rotate", degrees, 0, 360)
+	p.steps = append(p.steps, "rotate,"+strconv.Itoa(degrees))
+	return p
+}
+
+// AutoOrient adds image/auto-orient, which rotates the image according to its EXIF orientation
+func (p *ProcessBuilder) AutoOrient(enabled bool) *ProcessBuilder {
+	if !p.checkKind("auto-orient") {
+		return p
+	}
+	if enabled {
+		p.steps = append(p.steps, "auto-orient,1")
+	} else {
+		p.steps = append(p.steps, "auto-orient,0")
+	}
+	return p
+}
+
+// Blur adds image/blur, radius and sigma are 1-50
+func (p *ProcessBuilder) Blur(radius, sigma int) *ProcessBuilder {
+	if !p.checkKind("blur") {
+		return p
+	}
+	p.checkRange("r", radius, 1, 50)
+	p.checkRange("s", sigma, 1, 50)
+	p.steps = append(p.steps, fmt.Sprintf("blur,r_%d,s_%d", radius, sigma))
+	return p
+}
+
+// Quality adds image/quality, quality is 1-100. The relative quality q_ is used unless absolute is true.
+func (p *ProcessBuilder) Quality(quality int, absolute bool) *ProcessBuilder {
+	if !p.checkKind("quality") {
+		return p
+	}
+	p.checkRange("quality", quality, 1, 100)
+	if absolute {
+		p.steps = append(p.steps, "quality,Q_"+strconv.Itoa(quality))
+	} else {
+		p.steps = append(p.steps, "quality,q_"+strconv.Itoa(quality))
+	}
+	return p
+}
+
+// Format adds image/format, such as jpg, png and webp
+func (p *ProcessBuilder) Format(format string) *ProcessBuilder {
+	if !p.checkKind("format") {
+		return p
+	}
+	p.checkEnum("format", format, validImageFormats)
+	p.steps = append(p.steps, "format,"+format)
+	return p
+}
+
+// Info adds image/info, which returns the image information in JSON
+func (p *ProcessBuilder) Info() *ProcessBuilder {
+	if !p.checkKind("info") {
+		return p
+	}
+	p.steps = append(p.steps, "info")
+	return p
+}
+
+// TextWatermark adds a text watermark
+func (p *ProcessBuilder) TextWatermark(watermark TextWatermark) *ProcessBuilder {
+	if !p.checkKind("watermark") {
+		return p
+	}
+	if watermark.Text == "" || len([]rune(watermark.Text)) > 64 {
+		p.setErr(fmt.Errorf("oss: watermark text must be 1-64 characters"))
+	}
+	params := []string{"watermark", "text_" + processBase64(watermark.Text)}
+	if watermark.Font != "" {
+		params = append(params, "type_"+processBase64(watermark.Font))
+	}
+	if watermark.Color != "" {
+		p.checkColor(watermark.Color)
+		params = append(params, "color_"+watermark.Color)
+	}
+	if watermark.Size != 0 {
+		p.checkRange("size", watermark.Size, 1, 1000)
+		params = append(params, "size_"+strconv.Itoa(watermark.Size))
+	}
+	params = append(params, p.watermarkPosition(watermark.Position, watermark.X, watermark.Y, watermark.Transparency)...)
+	p.steps = append(p.steps, strings.Join(params, ","))
+	return p
+}
+
+// ImageWatermark adds an image watermark
+func (p *ProcessBuilder) ImageWatermark(watermark ImageWatermark) *ProcessBuilder {
+	if !p.checkKind("watermark") {
+		return p
+	}
+	if watermark.Object == "" {
+		p.setErr(fmt.Errorf("oss: watermark object is empty"))
+	}
+	params := []string{"watermark", "image_" + processBase64(watermark.Object)}
+	params = append(params, p.watermarkPosition(watermark.Position, watermark.X, watermark.Y, watermark.Transparency)...)
+	p.steps = append(p.steps, strings.Join(params, ","))
+	return p
+}
+
+// SaveAs saves the processed result to another object by sys/saveas, it's only used by ProcessObject and AsyncProcessObject.
+//
+// bucketName    the target bucket, empty means the source bucket.
+// objectKey    the target object key.
+func (p *ProcessBuilder) SaveAs(bucketName, objectKey string) *ProcessBuilder {
+	if objectKey == "" {
+		p.setErr(fmt.Errorf("oss: saveas object key is empty"))
+	}
+	saveAs := "sys/saveas,o_" + processBase64(objectKey)
+	if bucketName != "" {
+		saveAs += ",b_" + processBase64(bucketName)
+	}
+	p.saveAs = saveAs
+	return p
+}
+
+// Build validates the parameters and renders the process string.
+//
+// string    the process string, such as image/resize,w_100|sys/saveas,o_dGVzdC5qcGc,b_dGVzdA
+// error    it's nil if no error, otherwise it's an error object.
+func (p *ProcessBuilder) Build() (string, error) {
+	if p.err != nil {
+		return "", p.err
+	}
+	if len(p.steps) == 0 {
+		return "", fmt.Errorf("oss: process has no operation")
+	}
+	process := p.kind + "/" + strings.Join(p.steps, "/")
+	if p.saveAs != "" {
+		process += "|" + p.saveAs
+	}
+	return process, nil
+}
+
+// StyleContent renders the content used by PutBucketStyle, such as image/resize,p_50
+func (p *ProcessBuilder) StyleContent() (string, error) {
+	if p.kind != "image" {
+		return "", fmt.Errorf("oss: style content only supports image processing")
+	}
+	if p.saveAs != "" {
+		return "", fmt.Errorf("oss: style content does not support saveas")
+	}
+	return p.Build()
+}
+
+// String renders the process string without validation
+func (p *ProcessBuilder) String() string {
+	process := p.kind + "/" + strings.Join(p.steps, "/")
+	if p.saveAs != "" {
+		process += "|" + p.saveAs
+	}
+	return process
+}
+
+func (p *ProcessBuilder) watermarkPosition(position string, x, y, transparency int) []string {
+	params := []string{}
+	if position != "" {
+		p.checkEnum("watermark position", position, validGravities)
+		params = append(params, "g_"+position)
+	}
+	if x != 0 {
+		p.checkRange("x", x, 0, 4096)
+		params = append(params, "x_"+strconv.Itoa(x))
+	}
+	if y != 0 {
+		p.checkRange("y", y, 0, 4096)
+		params = append(params, "y_"+strconv.Itoa(y))
+	}
+	if transparency != 0 {
+		p.checkRange("t", transparency, 0, 100)
+		params = append(params, "t_"+strconv.Itoa(transparency))
+	}
+	return params
+}
+
+func (p *ProcessBuilder) checkKind(operation string) bool {
+	if p.kind != "image" {
+		p.setErr(fmt.Errorf("oss: %s is not supported by %s process", operation, p.kind))
+		return false
+	}
+	return true
+}
+
+func (p *ProcessBuilder) checkRange(name string, value, min, max int) {
+	if value < min || value > max {
+		p.setErr(fmt.Errorf("oss: invalid process parameter %s: %d, valid range is %d-%d", name, value, min, max))
+	}
+}
+
+func (p *ProcessBuilder) checkEnum(name, value string, valid []string) {
+	for _, v := range valid {
+		if v == value {
+			return
+		}
+	}
+	p.setErr(fmt.Errorf("oss: invalid %s %s, valid values are %s", name, value, strings.Join(valid, ", ")))
+}
+
+func (p *ProcessBuilder) checkColor(color string) {
+	if len(color) != 6 {
+		p.setErr(fmt.Errorf("oss: invalid color %s, it must be 6 hex digits", color))
+		return
+	}
+	if _, err := strconv.ParseUint(color, 16, 32); err != nil {
+		p.setErr(fmt.Errorf("oss: invalid color %s, it must be 6 hex digits", color))
+	}
+}
+
+func (p *ProcessBuilder) setErr(err error) {
+	if p.err == nil {
+		p.err = err
+	}
+}
+
+// processBase64 encodes the value in URL safe base64 without padding, as required by x-oss-process
+func processBase64(value string) string {
+	return strings.TrimRight(base64.URLEncoding.EncodeToString([]byte(value)), "=")
+}
@@ -0,0 +1,91 @@
+package oss
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type OssProcessBuilderSuite struct{}
+
+var _ = Suite(&OssProcessBuilderSuite{})
+
+func (s *OssProcessBuilderSuite) TestImageProcess(c *C) {
+	process, err := NewImageProcess().
+		AutoOrient(true).
+		Resize(ResizeOptions{Mode: ResizeModePad, Width: 100, Height: 80, Color: "FF00ff"}).
+		Crop(10, 20, 50, 0, GravityCenter).
+		Rotate(90).
+		Blur(3, 2).
+		Quality(90, false).
+		Format("webp").
+		Build()
+	c.Assert(err, IsNil)
+	c.Assert(process, Equals, "image/auto-orient,1/resize,m_pad,w_100,h_80,color_FF00ff/crop,x_10,y_20,w_50,g_center/rotate,90/blur,r_3,s_2/quality,q_90/format,webp")
+
+	// watermarks and saveas, the example of the OSS documents
+	process, err = NewImageProcess().
+		Resize(ResizeOptions{Percentage: 50}).
+		TextWatermark(TextWatermark{Text: "Hello World", Size: 30, Position: GravitySouthEast, X: 10, Y: 10}).
+		ImageWatermark(ImageWatermark{Object: "panda.png", Transparency: 50}).
+		SaveAs("test", "test.jpg").
+		Build()
+	c.Assert(err, IsNil)
+	c.Assert(process, Equals, "image/resize,p_50/watermark,text_SGVsbG8gV29ybGQ,size_30,g_se,x_10,y_10/watermark,image_cGFuZGEucG5n,t_50|sys/saveas,o_dGVzdC5qcGc,b_dGVzdA")
+
+	process, err = NewImageProcess().Info().SaveAs("", "info.json").Build()
+	c.Assert(err, IsNil)
+	c.Assert(process, Equals, "image/info|sys/saveas,o_aW5mby5qc29u")
+
+	content, err := NewImageProcess().Resize(ResizeOptions{Percentage: 50}).StyleContent()
+	c.Assert(err, IsNil)
+	c.Assert(content, Equals, "image/resize,p_50")
+	_, err = NewImageProcess().Resize(ResizeOptions{Percentage: 50}).SaveAs("", "a.jpg").StyleContent()
+	c.Assert(err, NotNil)
+}
+
+func (s *OssProcessBuilderSuite) TestStyleAndVideoProcess(c *C) {
+	process, err := NewStyleProcess("small").SaveAs("bucket", "small.jpg").Build()
+	c.Assert(err, IsNil)
+	c.Assert(process, Equals, "style/small|sys/saveas,o_c21hbGwuanBn,b_YnVja2V0")
+
+	process, err = NewVideoSnapshot(VideoSnapshotOptions{Time: 7000, Width: 800, Height: 600, Format: "jpg", Fast: true}).Build()
+	c.Assert(err, IsNil)
+	c.Assert(process, Equals, "video/snapshot,t_7000,f_jpg,w_800,h_600,m_fast")
+
+	_, err = NewVideoSnapshot(VideoSnapshotOptions{Time: 7000, Format: "gif"}).Build()
+	c.Assert(err, NotNil)
+	_, err = NewVideoSnapshot(VideoSnapshotOptions{Time: 7000, Format: "jpg"}).Rotate(90).Build()
+	c.Assert(err, NotNil)
+	_, err = NewStyleProcess("").Build()
+	c.Assert(err, NotNil)
+}
+
+func (s *OssProcessBuilderSuite) TestProcessValidation(c *C) {
+	invalid := []*ProcessBuilder{
+		NewImageProcess(),
+		NewImageProcess().Resize(ResizeOptions{}),
+		NewImageProcess().Resize(ResizeOptions{Mode: ResizeModeFill}),
+		NewImageProcess().Resize(ResizeOptions{Mode: "zoom", Width: 10}),
+		NewImageProcess().Resize(ResizeOptions{Width: 16385}),
+		NewImageProcess().Resize(ResizeOptions{Percentage: 1001}),
+		NewImageProcess().Resize(ResizeOptions{Width: 10, Color: "red"}),
+		NewImageProcess().Crop(-1, 0, 10, 10, ""),
+		NewImageProcess().Crop(0, 0, 10, 10, "top"),
+		NewImageProcess().Rotate(361),
+		NewImageProcess().Blur(0, 1),
+		NewImageProcess().Quality(101, true),
+		NewImageProcess().Format("jpeg"),
+		NewImageProcess().TextWatermark(TextWatermark{}),
+		NewImageProcess().TextWatermark(TextWatermark{Text: "a", Transparency: 101}),
+		NewImageProcess().ImageWatermark(ImageWatermark{}),
+		NewImageProcess().Info().SaveAs("bucket", ""),
+	}
+	for i, p := range invalid {
+		_, err := p.Build()
+		c.Assert(err, NotNil, Commentf("case %d: %s", i, p.String()))
+	}
+
+	// the first error is reported
+	_, err := NewImageProcess().Rotate(400).Format("jpeg").Build()
+	c.Assert(err, NotNil)
+	c.Assert(err.Error(), Matches, ".*rotate.*")
+}
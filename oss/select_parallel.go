@@ -0,0 +1,341 @@
+package oss
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strconv"
+)
+
+// selectSplitsPerRoutine is the number of tasks per routine, more tasks balance the load better
+// but the output of every task is buffered in memory until it's written, at most routines outputs are buffered.
+const selectSplitsPerRoutine = 4
+
+// selectTask is a range of the object selected by one SelectObject request
+type selectTask struct {
+	index      int
+	splitRange string // split-range, such as 0-9
+	lineRange  string // line-range, used when the object has only one split
+}
+
+// selectTaskResult is the output of a select task
+type selectTaskResult struct {
+	index   int
+	data    []byte
+	scanned int64
+}
+
+// SelectObjectParallel selects a CSV or JSON LINES object with concurrent SelectObject requests and writes the merged output to w.
+//
+// The select meta of the object is created if it's missing, then the object is partitioned by split range,
+// or by line range when the object has only one split. The output of every range is buffered in memory and written
+// in the object order, or in the completion order with SelectUnordered(true). No more range is started while routines
+// outputs are running or waiting to be written, so a slow range doesn't buffer the rest of the object. A range failed
+// by the network or a 5xx status is retried SelectSplitRetries times, which is 3 by default.
+//
+// key    the object key.
+// selectReq    the select request, the Range and SplitRange of the input must be empty and OutputHeader must not be set.
+// routines    the concurrent request count.
+// w    the writer of the merged output.
+// options    the options for SelectObject, plus Progress, SelectUnordered and SelectSplitRetries.
+//
+//	The progress is the scanned bytes of the completed ranges against the object size.
+//
+// error    it's nil if no error, otherwise it's an error object.
+func (bucket Bucket) SelectObjectParallel(key string, selectReq SelectRequest, routines int, w io.Writer, options ...Option) error {
+	tasks, total, err := bucket.selectTasks(key, selectReq, routines, options)
+	if err != nil {
+		return err
+	}
+	return bucket.runSelectTasks(key, selectReq, tasks, total, routines, w, nil, options)
+}
+
+// SelectRecordsParallel is the record iterator of SelectObjectParallel, the records are decoded as SelectRecords.
+// The BytesScanned of the stats is updated when a range completes.
+//
+// key    the object key.
+// selectReq    the select request, the same as SelectObjectParallel.
+// routines    the concurrent request count.
+// options    the options, the same as SelectObjectParallel.
+//
+// *SelectRecordReader    the record reader. It must be closed after the usage and only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func (bucket Bucket) SelectRecordsParallel(key string, selectReq SelectRequest, routines int, options ...Option) (*SelectRecordReader, error) {
+	tasks, total, err := bucket.selectTasks(key, selectReq, routines, options)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	r := newSelectRecordReader(selectReq, pr)
+	go func() {
+		err := bucket.runSelectTasks(key, selectReq, tasks, total, routines, pw, r, options)
+		pw.CloseWithError(err)
+	}()
+	return r, nil
+}
+
+// selectTasks creates the select meta and partitions the object
+func (bucket Bucket) selectTasks(key string, selectReq SelectRequest, routines int, options []Option) ([]selectTask, int64, error) {
+	if routines < 1 {
+		return nil, 0, fmt.Errorf("oss: invalid routines %d", routines)
+	}
+
+	isJSON := !selectReq.InputSerializationSelect.JsonBodyInput.JsonIsEmpty()
+	metaOptions := DeleteOption(options, progressListener)
+	var splits int32
+	var rows, total int64
+	if isJSON {
+		input := selectReq.InputSerializationSelect.JsonBodyInput
+		if input.Range != "" || input.SplitRange != "" {
+			return nil, 0, fmt.Errorf("oss: the range of SelectObjectParallel must be empty")
+		}
+		if input.JSONType != "LINES" {
+			return nil, 0, fmt.Errorf("oss: SelectObjectParallel only supports JSON LINES")
+		}
+		meta, err := bucket.CreateSelectJsonObjectMeta(key, JsonMetaRequest{
+			InputSerialization: InputSerialization{
+				JSON:            JSON{JSONType: input.JSONType},
+				CompressionType: selectReq.InputSerializationSelect.CompressionType,
+			},
+		}, metaOptions...)
+		if err != nil {
+			return nil, 0, err
+		}
+		splits, rows, total = meta.SplitsCount, meta.RowsCount, meta.TotalScanned
+	} else {
+		input := selectReq.InputSerializationSelect.CsvBodyInput
+		if input.Range != "" || input.SplitRange != "" {
+			return nil, 0, fmt.Errorf("oss: the range of SelectObjectParallel must be empty")
+		}
+		header := selectReq.OutputSerializationSelect.OutputHeader
+		if header != nil && *header {
+			return nil, 0, fmt.Errorf("oss: SelectObjectParallel does not support OutputHeader")
+		}
+		meta, err := bucket.CreateSelectCsvObjectMeta(key, CsvMetaRequest{
+			InputSerialization: InputSerialization{
+				CSV: CSV{
+					RecordDelimiter: input.RecordDelimiter,
+					FieldDelimiter:  input.FieldDelimiter,
+					QuoteCharacter:  input.QuoteCharacter,
+				},
+				CompressionType: selectReq.InputSerializationSelect.CompressionType,
+			},
+		}, metaOptions...)
+		if err != nil {
+			return nil, 0, err
+		}
+		splits, rows, total = meta.SplitsCount, meta.RowsCount, meta.TotalScanned
+	}
+
+	return splitSelectTasks(int64(splits), rows, routines), total, nil
+}
+
+// splitSelectTasks partitions the splits, or the lines if there is only one split, into tasks
+func splitSelectTasks(splits, rows int64, routines int) []selectTask {
+	count, useLines := splits, false
+	if splits <= 1 {
+		count, useLines = rows, true
+	}
+	taskCount := int64(routines * selectSplitsPerRoutine)
+	if taskCount > count {
+		taskCount = count
+	}
+	if taskCount <= 1 {
+		return []selectTask{{index: 0}}
+	}
+
+	tasks := make([]selectTask, 0, taskCount)
+	var start int64
+	for i := int64(0); i < taskCount; i++ {
+		end := start + count/taskCount - 1
+		if i < count%taskCount {
+			end++
+		}
+		r := strconv.FormatInt(start, 10) + "-" + strconv.FormatInt(end, 10)
+		if useLines {
+			tasks = append(tasks, selectTask{index: int(i), lineRange: r})
+		} else {
+			tasks = append(tasks, selectTask{index: int(i), splitRange: r})
+		}
+		start = end + 1
+	}
+	return tasks
+}
+
+// runSelectTasks runs the tasks concurrently and merges the output into w, r is updated with the scanned bytes if it's not nil
+func (bucket Bucket) runSelectTasks(key string, selectReq SelectRequest, tasks []selectTask, total int64, routines int,
+	w io.Writer, r *SelectRecordReader, options []Option) error {
+	listener := GetProgressListener(options)
+	unordered, _ := FindOption(options, selectUnordered, false)
+	retries, _ := FindOption(options, selectRetries, 3)
+	selectOptions := DeleteOption(options, progressListener)
+	ctxArg, _ := FindOption(options, contextArg, nil)
+	ctx, _ := ctxArg.(context.Context)
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	jobs := make(chan selectTask, len(tasks))
+	results := make(chan selectTaskResult, len(tasks))
+	failed := make(chan error)
+	die := make(chan bool)
+	defer close(die)
+	// A slot is taken by a task until its output is written, it bounds the buffered outputs
+	slots := make(chan bool, routines)
+
+	for _, task := range tasks {
+		jobs <- task
+	}
+	close(jobs)
+
+	for i := 0; i < routines; i++ {
+		go func() {
+			for {
+				select {
+				case slots <- true:
+				case <-die:
+					return
+				case <-ctx.Done():
+					return
+				}
+				task, ok := <-jobs
+				if !ok {
+					return
+				}
+				result, err := bucket.selectTaskWithRetry(ctx, key, selectReq, task, retries.(int), selectOptions)
+				if err != nil {
+					select {
+					case failed <- err:
+					case <-die:
+					}
+					return
+				}
+				select {
+				case results <- result:
+				case <-die:
+					return
+				}
+			}
+		}()
+	}
+
+	var scanned int64
+	event := newProgressEvent(TransferStartedEvent, 0, total, 0)
+	publishProgress(listener, event)
+
+	pending := map[int][]byte{}
+	next := 0
+	for completed := 0; completed < len(tasks); completed++ {
+		var result selectTaskResult
+		select {
+		case result = <-results:
+		case err := <-failed:
+			event = newProgressEvent(TransferFailedEvent, scanned, total, 0)
+			publishProgress(listener, event)
+			return err
+		case <-ctx.Done():
+			event = newProgressEvent(TransferFailedEvent, scanned, total, 0)
+			publishProgress(listener, event)
+			return ctx.Err()
+		}
+
+		scanned += result.scanned
+		if r != nil {
+			r.mu.Lock()
+			r.stats.BytesScanned = scanned
+			r.mu.Unlock()
+		}
+		event = newProgressEvent(TransferDataEvent, scanned, total, result.scanned)
+		publishProgress(listener, event)
+
+		if unordered.(bool) {
+			if _, err := w.Write(result.data); err != nil {
+				return err
+			}
+			<-slots
+			continue
+		}
+		pending[result.index] = result.data
+		for data, ok := pending[next]; ok; data, ok = pending[next] {
+			if _, err := w.Write(data); err != nil {
+				return err
+			}
+			delete(pending, next)
+			next++
+			<-slots
+		}
+	}
+
+	if r != nil {
+		r.mu.Lock()
+		r.stats.Finished = true
+		r.mu.Unlock()
+	}
+	event = newProgressEvent(TransferCompletedEvent, scanned, total, 0)
+	publishProgress(listener, event)
+	return nil
+}
+
+// selectTaskWithRetry selects a range of the object and reads the whole output. Only the transient errors are retried,
+// and they aren't retried once ctx is done.
+func (bucket Bucket) selectTaskWithRetry(ctx context.Context, key string, selectReq SelectRequest, task selectTask, retries int, options []Option) (selectTaskResult, error) {
+	if selectReq.InputSerializationSelect.JsonBodyInput.JsonIsEmpty() {
+		selectReq.InputSerializationSelect.CsvBodyInput.SplitRange = task.splitRange
+		selectReq.InputSerializationSelect.CsvBodyInput.Range = task.lineRange
+	} else {
+		selectReq.InputSerializationSelect.JsonBodyInput.SplitRange = task.splitRange
+		selectReq.InputSerializationSelect.JsonBodyInput.Range = task.lineRange
+	}
+
+	var err error
+	for i := 0; i <= retries; i++ {
+		var result selectTaskResult
+		var retryable bool
+		result, retryable, err = bucket.selectTask(key, selectReq, task, options)
+		if err == nil {
+			return result, nil
+		}
+		if !retryable || ctx.Err() != nil {
+			return selectTaskResult{}, err
+		}
+		bucket.GetConfig().WriteLog(Debug, "select range error, split range:%s, line range:%s, retry:%d, error:%s\n",
+			task.splitRange, task.lineRange, i, err.Error())
+	}
+	return selectTaskResult{}, err
+}
+
+// selectTask selects a range of the object, the bool is true if the error is transient, such as the network failures and
+// the 5xx statuses. The other errors such as InvalidArgument fail the same way when the range is selected again.
+func (bucket Bucket) selectTask(key string, selectReq SelectRequest, task selectTask, options []Option) (selectTaskResult, bool, error) {
+	body, err := bucket.SelectObject(key, selectReq, options...)
+	if err != nil {
+		return selectTaskResult{}, selectRetryable(err), err
+	}
+	defer body.Close()
+
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		// The output is broken while it's transferred
+		return selectTaskResult{}, true, err
+	}
+	end := body.(*SelectObjectResponse).Frame.EndFrame
+	if end.HTTPStatusCode >= 400 {
+		return selectTaskResult{}, end.HTTPStatusCode >= 500,
+			fmt.Errorf("oss: select object failed, status code %d, %s", end.HTTPStatusCode, end.ErrorMsg)
+	}
+	return selectTaskResult{index: task.index, data: data, scanned: end.TotalScanned}, false, nil
+}
+
+// selectRetryable checks if the error of SelectObject is transient
+func selectRetryable(err error) bool {
+	switch e := err.(type) {
+	case ServiceError:
+		return e.StatusCode >= 500
+	case net.Error:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,236 @@
+package oss
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type OssSelectParallelSuite struct{}
+
+var _ = Suite(&OssSelectParallelSuite{})
+
+// selectParallelServer serves a CSV object of 10 splits, split i contains the line "i".
+// The first request of the split range starting at failAt returns an end frame of failStatus.
+// The split range starting at 0 waits until hold is closed if it's not nil.
+func selectParallelServer(c *C, failAt int, failStatus int32, hold chan bool) (*httptest.Server, func() int) {
+	var mu sync.Mutex
+	requests := 0
+	failed := false
+	rangeRe := regexp.MustCompile("split-range=(\\d+)-(\\d+)")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+		if r.URL.Query().Get("x-oss-process") == "csv/meta" {
+			payload := new(bytes.Buffer)
+			binary.Write(payload, binary.BigEndian, int64(100))
+			binary.Write(payload, binary.BigEndian, int32(200))
+			binary.Write(payload, binary.BigEndian, int32(10))
+			binary.Write(payload, binary.BigEndian, int64(10))
+			binary.Write(payload, binary.BigEndian, int32(1))
+			w.Write(selectFrame(MetaEndFrameCSVType, 100, payload.Bytes()))
+			return
+		}
+
+		c.Assert(r.URL.Query().Get("x-oss-process"), Equals, "csv/select")
+		m := rangeRe.FindStringSubmatch(string(body))
+		c.Assert(m, NotNil)
+		start, _ := strconv.Atoi(m[1])
+		end, _ := strconv.Atoi(m[2])
+
+		mu.Lock()
+		requests++
+		fail := start == failAt && !failed
+		if fail {
+			failed = true
+		}
+		mu.Unlock()
+		if fail {
+			w.Write(selectEndFrame(0, 0, failStatus, "InternalError"))
+			return
+		}
+		if start == 0 && hold != nil {
+			<-hold
+		}
+
+		data := ""
+		for i := start; i <= end; i++ {
+			data += strconv.Itoa(i) + "\n"
+		}
+		w.Write(selectFrame(DataFrameType, 0, []byte(data)))
+		w.Write(selectEndFrame(uint64(end-start+1)*10, int64(end-start+1)*10, 200, ""))
+	}))
+	return server, func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return requests
+	}
+}
+
+func (s *OssSelectParallelSuite) TestSelectObjectParallel(c *C) {
+	server, requests := selectParallelServer(c, 4, 500, nil)
+	defer server.Close()
+
+	client, err := New(server.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("bucket")
+	c.Assert(err, IsNil)
+
+	listener := &selectProgressListener{}
+	out := new(bytes.Buffer)
+	err = bucket.SelectObjectParallel("object.csv", SelectRequest{Expression: "select * from ossobject"}, 2, out, Progress(listener))
+	c.Assert(err, IsNil)
+	c.Assert(out.String(), Equals, "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n")
+	// 8 ranges and a retry
+	c.Assert(requests(), Equals, 9)
+	c.Assert(listener.consumed, Equals, int64(100))
+	c.Assert(listener.total, Equals, int64(100))
+	c.Assert(listener.completed, Equals, true)
+
+	// no retry
+	server2, _ := selectParallelServer(c, 4, 500, nil)
+	defer server2.Close()
+	client2, err := New(server2.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+	bucket2, err := client2.Bucket("bucket")
+	c.Assert(err, IsNil)
+	err = bucket2.SelectObjectParallel("object.csv", SelectRequest{Expression: "select * from ossobject"}, 2, out, SelectSplitRetries(0))
+	c.Assert(err, NotNil)
+
+	// invalid requests
+	selectReq := SelectRequest{Expression: "select * from ossobject"}
+	selectReq.InputSerializationSelect.CsvBodyInput.Range = "0-10"
+	err = bucket.SelectObjectParallel("object.csv", selectReq, 2, out)
+	c.Assert(err, NotNil)
+	err = bucket.SelectObjectParallel("object.csv", SelectRequest{Expression: "select * from ossobject"}, 0, out)
+	c.Assert(err, NotNil)
+
+	// the 4xx status isn't retried
+	server3, requests3 := selectParallelServer(c, 0, 400, nil)
+	defer server3.Close()
+	client3, err := New(server3.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+	bucket3, err := client3.Bucket("bucket")
+	c.Assert(err, IsNil)
+	err = bucket3.SelectObjectParallel("object.csv", SelectRequest{Expression: "select * from ossobject"}, 1, out)
+	c.Assert(err, NotNil)
+	c.Assert(requests3(), Equals, 1)
+}
+
+func (s *OssSelectParallelSuite) TestSelectParallelBuffered(c *C) {
+	hold := make(chan bool)
+	server, requests := selectParallelServer(c, -1, 500, hold)
+	defer server.Close()
+
+	client, err := New(server.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("bucket")
+	c.Assert(err, IsNil)
+
+	// the slow first range holds a slot, the other routine stops after its output is buffered
+	done := make(chan error)
+	out := new(bytes.Buffer)
+	go func() {
+		done <- bucket.SelectObjectParallel("object.csv", SelectRequest{Expression: "select * from ossobject"}, 2, out)
+	}()
+	time.Sleep(200 * time.Millisecond)
+	c.Assert(requests(), Equals, 2)
+	close(hold)
+	c.Assert(<-done, IsNil)
+	c.Assert(out.String(), Equals, "0\n1\n2\n3\n4\n5\n6\n7\n8\n9\n")
+	c.Assert(requests(), Equals, 8)
+}
+
+func (s *OssSelectParallelSuite) TestSelectTaskCanceled(c *C) {
+	server, requests := selectParallelServer(c, 0, 500, nil)
+	defer server.Close()
+
+	client, err := New(server.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("bucket")
+	c.Assert(err, IsNil)
+
+	// the failed task isn't retried once the context is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	selectReq := SelectRequest{Expression: "select * from ossobject"}
+	task := selectTask{index: 0, splitRange: "0-4"}
+	_, err = bucket.selectTaskWithRetry(ctx, "object.csv", selectReq, task, 3, nil)
+	c.Assert(err, NotNil)
+	c.Assert(requests(), Equals, 1)
+
+	// the tasks aren't started once the context is done
+	out := new(bytes.Buffer)
+	tasks := []selectTask{{index: 0, splitRange: "0-4"}, {index: 1, splitRange: "5-9"}}
+	err = bucket.runSelectTasks("object.csv", selectReq, tasks, 100, 1, out, nil, []Option{WithContext(ctx)})
+	c.Assert(err, Equals, context.Canceled)
+	c.Assert(requests(), Equals, 1)
+	c.Assert(out.Len(), Equals, 0)
+}
+
+func (s *OssSelectParallelSuite) TestSelectRecordsParallelUnordered(c *C) {
+	server, _ := selectParallelServer(c, -1, 500, nil)
+	defer server.Close()
+
+	client, err := New(server.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("bucket")
+	c.Assert(err, IsNil)
+
+	reader, err := bucket.SelectRecordsParallel("object.csv", SelectRequest{Expression: "select * from ossobject"}, 3, SelectUnordered(true))
+	c.Assert(err, IsNil)
+	defer reader.Close()
+
+	lines := []string{}
+	for reader.Next() {
+		lines = append(lines, strings.Join(reader.Record(), ","))
+	}
+	c.Assert(reader.Err(), IsNil)
+	sort.Strings(lines)
+	c.Assert(lines, DeepEquals, []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"})
+	c.Assert(reader.Stats().BytesScanned, Equals, int64(100))
+	c.Assert(reader.Stats().RowsReturned, Equals, int64(10))
+}
+
+func (s *OssSelectParallelSuite) TestSplitSelectTasks(c *C) {
+	tasks := splitSelectTasks(10, 1000, 1)
+	c.Assert(len(tasks), Equals, 4)
+	c.Assert(tasks[0].splitRange, Equals, "0-2")
+	c.Assert(tasks[1].splitRange, Equals, "3-5")
+	c.Assert(tasks[2].splitRange, Equals, "6-7")
+	c.Assert(tasks[3].splitRange, Equals, "8-9")
+
+	// line range for the object with only one split
+	tasks = splitSelectTasks(1, 3, 2)
+	c.Assert(len(tasks), Equals, 3)
+	c.Assert(tasks[2].lineRange, Equals, "2-2")
+	c.Assert(tasks[2].splitRange, Equals, "")
+
+	tasks = splitSelectTasks(0, 1, 2)
+	c.Assert(tasks, DeepEquals, []selectTask{{index: 0}})
+}
+
+type selectProgressListener struct {
+	consumed  int64
+	total     int64
+	completed bool
+}
+
+func (listener *selectProgressListener) ProgressChanged(event *ProgressEvent) {
+	listener.consumed = event.ConsumedBytes
+	listener.total = event.TotalBytes
+	if event.EventType == TransferCompletedEvent {
+		listener.completed = true
+	}
+}
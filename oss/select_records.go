@@ -0,0 +1,284 @@
+package oss
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// SelectStats is the scan statistics of a SelectRecords request, updated while the records are read
+type SelectStats struct {
+	BytesScanned   int64  // The bytes of the object scanned by OSS so far
+	RowsReturned   int64  // The records returned so far
+	Finished       bool   // Whether the end frame has been received
+	HTTPStatusCode int32  // The status code of the end frame, 0 before the end frame
+	ErrorMsg       string // The error message of the end frame, such as the count of skipped lines
+}
+
+// SelectRecordReader iterates over the records returned by SelectRecords.
+// CSV records are read by Record, JSON records by RawJSON or Decode.
+//
+//	reader, err := bucket.SelectRecords(key, selectReq)
+//	...
+//	defer reader.Close()
+//	for reader.Next() {
+//		fmt.Println(reader.Record())
+//	}
+//	err = reader.Err()
+type SelectRecordReader struct {
+	body        io.Closer
+	reader      *bufio.Reader
+	isJSON      bool
+	fieldDelim  []byte
+	recordDelim []byte
+	quote       byte
+	record      []string
+	rawJSON     json.RawMessage
+	mu          sync.Mutex // Guards stats, which is updated by the merging goroutine of SelectRecordsParallel
+	stats       SelectStats
+	err         error
+}
+
+// SelectRecords selects the object and decodes the result into records.
+//
+// key    the object key.
+// selectReq    the select request, the same as SelectObject. CSV records are split by
+//
+//	CsvBodyOutput.RecordDelimiter and CsvBodyOutput.FieldDelimiter, quoted by CsvBodyInput.QuoteCharacter.
+//	JSON records are split by JsonBodyOutput.RecordDelimiter.
+//
+// options    the options for select file of the object.
+//
+// *SelectRecordReader    the record reader. It must be closed after the usage and only valid when error is nil.
+// error    it's nil if no error, otherwise it's an error object.
+func (bucket Bucket) SelectRecords(key string, selectReq SelectRequest, options ...Option) (*SelectRecordReader, error) {
+	body, err := bucket.SelectObject(key, selectReq, options...)
+	if err != nil {
+		return nil, err
+	}
+	r := newSelectRecordReader(selectReq, body)
+	r.reader = bufio.NewReader(&selectStatsReader{resp: body.(*SelectObjectResponse), r: r})
+	return r, nil
+}
+
+// newSelectRecordReader creates a record reader with the delimiters of the select request
+func newSelectRecordReader(selectReq SelectRequest, body io.ReadCloser) *SelectRecordReader {
+	r := &SelectRecordReader{
+		body:        body,
+		reader:      bufio.NewReader(body),
+		isJSON:      !selectReq.InputSerializationSelect.JsonBodyInput.JsonIsEmpty(),
+		fieldDelim:  []byte(","),
+		recordDelim: []byte("\n"),
+		quote:       '"',
+	}
+	if r.isJSON {
+		if d := selectReq.OutputSerializationSelect.JsonBodyOutput.RecordDelimiter; d != "" {
+			r.recordDelim = []byte(d)
+		}
+	} else {
+		if d := selectReq.OutputSerializationSelect.CsvBodyOutput.RecordDelimiter; d != "" {
+			r.recordDelim = []byte(d)
+		}
+		if d := selectReq.OutputSerializationSelect.CsvBodyOutput.FieldDelimiter; d != "" {
+			r.fieldDelim = []byte(d)
+		}
+		if q := selectReq.InputSerializationSelect.CsvBodyInput.QuoteCharacter; q != "" {
+			r.quote = q[0]
+		}
+	}
+	return r
+}
+
+// Next reads the next record. It returns false at the end of the result or on an error, which is returned by Err.
+func (r *SelectRecordReader) Next() bool {
+	if r.err != nil {
+		return false
+	}
+
+	var err error
+	if r.isJSON {
+		r.rawJSON, err = r.readJSONRecord()
+	} else {
+		r.record, err = r.readCSVRecord()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err == nil {
+		r.stats.RowsReturned++
+		return true
+	}
+
+	r.record, r.rawJSON = nil, nil
+	if err == io.EOF && r.stats.HTTPStatusCode >= 400 {
+		err = fmt.Errorf("oss: select object failed, status code %d, %s", r.stats.HTTPStatusCode, r.stats.ErrorMsg)
+	}
+	r.err = err
+	return false
+}
+
+// Record returns the current CSV record
+func (r *SelectRecordReader) Record() []string {
+	return r.record
+}
+
+// RawJSON returns the current JSON record
+func (r *SelectRecordReader) RawJSON() json.RawMessage {
+	return r.rawJSON
+}
+
+// Decode unmarshals the current JSON record into v
+func (r *SelectRecordReader) Decode(v interface{}) error {
+	if !r.isJSON {
+		return fmt.Errorf("oss: Decode only supports JSON records, use Record for CSV records")
+	}
+	if r.rawJSON == nil {
+		return fmt.Errorf("oss: no current record")
+	}
+	return json.Unmarshal(r.rawJSON, v)
+}
+
+// Stats returns the scan statistics received so far
+func (r *SelectRecordReader) Stats() SelectStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stats
+}
+
+// Err returns the error stopping Next, nil at the end of the result
+func (r *SelectRecordReader) Err() error {
+	if r.err == io.EOF {
+		return nil
+	}
+	return r.err
+}
+
+// Close closes the response body
+func (r *SelectRecordReader) Close() error {
+	return r.body.Close()
+}
+
+// readCSVRecord reads a CSV record, the quote character in a quoted field is escaped by doubling it
+func (r *SelectRecordReader) readCSVRecord() ([]string, error) {
+	var fields []string
+	var field bytes.Buffer
+	inQuote, empty := false, true
+	for {
+		b, err := r.reader.ReadByte()
+		if err == io.EOF && !empty {
+			return append(fields, field.String()), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		empty = false
+
+		if inQuote {
+			if b != r.quote {
+				field.WriteByte(b)
+			} else if next, err := r.reader.Peek(1); err == nil && next[0] == r.quote {
+				r.reader.ReadByte()
+				field.WriteByte(r.quote)
+			} else {
+				inQuote = false
+			}
+			continue
+		}
+
+		switch {
+		case b == r.quote && field.Len() == 0:
+			inQuote = true
+		case r.matchDelim(b, r.fieldDelim):
+			fields = append(fields, field.String())
+			field.Reset()
+		case r.matchDelim(b, r.recordDelim):
+			return append(fields, field.String()), nil
+		default:
+			field.WriteByte(b)
+		}
+	}
+}
+
+// readJSONRecord reads a JSON record, the record delimiters in strings, objects and arrays are ignored
+func (r *SelectRecordReader) readJSONRecord() (json.RawMessage, error) {
+	var buf bytes.Buffer
+	inString, escaped, depth := false, false, 0
+	for {
+		b, err := r.reader.ReadByte()
+		if err == io.EOF {
+			if record := bytes.TrimSpace(buf.Bytes()); len(record) > 0 {
+				return json.RawMessage(record), nil
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if !inString && depth == 0 && r.matchDelim(b, r.recordDelim) {
+			if record := bytes.TrimSpace(buf.Bytes()); len(record) > 0 {
+				return json.RawMessage(record), nil
+			}
+			buf.Reset()
+			continue
+		}
+
+		buf.WriteByte(b)
+		switch {
+		case inString && escaped:
+			escaped = false
+		case inString && b == '\\':
+			escaped = true
+		case b == '"':
+			inString = !inString
+		case !inString && (b == '{' || b == '['):
+			depth++
+		case !inString && (b == '}' || b == ']'):
+			depth--
+		}
+	}
+}
+
+// matchDelim checks if the delimiter starts with b, the rest of the delimiter is consumed if it matches
+func (r *SelectRecordReader) matchDelim(b byte, delim []byte) bool {
+	if b != delim[0] {
+		return false
+	}
+	if len(delim) == 1 {
+		return true
+	}
+	next, err := r.reader.Peek(len(delim) - 1)
+	if err != nil || !bytes.Equal(next, delim[1:]) {
+		return false
+	}
+	r.reader.Discard(len(delim) - 1)
+	return true
+}
+
+// selectStatsReader reads the select response and records the statistics of the frames
+type selectStatsReader struct {
+	resp *SelectObjectResponse
+	r    *SelectRecordReader
+}
+
+func (sr *selectStatsReader) Read(p []byte) (int, error) {
+	n, err := sr.resp.Read(p)
+
+	sr.r.mu.Lock()
+	defer sr.r.mu.Unlock()
+	stats := &sr.r.stats
+	if offset := int64(sr.resp.Frame.Offset); offset > stats.BytesScanned {
+		stats.BytesScanned = offset
+	}
+	if sr.resp.Finish && !stats.Finished {
+		end := sr.resp.Frame.EndFrame
+		stats.Finished = true
+		stats.HTTPStatusCode = end.HTTPStatusCode
+		stats.ErrorMsg = end.ErrorMsg
+		if end.TotalScanned > stats.BytesScanned {
+			stats.BytesScanned = end.TotalScanned
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,151 @@
+package oss
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+
+	. "gopkg.in/check.v1"
+)
+
+type OssSelectRecordsSuite struct{}
+
+var _ = Suite(&OssSelectRecordsSuite{})
+
+// selectFrame builds a frame of the select response
+func selectFrame(frameType int32, offset uint64, payload []byte) []byte {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.BigEndian, frameType|1<<24)
+	binary.Write(buf, binary.BigEndian, int32(len(payload)+8))
+	binary.Write(buf, binary.BigEndian, uint32(0))
+	binary.Write(buf, binary.BigEndian, offset)
+	buf.Write(payload)
+	binary.Write(buf, binary.BigEndian, uint32(0))
+	return buf.Bytes()
+}
+
+// selectEndFrame builds the end frame of the select response
+func selectEndFrame(offset uint64, totalScanned int64, status int32, msg string) []byte {
+	payload := new(bytes.Buffer)
+	binary.Write(payload, binary.BigEndian, totalScanned)
+	binary.Write(payload, binary.BigEndian, status)
+	payload.WriteString(msg)
+	return selectFrame(EndFrameType, offset, payload.Bytes())
+}
+
+func selectServer(c *C, frames ...[]byte) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Method, Equals, "POST")
+		w.WriteHeader(http.StatusPartialContent)
+		for _, frame := range frames {
+			w.Write(frame)
+		}
+	}))
+}
+
+func (s *OssSelectRecordsSuite) TestSelectCSVRecords(c *C) {
+	server := selectServer(c,
+		selectFrame(DataFrameType, 10, []byte("1|\"Tom|Jerry\"|a\r\n2|\"say \"\"hi\"\"\"|")),
+		selectFrame(ContinuousFrameType, 100, nil),
+		selectFrame(DataFrameType, 150, []byte("\r\n3||c\r\n")),
+		selectEndFrame(200, 200, 206, "1 lines skipped"),
+	)
+	defer server.Close()
+
+	client, err := New(server.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("bucket")
+	c.Assert(err, IsNil)
+
+	selectReq := SelectRequest{Expression: "select * from ossobject"}
+	selectReq.OutputSerializationSelect.CsvBodyOutput.FieldDelimiter = "|"
+	selectReq.OutputSerializationSelect.CsvBodyOutput.RecordDelimiter = "\r\n"
+	reader, err := bucket.SelectRecords("object.csv", selectReq)
+	c.Assert(err, IsNil)
+	defer reader.Close()
+
+	records := [][]string{}
+	for reader.Next() {
+		records = append(records, reader.Record())
+		c.Assert(reader.Stats().RowsReturned, Equals, int64(len(records)))
+		c.Assert(reader.Stats().BytesScanned > 0, Equals, true)
+	}
+	c.Assert(reader.Err(), IsNil)
+	c.Assert(records, DeepEquals, [][]string{
+		{"1", "Tom|Jerry", "a"},
+		{"2", "say \"hi\"", ""},
+		{"3", "", "c"},
+	})
+
+	stats := reader.Stats()
+	c.Assert(stats.Finished, Equals, true)
+	c.Assert(stats.BytesScanned, Equals, int64(200))
+	c.Assert(stats.RowsReturned, Equals, int64(3))
+	c.Assert(stats.HTTPStatusCode, Equals, int32(206))
+	c.Assert(stats.ErrorMsg, Equals, "1 lines skipped")
+
+	err = reader.Decode(&records)
+	c.Assert(err, NotNil)
+}
+
+func (s *OssSelectRecordsSuite) TestSelectJSONRecords(c *C) {
+	server := selectServer(c,
+		selectFrame(DataFrameType, 10, []byte("{\"name\":\"a,b\",\"tags\":[\"x\",\"y\"]},{\"name\":\"c\\\"}\"")),
+		selectFrame(DataFrameType, 20, []byte(",\"tags\":[]},")),
+		selectEndFrame(30, 30, 200, ""),
+	)
+	defer server.Close()
+
+	client, err := New(server.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("bucket")
+	c.Assert(err, IsNil)
+
+	selectReq := SelectRequest{Expression: "select * from ossobject.objects[*]"}
+	selectReq.InputSerializationSelect.JsonBodyInput.JSONType = "DOCUMENT"
+	selectReq.OutputSerializationSelect.JsonBodyOutput.RecordDelimiter = ","
+	reader, err := bucket.SelectRecords("object.json", selectReq)
+	c.Assert(err, IsNil)
+	defer reader.Close()
+
+	type record struct {
+		Name string   `json:"name"`
+		Tags []string `json:"tags"`
+	}
+	records := []record{}
+	raws := []string{}
+	for reader.Next() {
+		var r record
+		c.Assert(reader.Decode(&r), IsNil)
+		records = append(records, r)
+		raws = append(raws, string(reader.RawJSON()))
+	}
+	c.Assert(reader.Err(), IsNil)
+	c.Assert(raws, DeepEquals, []string{"{\"name\":\"a,b\",\"tags\":[\"x\",\"y\"]}", "{\"name\":\"c\\\"}\",\"tags\":[]}"})
+	c.Assert(records, DeepEquals, []record{{Name: "a,b", Tags: []string{"x", "y"}}, {Name: "c\"}", Tags: []string{}}})
+	c.Assert(reader.Stats().BytesScanned, Equals, int64(30))
+}
+
+func (s *OssSelectRecordsSuite) TestSelectRecordsEndFrameError(c *C) {
+	server := selectServer(c,
+		selectFrame(DataFrameType, 10, []byte("1,2\n")),
+		selectEndFrame(20, 20, 400, "InvalidCsvLine"),
+	)
+	defer server.Close()
+
+	client, err := New(server.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("bucket")
+	c.Assert(err, IsNil)
+
+	reader, err := bucket.SelectRecords("object.csv", SelectRequest{Expression: "select * from ossobject"})
+	c.Assert(err, IsNil)
+	defer reader.Close()
+
+	c.Assert(reader.Next(), Equals, true)
+	c.Assert(reader.Record(), DeepEquals, []string{"1", "2"})
+	c.Assert(reader.Next(), Equals, false)
+	c.Assert(reader.Err(), NotNil)
+	c.Assert(reader.Stats().ErrorMsg, Equals, "InvalidCsvLine")
+}
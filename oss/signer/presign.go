@@ -0,0 +1,96 @@
+package signer
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Presign signs the request by the query parameters, the URL of the request could be used without the credentials
+// until the expiration. The headers of the request, such as Content-Type, must be sent with the URL.
+//
+// req    the request to sign, its URL is updated.
+// bucket    the bucket name.
+// key    the object key, empty for the bucket requests.
+// credentials    the AccessKey.
+// expiration    the time the URL expires.
+//
+// error    it's nil if no error, otherwise it's an error object.
+func (s *Signer) Presign(req *http.Request, bucket, key string, credentials Credentials, expiration time.Time) error {
+	query := req.URL.Query()
+	if err := s.PresignQuery(req.Method, req.Header, bucket, key, query, credentials, expiration); err != nil {
+		return err
+	}
+	req.URL.RawQuery = EncodeQuery(query)
+	return nil
+}
+
+// PresignQuery adds the signature parameters to the query, the query encoded by EncodeQuery is the query of the
+// presigned URL.
+//
+// method    the HTTP method.
+// header    the headers sent with the URL, it could be nil.
+// bucket    the bucket name.
+// key    the object key, empty for the bucket requests.
+// query    the query parameters of the request, the signature parameters are added.
+// credentials    the AccessKey.
+// expiration    the time the URL expires.
+//
+// error    it's nil if no error, otherwise it's an error object.
+func (s *Signer) PresignQuery(method string, header http.Header, bucket, key string, query url.Values,
+	credentials Credentials, expiration time.Time) error {
+	if credentials == nil {
+		return fmt.Errorf("signer: credentials are nil")
+	}
+	req := &http.Request{Method: strings.ToUpper(method), Header: make(http.Header)}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	if s.Version == V4 {
+		if token := credentials.GetSecurityToken(); token != "" {
+			query.Set(paramSecurityTokenV4, token)
+		}
+		now := s.now()
+		region, product := s.Scope()
+		query.Set(paramVersion, algorithmV4)
+		query.Set(paramCredential, fmt.Sprintf("%s/%s/%s/%s/%s", credentials.GetAccessKeyID(), now.Format(shortTimeFormatV4),
+			region, product, requestTerminatorV4))
+		query.Set(paramDate, now.Format(timeFormatV4))
+		query.Set(paramExpiresV2, strconv.FormatInt(expiration.Unix()-now.Unix(), 10))
+		if additional := s.AdditionalHeaderKeys(req.Header); len(additional) > 0 {
+			query.Set(paramAdditionalV2, strings.Join(additional, ";"))
+		}
+		resource := s.Resource(bucket, key, s.SubResource(query))
+		query.Set(paramSignatureV2, s.Signature(req, resource, credentials.GetAccessKeySecret(), now))
+		return nil
+	}
+
+	// The expiration is signed as the date
+	expires := strconv.FormatInt(expiration.Unix(), 10)
+	req.Header.Set(headerDate, expires)
+	if token := credentials.GetSecurityToken(); token != "" {
+		query.Set(paramSecurityToken, token)
+	}
+	if s.Version == V2 {
+		query.Set(paramVersion, "OSS2")
+		query.Set(paramExpiresV2, expires)
+		query.Set(paramAccessKeyIDV2, credentials.GetAccessKeyID())
+		if additional := s.AdditionalHeaderKeys(req.Header); len(additional) > 0 {
+			query.Set(paramAdditionalV2, strings.Join(additional, ";"))
+		}
+	}
+
+	signature := s.Signature(req, s.Resource(bucket, key, s.SubResource(query)), credentials.GetAccessKeySecret(), time.Time{})
+	if s.Version == V2 {
+		query.Set(paramSignatureV2, signature)
+	} else {
+		query.Set(paramExpires, expires)
+		query.Set(paramAccessKeyID, credentials.GetAccessKeyID())
+		query.Set(paramSignature, signature)
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package signer
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Sign signs the request by the Authorization header. The Date header is set by the clock if the request has
+// neither Date nor X-Oss-Date, the X-Oss-Security-Token header is set for the STS credentials, and V4 sets
+// X-Oss-Date and X-Oss-Content-Sha256 if they're missing. The query of the request URL is signed as is.
+//
+// req    the request to sign.
+// bucket    the bucket name, empty for the service requests such as ListBuckets.
+// key    the object key, empty for the bucket requests.
+// credentials    the AccessKey.
+//
+// error    it's nil if no error, otherwise it's an error object.
+func (s *Signer) Sign(req *http.Request, bucket, key string, credentials Credentials) error {
+	if credentials == nil {
+		return fmt.Errorf("signer: credentials are nil")
+	}
+	if req.Header == nil {
+		req.Header = make(http.Header)
+	}
+
+	now := s.now()
+	if req.Header.Get(headerDate) == "" && req.Header.Get(headerOssDate) == "" {
+		req.Header.Set(headerDate, now.Format(http.TimeFormat))
+	}
+	if s.Version == V4 {
+		if req.Header.Get(headerOssDate) == "" {
+			req.Header.Set(headerOssDate, now.Format(timeFormatV4))
+		}
+		if req.Header.Get(headerContentSha256) == "" {
+			req.Header.Set(headerContentSha256, unsignedPayload)
+		}
+	}
+	if token := credentials.GetSecurityToken(); token != "" {
+		req.Header.Set(headerSecurityToken, token)
+	}
+
+	s.SignHeader(req, s.Resource(bucket, key, s.SubResource(req.URL.Query())), credentials)
+	return nil
+}
+
+// SignHeader signs the request with the canonicalized resource and sets it as the Authorization header, the
+// request must have the Date or X-Oss-Date header.
+//
+// req    the request to sign.
+// resource    the canonicalized resource returned by Resource.
+// credentials    the AccessKey.
+func (s *Signer) SignHeader(req *http.Request, resource string, credentials Credentials) {
+	var authorization string
+	switch s.Version {
+	case V4:
+		var t time.Time
+		if date := req.Header.Get(headerOssDate); date != "" {
+			t, _ = time.Parse(timeFormatV4, date)
+		} else {
+			t, _ = time.Parse(http.TimeFormat, req.Header.Get(headerDate))
+		}
+		region, product := s.Scope()
+		authorization = fmt.Sprintf("%s Credential=%s/%s/%s/%s/%s,", algorithmV4, credentials.GetAccessKeyID(),
+			t.Format(shortTimeFormatV4), region, product, requestTerminatorV4)
+		if additional := s.additionalHeaderKeys(req.Header, true); len(additional) > 0 {
+			authorization += "AdditionalHeaders=" + strings.Join(additional, ";") + ","
+		}
+		authorization += "Signature=" + s.Signature(req, resource, credentials.GetAccessKeySecret(), time.Time{})
+	case V2:
+		authorization = "OSS2 AccessKeyId:" + credentials.GetAccessKeyID() + ","
+		if additional := s.AdditionalHeaderKeys(req.Header); len(additional) > 0 {
+			authorization += "AdditionalHeaders:" + strings.Join(additional, ";") + ","
+		}
+		authorization += "Signature:" + s.Signature(req, resource, credentials.GetAccessKeySecret(), time.Time{})
+	default:
+		authorization = "OSS " + credentials.GetAccessKeyID() + ":" + s.Signature(req, resource, credentials.GetAccessKeySecret(), time.Time{})
+	}
+	req.Header.Set(headerAuthorization, authorization)
+}
+
+// Signature returns the signature of the request
+//
+// req    the request to sign, the Date header is the expiration for the V1 and V2 presigned URLs.
+// resource    the canonicalized resource returned by Resource.
+// secret    the AccessKey secret.
+// signingTime    the V4 signing time, the zero value means the time of the X-Oss-Date or Date header.
+//
+// string    the base64 encoded HMAC-SHA1 or HMAC-SHA256 of V1 and V2, or the hex encoded HMAC-SHA256 of V4.
+func (s *Signer) Signature(req *http.Request, resource, secret string, signingTime time.Time) string {
+	if s.Version == V4 {
+		return s.signatureV4(req, resource, secret, signingTime)
+	}
+
+	// The x-oss- headers, and the additional headers of V2
+	headers := make(map[string]string)
+	additional := s.AdditionalHeaderKeys(req.Header)
+	for k, v := range req.Header {
+		lowKey := strings.ToLower(k)
+		if strings.HasPrefix(lowKey, "x-oss-") {
+			headers[lowKey] = v[0]
+		} else if s.Version == V2 && containsString(additional, lowKey) {
+			headers[lowKey] = v[0]
+		}
+	}
+
+	stringToSign := req.Method + "\n" + req.Header.Get(headerContentMD5) + "\n" + req.Header.Get(headerContentType) + "\n" +
+		req.Header.Get(headerDate) + "\n" + canonicalHeaders(headers)
+	h := hmac.New(func() hash.Hash { return sha1.New() }, []byte(secret))
+	if s.Version == V2 {
+		stringToSign += strings.Join(additional, ";") + "\n" + resource
+		h = hmac.New(func() hash.Hash { return sha256.New() }, []byte(secret))
+	} else {
+		stringToSign += resource
+	}
+	if s.Debug != nil {
+		s.Debug(req, "", stringToSign)
+	}
+
+	io.WriteString(h, stringToSign)
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func (s *Signer) signatureV4(req *http.Request, resource, secret string, signingTime time.Time) string {
+	// Content-MD5, Content-Type, the x-oss- headers and the additional headers
+	headers := make(map[string]string)
+	additional := s.additionalHeaderKeys(req.Header, true)
+	for k, v := range req.Header {
+		lowKey := strings.ToLower(k)
+		if strings.EqualFold(lowKey, headerContentMD5) || strings.EqualFold(lowKey, headerContentType) ||
+			strings.HasPrefix(lowKey, "x-oss-") || containsString(additional, lowKey) {
+			headers[lowKey] = strings.Trim(v[0], " ")
+		}
+	}
+
+	signDate, day := "", ""
+	if !signingTime.IsZero() {
+		signDate, day = signingTime.Format(timeFormatV4), signingTime.Format(shortTimeFormatV4)
+	} else {
+		var t time.Time
+		if date := req.Header.Get(headerDate); date != "" {
+			signDate = date
+			t, _ = time.Parse(http.TimeFormat, date)
+		}
+		if ossDate := req.Header.Get(headerOssDate); ossDate != "" {
+			signDate = ossDate
+			t, _ = time.Parse(timeFormatV4, ossDate)
+		}
+		day = t.Format(shortTimeFormatV4)
+	}
+
+	hashedPayload := unsignedPayload
+	if v := req.Header.Get(headerContentSha256); v != "" {
+		hashedPayload = v
+	}
+
+	path, query := resource, ""
+	if pos := strings.LastIndex(resource, "?"); pos != -1 {
+		path, query = resource[:pos], resource[pos+1:]
+	}
+
+	canonicalRequest := req.Method + "\n" + path + "\n" + query + "\n" + canonicalHeaders(headers) + "\n" +
+		strings.Join(additional, ";") + "\n" + hashedPayload
+	hashedRequest := sha256.Sum256([]byte(canonicalRequest))
+
+	region, product := s.Scope()
+	stringToSign := algorithmV4 + "\n" + signDate + "\n" + day + "/" + region + "/" + product + "/" + requestTerminatorV4 + "\n" +
+		hex.EncodeToString(hashedRequest[:])
+	if s.Debug != nil {
+		s.Debug(req, canonicalRequest, stringToSign)
+	}
+
+	key := hmacSHA256([]byte("aliyun_v4"+secret), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, product)
+	key = hmacSHA256(key, requestTerminatorV4)
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	io.WriteString(h, data)
+	return h.Sum(nil)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,299 @@
+// Package signer signs the OSS requests by the V1, V2 and V4 signatures without a Client, such as for the gateways
+// which proxy the requests to OSS or the tests which need the deterministic signatures.
+//
+// The request is signed by the Authorization header:
+//
+//	s := &signer.Signer{Version: signer.V4, Region: "cn-hangzhou"}
+//	req, _ := http.NewRequest("GET", "https://examplebucket.oss-cn-hangzhou.aliyuncs.com/dir/a.txt", nil)
+//	err := s.Sign(req, "examplebucket", "dir/a.txt", signer.StaticCredentials{AccessKeyID: "ak", AccessKeySecret: "sk"})
+//
+// or by the query parameters of the URL, which is valid until the expiration:
+//
+//	err := s.Presign(req, "examplebucket", "dir/a.txt", credentials, time.Now().Add(time.Hour))
+//
+// The clock is Signer.Now, and the canonical request and the string to sign are passed to Signer.Debug.
+//
+// The presigned URLs are inspected by ParsePresignedURL, and verified by VerifyPresignedURL with the AccessKey secret,
+// such as for the local services compatible with OSS.
+package signer
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Version is the version of the signature
+type Version string
+
+const (
+	// V1 is the HMAC-SHA1 signature
+	V1 Version = "v1"
+	// V2 is the HMAC-SHA256 signature with the additional headers
+	V2 Version = "v2"
+	// V4 is the OSS4-HMAC-SHA256 signature scoped by the date, the region and the product
+	V4 Version = "v4"
+)
+
+const (
+	headerAuthorization  = "Authorization"
+	headerContentMD5     = "Content-MD5"
+	headerContentType    = "Content-Type"
+	headerDate           = "Date"
+	headerOssDate        = "X-Oss-Date"
+	headerContentSha256  = "X-Oss-Content-Sha256"
+	headerSecurityToken  = "X-Oss-Security-Token"
+	unsignedPayload      = "UNSIGNED-PAYLOAD"
+	timeFormatV4         = "20060102T150405Z"
+	shortTimeFormatV4    = "20060102"
+	algorithmV4          = "OSS4-HMAC-SHA256"
+	defaultProduct       = "oss"
+	cloudBoxProduct      = "oss-cloudbox"
+	requestTerminatorV4  = "aliyun_v4_request"
+	paramExpires         = "Expires"
+	paramAccessKeyID     = "OSSAccessKeyId"
+	paramSignature       = "Signature"
+	paramSecurityToken   = "security-token"
+	paramVersion         = "x-oss-signature-version"
+	paramExpiresV2       = "x-oss-expires"
+	paramAccessKeyIDV2   = "x-oss-access-key-id"
+	paramSignatureV2     = "x-oss-signature"
+	paramAdditionalV2    = "x-oss-additional-headers"
+	paramCredential      = "x-oss-credential"
+	paramDate            = "x-oss-date"
+	paramSecurityTokenV4 = "x-oss-security-token"
+)
+
+// The query parameters signed by the V1 signature, the others are ignored
+var subResources = []string{"acl", "uploads", "location", "cors",
+	"logging", "website", "referer", "lifecycle",
+	"delete", "append", "tagging", "objectMeta",
+	"uploadId", "partNumber", "security-token",
+	"position", "img", "style", "styleName",
+	"replication", "replicationProgress",
+	"replicationLocation", "cname", "bucketInfo",
+	"comp", "qos", "live", "status", "vod",
+	"startTime", "endTime", "symlink",
+	"x-oss-process", "response-content-type", "x-oss-traffic-limit",
+	"response-content-language", "response-expires",
+	"response-cache-control", "response-content-disposition",
+	"response-content-encoding", "udf", "udfName", "udfImage",
+	"udfId", "udfImageDesc", "udfApplication", "comp",
+	"udfApplicationLog", "restore", "callback", "callback-var", "qosInfo",
+	"policy", "stat", "encryption", "versions", "versioning", "versionId", "requestPayment",
+	"x-oss-request-payer", "sequential",
+	"inventory", "inventoryId", "continuation-token", "asyncFetch",
+	"worm", "wormId", "wormExtend", "withHashContext",
+	"x-oss-enable-md5", "x-oss-enable-sha1", "x-oss-enable-sha256",
+	"x-oss-hash-ctx", "x-oss-md5-ctx", "transferAcceleration",
+	"regionList", "cloudboxes", "x-oss-ac-source-ip", "x-oss-ac-subnet-mask", "x-oss-ac-vpc-id", "x-oss-ac-forward-allow",
+	"metaQuery", "resourceGroup", "rtc", "x-oss-async-process", "responseHeader",
+}
+
+// Credentials is the AccessKey to sign the requests, which is implemented by oss.Credentials
+type Credentials interface {
+	GetAccessKeyID() string
+	GetAccessKeySecret() string
+	GetSecurityToken() string
+}
+
+// StaticCredentials is the fixed AccessKey, the SecurityToken is set for the STS credentials
+type StaticCredentials struct {
+	AccessKeyID     string
+	AccessKeySecret string
+	SecurityToken   string
+}
+
+// GetAccessKeyID returns the AccessKey ID
+func (c StaticCredentials) GetAccessKeyID() string {
+	return c.AccessKeyID
+}
+
+// GetAccessKeySecret returns the AccessKey secret
+func (c StaticCredentials) GetAccessKeySecret() string {
+	return c.AccessKeySecret
+}
+
+// GetSecurityToken returns the STS token
+func (c StaticCredentials) GetSecurityToken() string {
+	return c.SecurityToken
+}
+
+// Signer signs the requests, the zero value signs by V1
+type Signer struct {
+	Version           Version          // The version of the signature, default is V1
+	Region            string           // The region of the V4 scope, such as cn-hangzhou
+	Product           string           // The product of the V4 scope, default is oss
+	CloudBoxID        string           // The cloud box of the V4 scope, it overrides the region and the product is oss-cloudbox
+	AdditionalHeaders []string         // The headers signed by V2 and V4 besides the x-oss- headers, if they are in the request
+	Now               func() time.Time // The clock, default is time.Now
+
+	// Debug receives the canonical request and the string to sign of every signature, the canonical request is
+	// empty for V1 and V2
+	Debug func(req *http.Request, canonicalRequest, stringToSign string)
+}
+
+// IsSubResource checks if the query parameter is signed by the V1 signature
+func IsSubResource(key string) bool {
+	for _, k := range subResources {
+		if key == k {
+			return true
+		}
+	}
+	return false
+}
+
+// EncodeQuery encodes the query by the sorted keys, the spaces are encoded as %20 and the empty values are omitted
+func EncodeQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	for _, k := range keys {
+		if buf.Len() > 0 {
+			buf.WriteByte('&')
+		}
+		buf.WriteString(url.QueryEscape(k))
+		if v := query.Get(k); v != "" {
+			buf.WriteString("=" + escape(v))
+		}
+	}
+	return buf.String()
+}
+
+func escape(s string) string {
+	return strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+}
+
+func (s *Signer) now() time.Time {
+	if s.Now != nil {
+		return s.Now().UTC()
+	}
+	return time.Now().UTC()
+}
+
+// Scope returns the region and the product of the V4 signature
+func (s *Signer) Scope() (region, product string) {
+	if s.CloudBoxID != "" {
+		return s.CloudBoxID, cloudBoxProduct
+	}
+	if s.Product != "" {
+		return s.Region, s.Product
+	}
+	return s.Region, defaultProduct
+}
+
+// SubResource returns the canonicalized query. V1 signs the sub resources only, V2 and V4 sign all the parameters.
+func (s *Signer) SubResource(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	signParams := make(map[string]string)
+	for k := range query {
+		if s.Version == V2 || s.Version == V4 {
+			encodedKey := url.QueryEscape(k)
+			keys = append(keys, encodedKey)
+			signParams[encodedKey] = escape(query.Get(k))
+		} else if IsSubResource(k) {
+			keys = append(keys, k)
+			signParams[k] = query.Get(k)
+		}
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	for _, k := range keys {
+		if buf.Len() > 0 {
+			buf.WriteByte('&')
+		}
+		buf.WriteString(k)
+		if signParams[k] != "" {
+			buf.WriteString("=" + signParams[k])
+		}
+	}
+	return buf.String()
+}
+
+// Resource returns the canonicalized resource of the bucket and the object
+//
+// bucket    the bucket name, empty for the service requests such as ListBuckets.
+// key    the object key, empty for the bucket requests.
+// subResource    the canonicalized query returned by SubResource.
+//
+// string    the canonicalized resource.
+func (s *Signer) Resource(bucket, key, subResource string) string {
+	if subResource != "" {
+		subResource = "?" + subResource
+	}
+	switch s.Version {
+	case V4:
+		if bucket == "" {
+			return "/" + subResource
+		}
+		if key != "" {
+			return "/" + bucket + "/" + strings.Replace(escape(key), "%2F", "/", -1) + subResource
+		}
+		return "/" + bucket + "/" + subResource
+	case V2:
+		if bucket == "" {
+			return url.QueryEscape("/") + subResource
+		}
+		return url.QueryEscape("/"+bucket+"/") + escape(key) + subResource
+	default:
+		if bucket == "" {
+			return "/" + subResource
+		}
+		return fmt.Sprintf("/%s/%s%s", bucket, key, subResource)
+	}
+}
+
+// AdditionalHeaderKeys returns the sorted lower case names of the additional headers in the header
+func (s *Signer) AdditionalHeaderKeys(header http.Header) []string {
+	return s.additionalHeaderKeys(header, false)
+}
+
+// additionalHeaderKeys returns the additional headers, Content-MD5 and Content-Type are skipped for V4 since they
+// are always signed
+func (s *Signer) additionalHeaderKeys(header http.Header, v4 bool) []string {
+	exists := make(map[string]bool)
+	for k := range header {
+		exists[strings.ToLower(k)] = true
+	}
+
+	keys := make(map[string]bool)
+	for _, v := range s.AdditionalHeaders {
+		if v4 && (strings.EqualFold(v, headerContentMD5) || strings.EqualFold(v, headerContentType)) {
+			continue
+		}
+		if exists[strings.ToLower(v)] {
+			keys[strings.ToLower(v)] = true
+		}
+	}
+
+	list := make([]string, 0, len(keys))
+	for k := range keys {
+		list = append(list, k)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// canonicalHeaders returns the sorted lines of name:value of the headers
+func canonicalHeaders(headers map[string]string) string {
+	keys := make([]string, 0, len(headers))
+	for k := range headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	for _, k := range keys {
+		buf.WriteString(k + ":" + headers[k] + "\n")
+	}
+	return buf.String()
+}
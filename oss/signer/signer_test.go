@@ -0,0 +1,205 @@
+package signer
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+type OssSignerSuite struct{}
+
+var _ = Suite(&OssSignerSuite{})
+
+var testCredentials = StaticCredentials{AccessKeyID: "ak", AccessKeySecret: "sk"}
+
+func fixedClock(sec int64) func() time.Time {
+	return func() time.Time { return time.Unix(sec, 0) }
+}
+
+func (s *OssSignerSuite) TestSignV1(c *C) {
+	signer := &Signer{}
+	req, err := http.NewRequest("PUT", "http://examplebucket.oss-cn-hangzhou.aliyuncs.com/nelson", nil)
+	c.Assert(err, IsNil)
+	req.Header.Add("Content-MD5", "eB5eJF1ptWaXm4bijSPyxw==")
+	req.Header.Add("Content-Type", "text/html")
+	req.Header.Add("x-oss-meta-author", "alice")
+	req.Header.Add("x-oss-meta-magic", "abracadabra")
+	req.Header.Add("x-oss-date", "Wed, 28 Dec 2022 10:27:41 GMT")
+	req.Header.Add("Date", "Wed, 28 Dec 2022 10:27:41 GMT")
+	c.Assert(signer.Sign(req, "examplebucket", "nelson", testCredentials), IsNil)
+	c.Assert(req.Header.Get("Authorization"), Equals, "OSS ak:kSHKmLxlyEAKtZPkJhG9bZb5k7M=")
+
+	// The sub resources are signed only
+	query := url.Values{"resourceGroup": {""}, "acl": {""}, "non-resource": {"null"}}
+	c.Assert(signer.SubResource(query), Equals, "acl&resourceGroup")
+	c.Assert(signer.Resource("examplebucket", "", "acl"), Equals, "/examplebucket/?acl")
+	c.Assert(signer.Resource("", "", ""), Equals, "/")
+
+	// The clock sets the Date header, the token is signed
+	var stringToSign string
+	signer.Now = fixedClock(1699807420)
+	signer.Debug = func(req *http.Request, canonicalRequest, s string) {
+		c.Assert(canonicalRequest, Equals, "")
+		stringToSign = s
+	}
+	req, err = http.NewRequest("GET", "http://examplebucket.oss-cn-hangzhou.aliyuncs.com/?acl", nil)
+	c.Assert(err, IsNil)
+	credentials := StaticCredentials{AccessKeyID: "ak", AccessKeySecret: "sk", SecurityToken: "token"}
+	c.Assert(signer.Sign(req, "examplebucket", "", credentials), IsNil)
+	c.Assert(req.Header.Get("Date"), Equals, "Sun, 12 Nov 2023 16:43:40 GMT")
+	c.Assert(req.Header.Get("X-Oss-Security-Token"), Equals, "token")
+	c.Assert(stringToSign, Equals, "GET\n\n\nSun, 12 Nov 2023 16:43:40 GMT\nx-oss-security-token:token\n/examplebucket/?acl")
+	c.Assert(signer.Sign(req, "examplebucket", "", nil), NotNil)
+}
+
+func (s *OssSignerSuite) TestSignV4(c *C) {
+	signer := &Signer{Version: V4, Region: "cn-hangzhou", Now: fixedClock(1702743657)}
+	var canonicalRequest, stringToSign string
+	signer.Debug = func(req *http.Request, cr, s string) {
+		canonicalRequest, stringToSign = cr, s
+	}
+	req, err := http.NewRequest("PUT", "http://bucket.oss-cn-hangzhou.aliyuncs.com/1234%2B-/123/1.txt?"+
+		"param1=value1&%2Bparam1=value3&%7Cparam1=value4&%2Bparam2&%7Cparam2&param2", nil)
+	c.Assert(err, IsNil)
+	req.Header.Add("x-oss-head1", "value")
+	req.Header.Add("abc", "value")
+	req.Header.Add("ZAbc", "value")
+	req.Header.Add("XYZ", "value")
+	req.Header.Add("content-type", "text/plain")
+	c.Assert(signer.Sign(req, "bucket", "1234+-/123/1.txt", testCredentials), IsNil)
+	c.Assert(req.Header.Get("X-Oss-Date"), Equals, "20231216T162057Z")
+	c.Assert(req.Header.Get("X-Oss-Content-Sha256"), Equals, "UNSIGNED-PAYLOAD")
+	c.Assert(req.Header.Get("Authorization"), Equals, "OSS4-HMAC-SHA256 Credential=ak/20231216/cn-hangzhou/oss/aliyun_v4_request,"+
+		"Signature=e21d18daa82167720f9b1047ae7e7f1ce7cb77a31e8203a7d5f4624fa0284afe")
+	c.Assert(strings.HasPrefix(canonicalRequest, "PUT\n/bucket/1234%2B-/123/1.txt\n%2Bparam1=value3&%2Bparam2&%7Cparam1=value4&%7Cparam2&param1=value1&param2\n"),
+		Equals, true)
+	c.Assert(strings.HasPrefix(stringToSign, "OSS4-HMAC-SHA256\n20231216T162057Z\n20231216/cn-hangzhou/oss/aliyun_v4_request\n"), Equals, true)
+
+	// The additional headers
+	signer.AdditionalHeaders = []string{"ZAbc", "abc", "Content-Type", "missing"}
+	c.Assert(signer.AdditionalHeaderKeys(req.Header), DeepEquals, []string{"abc", "content-type", "zabc"})
+	signer.SignHeader(req, signer.Resource("bucket", "1234+-/123/1.txt", signer.SubResource(req.URL.Query())), testCredentials)
+	c.Assert(strings.Contains(req.Header.Get("Authorization"), ",AdditionalHeaders=abc;zabc,"), Equals, true)
+	c.Assert(strings.Contains(canonicalRequest, "\nabc;zabc\nUNSIGNED-PAYLOAD"), Equals, true)
+
+	// The cloud box scope
+	signer.CloudBoxID = "cb-123"
+	region, product := signer.Scope()
+	c.Assert(region, Equals, "cb-123")
+	c.Assert(product, Equals, "oss-cloudbox")
+}
+
+func (s *OssSignerSuite) TestPresign(c *C) {
+	signer := &Signer{}
+	req, err := http.NewRequest("GET", "http://bucket.oss-cn-hangzhou.aliyuncs.com/key?versionId=versionId", nil)
+	c.Assert(err, IsNil)
+	c.Assert(signer.Presign(req, "bucket", "key", testCredentials, time.Unix(1699807420, 0)), IsNil)
+	c.Assert(req.URL.String(), Equals, "http://bucket.oss-cn-hangzhou.aliyuncs.com/key?"+
+		"Expires=1699807420&OSSAccessKeyId=ak&Signature=dcLTea%2BYh9ApirQ8o8dOPqtvJXQ%3D&versionId=versionId")
+
+	signer = &Signer{Version: V4, Region: "cn-hangzhou", Now: fixedClock(1702781677)}
+	req, err = http.NewRequest("PUT", "http://bucket.oss-cn-hangzhou.aliyuncs.com/1234%2B-%2F123%2F1.txt?"+
+		"param1=value1&%2Bparam1=value3&%7Cparam1=value4&%2Bparam2&%7Cparam2&param2", nil)
+	c.Assert(err, IsNil)
+	req.Header.Add("x-oss-head1", "value")
+	req.Header.Add("abc", "value")
+	req.Header.Add("ZAbc", "value")
+	req.Header.Add("XYZ", "value")
+	req.Header.Add("content-type", "application/octet-stream")
+	c.Assert(signer.Presign(req, "bucket", "1234+-/123/1.txt", testCredentials, time.Unix(1702782276, 0)), IsNil)
+	c.Assert(req.URL.RawQuery, Equals, "%2Bparam1=value3&%2Bparam2&param1=value1&param2&"+
+		"x-oss-credential=ak%2F20231217%2Fcn-hangzhou%2Foss%2Faliyun_v4_request&x-oss-date=20231217T025437Z&x-oss-expires=599&"+
+		"x-oss-signature=a39966c61718be0d5b14e668088b3fa07601033f6518ac7b523100014269c0fe&x-oss-signature-version=OSS4-HMAC-SHA256&"+
+		"%7Cparam1=value4&%7Cparam2")
+	c.Assert(req.Header.Get("Authorization"), Equals, "")
+
+	// V2 signs the AccessKey ID and the expiration in the query
+	signer = &Signer{Version: V2}
+	query := url.Values{}
+	c.Assert(signer.PresignQuery("get", nil, "bucket", "a b", query, testCredentials, time.Unix(1699807420, 0)), IsNil)
+	c.Assert(query.Get("x-oss-signature-version"), Equals, "OSS2")
+	c.Assert(query.Get("x-oss-access-key-id"), Equals, "ak")
+	c.Assert(query.Get("x-oss-expires"), Equals, "1699807420")
+	c.Assert(query.Get("x-oss-signature"), Not(Equals), "")
+	c.Assert(signer.Resource("bucket", "a b", ""), Equals, "%2Fbucket%2Fa%20b")
+}
+
+func (s *OssSignerSuite) TestVerifyPresignedURL(c *C) {
+	credentials := StaticCredentials{AccessKeyID: "ak", AccessKeySecret: "sk", SecurityToken: "token"}
+	expiration := time.Unix(1702782276, 0)
+	for _, signer := range []*Signer{
+		{},
+		{Version: V2, AdditionalHeaders: []string{"Range"}},
+		{Version: V4, Region: "cn-hangzhou", Now: fixedClock(1702781677), AdditionalHeaders: []string{"Range"}},
+	} {
+		comment := Commentf("version %s", signer.Version)
+		req, err := http.NewRequest("GET", "https://bucket.oss-cn-hangzhou.aliyuncs.com/dir/a%20b.txt?versionId=v1", nil)
+		c.Assert(err, IsNil)
+		req.Header.Set("Range", "bytes=0-9")
+		c.Assert(signer.Presign(req, "bucket", "dir/a b.txt", credentials, expiration), IsNil)
+
+		p, err := VerifyPresignedURL(req.URL.String(), "GET", req.Header, "sk", time.Unix(1702782000, 0))
+		c.Assert(err, IsNil, comment)
+		c.Assert(p.Bucket, Equals, "bucket")
+		c.Assert(p.Key, Equals, "dir/a b.txt")
+		c.Assert(p.AccessKeyID, Equals, "ak")
+		c.Assert(p.HasSecurityToken, Equals, true)
+		c.Assert(p.Expires.Equal(expiration), Equals, true, comment)
+		if p.Version != V1 {
+			c.Assert(p.AdditionalHeaders, DeepEquals, []string{"range"}, comment)
+		}
+
+		// The method, the signed headers and the secret are signed
+		_, err = VerifyPresignedURL(req.URL.String(), "PUT", req.Header, "sk", time.Unix(1702782000, 0))
+		c.Assert(err.(VerifyError).Code, Equals, "SignatureDoesNotMatch", comment)
+		_, err = VerifyPresignedURL(req.URL.String(), "GET", req.Header, "sk2", time.Unix(1702782000, 0))
+		c.Assert(err.(VerifyError).Code, Equals, "SignatureDoesNotMatch", comment)
+		if p.Version != V1 {
+			_, err = VerifyPresignedURL(req.URL.String(), "GET", http.Header{"Range": {"bytes=0-99"}}, "sk", time.Unix(1702782000, 0))
+			c.Assert(err.(VerifyError).Code, Equals, "SignatureDoesNotMatch", comment)
+		}
+		_, err = VerifyPresignedURL(strings.Replace(req.URL.String(), "versionId=v1", "versionId=v2", 1), "GET", req.Header, "sk", time.Unix(1702782000, 0))
+		c.Assert(err.(VerifyError).Code, Equals, "SignatureDoesNotMatch", comment)
+		_, err = VerifyPresignedURL(req.URL.String(), "GET", req.Header, "sk", expiration)
+		c.Assert(err.(VerifyError).Code, Equals, "AccessDenied", comment)
+	}
+
+	p, err := ParsePresignedURL("https://bucket.oss-cn-hangzhou.aliyuncs.com/key?x-oss-signature-version=OSS4-HMAC-SHA256&" +
+		"x-oss-credential=ak%2F20231217%2Fcb-1%2Foss-cloudbox%2Faliyun_v4_request&x-oss-date=20231217T025437Z&x-oss-expires=599&x-oss-signature=abc")
+	c.Assert(err, IsNil)
+	c.Assert(p.Version, Equals, V4)
+	c.Assert(p.Region, Equals, "cb-1")
+	c.Assert(p.Product, Equals, "oss-cloudbox")
+	c.Assert(p.SignedAt.Equal(time.Unix(1702781677, 0)), Equals, true)
+	c.Assert(p.Expires.Equal(time.Unix(1702782276, 0)), Equals, true)
+	c.Assert(p.HasSecurityToken, Equals, false)
+
+	// The path style and the custom domain
+	p, err = ParsePresignedURL("http://127.0.0.1:8080/bucket/dir/key?Expires=1699807420&OSSAccessKeyId=ak&Signature=abc")
+	c.Assert(err, IsNil)
+	c.Assert(p.Version, Equals, V1)
+	c.Assert(p.Bucket, Equals, "bucket")
+	c.Assert(p.Key, Equals, "dir/key")
+	p, err = ParsePresignedURL("http://static.example.com/dir/key?Expires=1699807420&OSSAccessKeyId=ak&Signature=abc")
+	c.Assert(err, IsNil)
+	c.Assert(p.Bucket, Equals, "")
+	c.Assert(p.Key, Equals, "dir/key")
+
+	for _, rawURL := range []string{
+		"http://bucket.oss-cn-hangzhou.aliyuncs.com/key",
+		"http://bucket.oss-cn-hangzhou.aliyuncs.com/key?Expires=abc&OSSAccessKeyId=ak&Signature=abc",
+		"http://bucket.oss-cn-hangzhou.aliyuncs.com/key?x-oss-signature-version=OSS4-HMAC-SHA256&x-oss-credential=ak&x-oss-date=20231217T025437Z&x-oss-expires=1&x-oss-signature=a",
+		"http://bucket.oss-cn-hangzhou.aliyuncs.com/key?x-oss-signature-version=OSS2&x-oss-expires=1&x-oss-signature=a",
+	} {
+		_, err = ParsePresignedURL(rawURL)
+		c.Assert(err, NotNil, Commentf(rawURL))
+	}
+}
@@ -0,0 +1,206 @@
+package signer
+
+import (
+	"crypto/hmac"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PresignedURL is the details of a presigned URL, which are independent of the method
+type PresignedURL struct {
+	Version           Version
+	Bucket            string // The bucket name, it's empty if the host is a custom domain
+	Key               string // The object key
+	AccessKeyID       string
+	SignedAt          time.Time // The signing time of V4, it's zero for V1 and V2
+	Expires           time.Time
+	Region            string   // The region of the V4 scope, or the cloud box ID
+	Product           string   // The product of the V4 scope, such as oss or oss-cloudbox
+	AdditionalHeaders []string // The signed headers besides Content-MD5, Content-Type and the x-oss- headers
+	HasSecurityToken  bool     // Whether it's signed by the STS credentials
+	Signature         string
+
+	query url.Values
+}
+
+// VerifyError is the failure of the verification, the Code is the error code of OSS
+type VerifyError struct {
+	Code    string // AccessDenied or SignatureDoesNotMatch
+	Message string
+}
+
+// Error implements interface error
+func (e VerifyError) Error() string {
+	return fmt.Sprintf("signer: %s: %s", e.Code, e.Message)
+}
+
+// ParsePresignedURL parses the presigned URL of V1 (Expires, OSSAccessKeyId and Signature), V2 (x-oss-signature-version
+// OSS2) or V4 (x-oss-credential, x-oss-date and x-oss-expires). The bucket is the first path segment if the host is
+// an IP or localhost, and it's the first label of the host if the host is an OSS domain such as
+// bucket.oss-cn-hangzhou.aliyuncs.com, otherwise the bucket is empty and the key is the path.
+//
+// rawURL    the presigned URL.
+//
+// *PresignedURL    the details.
+// error    it's nil if no error, otherwise it's an error object.
+func ParsePresignedURL(rawURL string) (*PresignedURL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	query := u.Query()
+	p := &PresignedURL{query: query}
+	p.Bucket, p.Key = splitBucketKey(u)
+
+	switch {
+	case query.Get(paramVersion) == algorithmV4:
+		p.Version = V4
+		p.HasSecurityToken = query.Get(paramSecurityTokenV4) != ""
+		// AccessKeyID/day/region/product/aliyun_v4_request
+		credential := strings.Split(query.Get(paramCredential), "/")
+		if len(credential) != 5 || credential[4] != requestTerminatorV4 {
+			return nil, fmt.Errorf("signer: invalid %s %s", paramCredential, query.Get(paramCredential))
+		}
+		p.AccessKeyID, p.Region, p.Product = credential[0], credential[2], credential[3]
+		if p.SignedAt, err = time.Parse(timeFormatV4, query.Get(paramDate)); err != nil {
+			return nil, fmt.Errorf("signer: invalid %s %s", paramDate, query.Get(paramDate))
+		}
+		if credential[1] != p.SignedAt.Format(shortTimeFormatV4) {
+			return nil, fmt.Errorf("signer: the date of %s doesn't match %s", paramCredential, paramDate)
+		}
+		expires, err := strconv.ParseInt(query.Get(paramExpiresV2), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("signer: invalid %s %s", paramExpiresV2, query.Get(paramExpiresV2))
+		}
+		p.Expires = p.SignedAt.Add(time.Duration(expires) * time.Second)
+		p.Signature = query.Get(paramSignatureV2)
+	case query.Get(paramVersion) == "OSS2":
+		p.Version = V2
+		p.HasSecurityToken = query.Get(paramSecurityToken) != ""
+		p.AccessKeyID = query.Get(paramAccessKeyIDV2)
+		if p.Expires, err = parseUnixTime(paramExpiresV2, query.Get(paramExpiresV2)); err != nil {
+			return nil, err
+		}
+		p.Signature = query.Get(paramSignatureV2)
+	case query.Get(paramSignature) != "":
+		p.Version = V1
+		p.HasSecurityToken = query.Get(paramSecurityToken) != ""
+		p.AccessKeyID = query.Get(paramAccessKeyID)
+		if p.Expires, err = parseUnixTime(paramExpires, query.Get(paramExpires)); err != nil {
+			return nil, err
+		}
+		p.Signature = query.Get(paramSignature)
+	default:
+		return nil, fmt.Errorf("signer: the URL isn't presigned")
+	}
+
+	if p.AccessKeyID == "" || p.Signature == "" {
+		return nil, fmt.Errorf("signer: the AccessKey ID or the signature is missing")
+	}
+	if additional := query.Get(paramAdditionalV2); additional != "" {
+		p.AdditionalHeaders = strings.Split(additional, ";")
+	}
+	return p, nil
+}
+
+// Expired checks if the URL expires at the time
+func (p *PresignedURL) Expired(now time.Time) bool {
+	return !now.Before(p.Expires)
+}
+
+// Verify recomputes the signature of the request by the URL, and checks the expiration. The Bucket could be set
+// before the verification if it's empty, such as for the custom domains.
+//
+// method    the HTTP method of the request.
+// header    the headers of the request, the signed headers such as Content-Type must be the same as presigning.
+// secret    the AccessKey secret of the AccessKeyID.
+// now    the time to check the expiration.
+//
+// error    it's nil if the request is allowed, it's a VerifyError if the URL expires or the signature doesn't match.
+func (p *PresignedURL) Verify(method string, header http.Header, secret string, now time.Time) error {
+	if p.Expired(now) {
+		return VerifyError{Code: "AccessDenied", Message: "Request has expired."}
+	}
+
+	s := &Signer{Version: p.Version, Region: p.Region, Product: p.Product, AdditionalHeaders: p.AdditionalHeaders}
+	req := &http.Request{Method: strings.ToUpper(method), Header: make(http.Header)}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	// The query without the signature is signed
+	query := make(url.Values, len(p.query))
+	for k, v := range p.query {
+		if k != paramSignature && k != paramSignatureV2 && !(p.Version == V1 && (k == paramExpires || k == paramAccessKeyID)) {
+			query[k] = v
+		}
+	}
+	resource := s.Resource(p.Bucket, p.Key, s.SubResource(query))
+
+	var signature string
+	if p.Version == V4 {
+		signature = s.Signature(req, resource, secret, p.SignedAt)
+	} else {
+		req.Header.Set(headerDate, strconv.FormatInt(p.Expires.Unix(), 10))
+		signature = s.Signature(req, resource, secret, time.Time{})
+	}
+	if !hmac.Equal([]byte(signature), []byte(p.Signature)) {
+		return VerifyError{Code: "SignatureDoesNotMatch", Message: "The request signature we calculated does not match the signature you provided."}
+	}
+	return nil
+}
+
+// VerifyPresignedURL parses the presigned URL and verifies the request by ParsePresignedURL and PresignedURL.Verify
+//
+// rawURL    the presigned URL.
+// method    the HTTP method of the request.
+// header    the headers of the request.
+// secret    the AccessKey secret of the AccessKeyID.
+// now    the time to check the expiration.
+//
+// *PresignedURL    the details, it's nil if the URL is invalid.
+// error    it's nil if the request is allowed, otherwise it's an error object.
+func VerifyPresignedURL(rawURL, method string, header http.Header, secret string, now time.Time) (*PresignedURL, error) {
+	p, err := ParsePresignedURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return p, p.Verify(method, header, secret, now)
+}
+
+func parseUnixTime(name, value string) (time.Time, error) {
+	sec, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("signer: invalid %s %s", name, value)
+	}
+	return time.Unix(sec, 0), nil
+}
+
+// splitBucketKey returns the bucket and the key of the path style or the virtual hosted style URL
+func splitBucketKey(u *url.URL) (bucket, key string) {
+	host := u.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	path := strings.TrimPrefix(u.Path, "/")
+
+	if net.ParseIP(strings.Trim(host, "[]")) != nil || !strings.Contains(host, ".") {
+		if pos := strings.Index(path, "/"); pos != -1 {
+			return path[:pos], path[pos+1:]
+		}
+		return path, ""
+	}
+
+	labels := strings.Split(host, ".")
+	for _, label := range labels[1:] {
+		if label == "oss" || strings.HasPrefix(label, "oss-") {
+			return labels[0], path
+		}
+	}
+	return "", path
+}
@@ -0,0 +1,255 @@
+package osssql
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/binary"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) {
+	TestingT(t)
+}
+
+type OssSQLSuite struct{}
+
+var _ = Suite(&OssSQLSuite{})
+
+// selectFrame builds a frame of the select response
+func selectFrame(frameType int32, offset uint64, payload []byte) []byte {
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.BigEndian, frameType|1<<24)
+	binary.Write(buf, binary.BigEndian, int32(len(payload)+8))
+	binary.Write(buf, binary.BigEndian, uint32(0))
+	binary.Write(buf, binary.BigEndian, offset)
+	buf.Write(payload)
+	binary.Write(buf, binary.BigEndian, uint32(0))
+	return buf.Bytes()
+}
+
+func selectEndFrame(totalScanned int64, status int32) []byte {
+	payload := new(bytes.Buffer)
+	binary.Write(payload, binary.BigEndian, totalScanned)
+	binary.Write(payload, binary.BigEndian, status)
+	return selectFrame(oss.EndFrameType, uint64(totalScanned), payload.Bytes())
+}
+
+func csvMetaFrame(columns int32) []byte {
+	payload := new(bytes.Buffer)
+	binary.Write(payload, binary.BigEndian, int64(100))
+	binary.Write(payload, binary.BigEndian, int32(200))
+	binary.Write(payload, binary.BigEndian, int32(1))
+	binary.Write(payload, binary.BigEndian, int64(3))
+	binary.Write(payload, binary.BigEndian, columns)
+	return selectFrame(oss.MetaEndFrameCSVType, 100, payload.Bytes())
+}
+
+// sqlServer serves the select requests by data, the expressions and the request bodies are recorded
+type sqlServer struct {
+	*httptest.Server
+	data        string
+	metas       int
+	expressions []string
+	bodies      []string
+}
+
+func newSQLServer(c *C, data string) *sqlServer {
+	expressionRe := regexp.MustCompile("<Expression>(.*)</Expression>")
+	s := &sqlServer{data: data}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+		if r.URL.Query().Get("x-oss-process") == "csv/meta" {
+			s.metas++
+			w.Write(csvMetaFrame(3))
+			return
+		}
+
+		m := expressionRe.FindStringSubmatch(string(body))
+		c.Assert(m, NotNil)
+		expression, err := base64.StdEncoding.DecodeString(m[1])
+		c.Assert(err, IsNil)
+		s.expressions = append(s.expressions, string(expression))
+		s.bodies = append(s.bodies, string(body))
+		if s.data != "" {
+			w.Write(selectFrame(oss.DataFrameType, 0, []byte(s.data)))
+		}
+		w.Write(selectEndFrame(100, 200))
+	}))
+	return s
+}
+
+func (s *OssSQLSuite) TestParseQuery(c *C) {
+	t, expression, err := parseQuery("select * from `dir/a b.csv?header=use` where name = ? and age > ? and note = '?'",
+		[]driver.Value{"O'Brien", int64(18)})
+	c.Assert(err, IsNil)
+	c.Assert(t.key, Equals, "dir/a b.csv")
+	c.Assert(t.hints.Get("header"), Equals, "use")
+	c.Assert(expression, Equals, "select * from ossobject where name = 'O''Brien' and age > 18 and note = '?'")
+
+	t, expression, err = parseQuery(`SELECT s.name FROM "people.json".contacts[*] s WHERE s.ok = ? AND s.at < ?`,
+		[]driver.Value{true, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)})
+	c.Assert(err, IsNil)
+	c.Assert(t.key, Equals, "people.json")
+	c.Assert(expression, Equals, "SELECT s.name FROM ossobject.contacts[*] s WHERE s.ok = true AND s.at < '2020-01-02T03:04:05Z'")
+
+	_, expression, err = parseQuery("select _1 from a.csv where _2 = ? or _3 is ?", []driver.Value{1.5, nil})
+	c.Assert(err, IsNil)
+	c.Assert(expression, Equals, "select _1 from ossobject where _2 = 1.5 or _3 is null")
+
+	// The keyword in the identifier and the string is not the FROM clause
+	t, _, err = parseQuery("select fromage, 'from x' from b.csv", nil)
+	c.Assert(err, IsNil)
+	c.Assert(t.key, Equals, "b.csv")
+
+	_, _, err = parseQuery("select * from a.csv where _1 = ?", nil)
+	c.Assert(err, NotNil)
+	_, _, err = parseQuery("select 1", nil)
+	c.Assert(err, NotNil)
+	_, _, err = parseQuery("select * from \"a.csv?region=cn\"", nil)
+	c.Assert(err, NotNil)
+	_, _, err = parseQuery("select * from a.csv where _1 = ?", []driver.Value{struct{}{}})
+	c.Assert(err, NotNil)
+	c.Assert(countPlaceholders("select ? from a where x = '?' and \"?\" = ?"), Equals, 2)
+
+	// The backslashes are escaped, the comments are dropped
+	_, expression, err = parseQuery("select * from a.csv -- where _1 = ?\nwhere _1 = ? and _2 = '\\'?'", []driver.Value{"a\\' or 1=1 --"})
+	c.Assert(err, IsNil)
+	c.Assert(expression, Equals, "select * from ossobject \nwhere _1 = 'a\\\\'' or 1=1 --' and _2 = '\\'?'")
+	c.Assert(countPlaceholders("select ? -- ?\nfrom a where x = 'it\\'s ?'"), Equals, 1)
+	t, _, err = parseQuery("-- select * from x.csv\nselect * from b.csv", nil)
+	c.Assert(err, IsNil)
+	c.Assert(t.key, Equals, "b.csv")
+}
+
+func (s *OssSQLSuite) TestQueryCSV(c *C) {
+	server := newSQLServer(c, "name,age,joined\nTom,18,2020-01-02\n\"Jerry, Jr\",,2021-03-04\n")
+	defer server.Close()
+
+	endpoint := strings.TrimPrefix(server.URL, "http://")
+	db, err := sql.Open("oss", "oss://ak:sk@"+endpoint+"/bucket?header=use&types=string,int,time")
+	c.Assert(err, IsNil)
+	defer db.Close()
+
+	rows, err := db.Query("select * from people.csv where age > ? or name = ?", 10, "Tom")
+	c.Assert(err, IsNil)
+	columns, err := rows.Columns()
+	c.Assert(err, IsNil)
+	c.Assert(columns, DeepEquals, []string{"name", "age", "joined"})
+	types, err := rows.ColumnTypes()
+	c.Assert(err, IsNil)
+	c.Assert(types[1].DatabaseTypeName(), Equals, "INT")
+
+	var names []string
+	var ages []sql.NullInt64
+	var joined []time.Time
+	for rows.Next() {
+		var name string
+		var age sql.NullInt64
+		var t time.Time
+		c.Assert(rows.Scan(&name, &age, &t), IsNil)
+		names = append(names, name)
+		ages = append(ages, age)
+		joined = append(joined, t)
+	}
+	c.Assert(rows.Err(), IsNil)
+	c.Assert(rows.Close(), IsNil)
+
+	c.Assert(names, DeepEquals, []string{"Tom", "Jerry, Jr"})
+	c.Assert(ages, DeepEquals, []sql.NullInt64{{Int64: 18, Valid: true}, {}})
+	c.Assert(joined[1].Equal(time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC)), Equals, true)
+	c.Assert(server.expressions[0], Equals, "select * from ossobject where age > 10 or name = 'Tom'")
+	c.Assert(strings.Contains(server.bodies[0], "<FileHeaderInfo>USE</FileHeaderInfo>"), Equals, true)
+
+	// The meta is cached, the columns of the empty result come from it
+	server.data = ""
+	rows, err = db.Query(`select * from "people.csv?header=none"`)
+	c.Assert(err, IsNil)
+	columns, _ = rows.Columns()
+	c.Assert(columns, DeepEquals, []string{"_1", "_2", "_3"})
+	c.Assert(rows.Next(), Equals, false)
+	c.Assert(rows.Close(), IsNil)
+	c.Assert(server.metas, Equals, 1)
+
+	// The meta is created again for other CSV settings
+	rows, err = db.Query(`select * from "people.csv?field_delimiter=%3B"`)
+	c.Assert(err, IsNil)
+	c.Assert(rows.Close(), IsNil)
+	c.Assert(server.metas, Equals, 2)
+
+	_, err = db.Exec("select * from people.csv")
+	c.Assert(err, NotNil)
+	_, err = db.Query(`select * from "people.csv?format=xml"`)
+	c.Assert(err, NotNil)
+}
+
+func (s *OssSQLSuite) TestQueryJSON(c *C) {
+	server := newSQLServer(c, "{\"name\":\"Tom\",\"age\":18,\"score\":9.5,\"tags\":[\"a\"]}\n{\"age\":20,\"name\":null}\n")
+	defer server.Close()
+
+	endpoint := strings.TrimPrefix(server.URL, "http://")
+	db, err := sql.Open("oss", "oss://ak:sk@"+endpoint+"/bucket?format=json")
+	c.Assert(err, IsNil)
+	defer db.Close()
+
+	stmt, err := db.Prepare(`select * from "people.json?json_type=document".people[*] where name = ?`)
+	c.Assert(err, IsNil)
+	defer stmt.Close()
+	rows, err := stmt.Query("Tom")
+	c.Assert(err, IsNil)
+	defer rows.Close()
+
+	columns, _ := rows.Columns()
+	c.Assert(columns, DeepEquals, []string{"name", "age", "score", "tags"})
+	values := make([]interface{}, 4)
+	pointers := make([]interface{}, 4)
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	c.Assert(rows.Next(), Equals, true)
+	c.Assert(rows.Scan(pointers...), IsNil)
+	c.Assert(values, DeepEquals, []interface{}{"Tom", int64(18), 9.5, []byte("[\"a\"]")})
+	c.Assert(rows.Next(), Equals, true)
+	c.Assert(rows.Scan(pointers...), IsNil)
+	c.Assert(values, DeepEquals, []interface{}{nil, int64(20), nil, nil})
+	c.Assert(rows.Next(), Equals, false)
+	c.Assert(rows.Err(), IsNil)
+
+	c.Assert(server.metas, Equals, 0)
+	c.Assert(server.expressions[0], Equals, "select * from ossobject.people[*] where name = 'Tom'")
+	c.Assert(strings.Contains(server.bodies[0], "<Type>DOCUMENT</Type>"), Equals, true)
+}
+
+func (s *OssSQLSuite) TestOpen(c *C) {
+	d := &Driver{}
+	_, err := d.Open("mysql://ak:sk@endpoint/bucket")
+	c.Assert(err, NotNil)
+	_, err = d.Open("oss://endpoint/bucket")
+	c.Assert(err, NotNil)
+	_, err = d.Open("oss://ak:sk@endpoint")
+	c.Assert(err, NotNil)
+	_, err = d.Open("oss://ak:sk@endpoint/bucket?unknown=1")
+	c.Assert(err, NotNil)
+	_, err = d.Open("oss://ak:sk@endpoint/bucket?types=int,decimal")
+	c.Assert(err, NotNil)
+
+	cn, err := d.Open("oss://ak:sk@oss-cn-hangzhou.aliyuncs.com/bucket?secure=true&region=cn-hangzhou&security_token=token")
+	c.Assert(err, IsNil)
+	config := cn.(*conn).bucket.GetConfig()
+	c.Assert(config.Endpoint, Equals, "https://oss-cn-hangzhou.aliyuncs.com")
+	c.Assert(config.AuthVersion, Equals, oss.AuthV4)
+	c.Assert(config.SecurityToken, Equals, "token")
+	_, err = cn.Begin()
+	c.Assert(err, NotNil)
+}
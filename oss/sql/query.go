@@ -0,0 +1,217 @@
+package osssql
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// table is the object referenced by the FROM clause
+type table struct {
+	key   string     // The object key
+	hints url.Values // The settings in the query string of the table name
+}
+
+// parseQuery replaces the table by ossobject and the placeholders by the escaped arguments
+func parseQuery(query string, args []driver.Value) (table, string, error) {
+	var t table
+	if n := countPlaceholders(query); n != len(args) {
+		return t, "", fmt.Errorf("osssql: expected %d arguments, got %d", n, len(args))
+	}
+
+	var out strings.Builder
+	argIndex := 0
+	foundTable := false
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'':
+			end := skipQuoted(query, i, '\'')
+			out.WriteString(query[i:end])
+			i = end
+		case c == '"' || c == '`':
+			end := skipQuoted(query, i, c)
+			out.WriteString(query[i:end])
+			i = end
+		case isCommentAt(query, i):
+			// The comments are dropped, the line break is kept
+			i = skipComment(query, i)
+		case c == '?':
+			literal, err := escapeArg(args[argIndex])
+			if err != nil {
+				return t, "", err
+			}
+			out.WriteString(literal)
+			argIndex++
+			i++
+		case !foundTable && isKeywordAt(query, i, "from"):
+			out.WriteString(query[i : i+4])
+			i += 4
+			for i < len(query) && unicode.IsSpace(rune(query[i])) {
+				out.WriteByte(query[i])
+				i++
+			}
+			name, end, err := tableName(query, i)
+			if err != nil {
+				return t, "", err
+			}
+			if t, err = parseTable(name); err != nil {
+				return t, "", err
+			}
+			out.WriteString("ossobject")
+			i = end
+			foundTable = true
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+
+	if !foundTable {
+		return t, "", fmt.Errorf("osssql: the query has no FROM clause")
+	}
+	return t, out.String(), nil
+}
+
+// countPlaceholders counts the ? outside the quoted strings, identifiers and comments
+func countPlaceholders(query string) int {
+	n := 0
+	for i := 0; i < len(query); {
+		switch c := query[i]; {
+		case c == '\'' || c == '"' || c == '`':
+			i = skipQuoted(query, i, c)
+		case isCommentAt(query, i):
+			i = skipComment(query, i)
+		case c == '?':
+			n++
+			i++
+		default:
+			i++
+		}
+	}
+	return n
+}
+
+// skipQuoted returns the position after the quoted text starting at i, doubled quotes are escaped quotes.
+// The backslash escapes the next character in the strings quoted by single quotes.
+func skipQuoted(query string, i int, quote byte) int {
+	for j := i + 1; j < len(query); j++ {
+		if quote == '\'' && query[j] == '\\' {
+			j++
+			continue
+		}
+		if query[j] != quote {
+			continue
+		}
+		if j+1 < len(query) && query[j+1] == quote {
+			j++
+			continue
+		}
+		return j + 1
+	}
+	return len(query)
+}
+
+// isCommentAt checks if a -- comment starts at i
+func isCommentAt(query string, i int) bool {
+	return strings.HasPrefix(query[i:], "--")
+}
+
+// skipComment returns the position of the line break ending the comment starting at i
+func skipComment(query string, i int) int {
+	if end := strings.IndexByte(query[i:], '\n'); end != -1 {
+		return i + end
+	}
+	return len(query)
+}
+
+// isKeywordAt checks if the keyword is at i as a whole word
+func isKeywordAt(query string, i int, keyword string) bool {
+	if i+len(keyword) > len(query) || !strings.EqualFold(query[i:i+len(keyword)], keyword) {
+		return false
+	}
+	if i > 0 && isIdentChar(query[i-1]) {
+		return false
+	}
+	return i+len(keyword) == len(query) || !isIdentChar(query[i+len(keyword)])
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// tableName reads the table name at i, which is quoted or ends at a space.
+// The JSON path after a quoted name, such as "a.json".contacts[*], is kept in the expression.
+func tableName(query string, i int) (string, int, error) {
+	if i >= len(query) {
+		return "", i, fmt.Errorf("osssql: the table name is missing")
+	}
+	if c := query[i]; c == '"' || c == '`' {
+		end := skipQuoted(query, i, c)
+		if end > len(query) || query[end-1] != c || end-i < 3 {
+			return "", i, fmt.Errorf("osssql: invalid table name %s", query[i:])
+		}
+		return strings.Replace(query[i+1:end-1], string([]byte{c, c}), string(c), -1), end, nil
+	}
+
+	end := i
+	for end < len(query) && !unicode.IsSpace(rune(query[end])) && query[end] != ';' && query[end] != ')' {
+		end++
+	}
+	if end == i {
+		return "", i, fmt.Errorf("osssql: the table name is missing")
+	}
+	return query[i:end], end, nil
+}
+
+// parseTable splits the table name into the object key and the hints
+func parseTable(name string) (table, error) {
+	t := table{key: name, hints: url.Values{}}
+	if pos := strings.Index(name, "?"); pos != -1 {
+		hints, err := url.ParseQuery(name[pos+1:])
+		if err != nil {
+			return t, fmt.Errorf("osssql: invalid table hints %s", name[pos+1:])
+		}
+		for _, k := range []string{"secure", "security_token", "region"} {
+			if _, ok := hints[k]; ok {
+				return t, fmt.Errorf("osssql: %s could not be set in the table hints", k)
+			}
+		}
+		t.key, t.hints = name[:pos], hints
+	}
+	if t.key == "" {
+		return t, fmt.Errorf("osssql: the object key is empty")
+	}
+	return t, nil
+}
+
+// escapeArg renders the argument as a SQL literal
+func escapeArg(arg driver.Value) (string, error) {
+	switch v := arg.(type) {
+	case nil:
+		return "null", nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case string:
+		return quoteString(v), nil
+	case []byte:
+		return quoteString(string(v)), nil
+	case time.Time:
+		return quoteString(v.Format(time.RFC3339Nano)), nil
+	}
+	return "", fmt.Errorf("osssql: unsupported argument type %T", arg)
+}
+
+// quoteString quotes the string by single quotes, the backslashes and the single quotes in it are escaped
+func quoteString(s string) string {
+	s = strings.Replace(s, "\\", "\\\\", -1)
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
@@ -0,0 +1,219 @@
+package osssql
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// isValidColumnType checks the column type of the types setting
+func isValidColumnType(t string) bool {
+	switch t {
+	case "string", "int", "float", "bool", "time":
+		return true
+	}
+	return false
+}
+
+// rows is the result of a query, the first record is read ahead to get the columns
+type rows struct {
+	reader  *oss.SelectRecordReader
+	config  tableConfig
+	columns []string
+	types   []string
+	pending bool // Whether the current record of the reader is not returned yet
+}
+
+// newRows reads the columns of the result. The CSV columns are named by the output header if the header is used,
+// otherwise they're named _1, _2 and so on, and the count of them comes from the CSV meta when the result is empty.
+// The JSON columns are the keys of the first record.
+func newRows(reader *oss.SelectRecordReader, config tableConfig, meta *oss.MetaEndFrameCSV) (*rows, error) {
+	r := &rows{reader: reader, config: config}
+	r.pending = reader.Next()
+	if err := reader.Err(); err != nil {
+		reader.Close()
+		return nil, err
+	}
+
+	switch {
+	case config.isJSON():
+		if r.pending {
+			keys, _, err := decodeJSONRecord(reader.RawJSON())
+			if err != nil {
+				reader.Close()
+				return nil, err
+			}
+			r.columns = keys
+		}
+	case config.header == "use":
+		if r.pending {
+			r.columns = append([]string{}, reader.Record()...)
+			r.pending = reader.Next()
+		}
+	case r.pending:
+		for i := 1; i <= len(reader.Record()); i++ {
+			r.columns = append(r.columns, "_"+strconv.Itoa(i))
+		}
+	}
+	if !config.isJSON() && len(r.columns) == 0 && meta != nil {
+		for i := 1; i <= int(meta.ColumnsCount); i++ {
+			r.columns = append(r.columns, "_"+strconv.Itoa(i))
+		}
+	}
+
+	r.types = make([]string, len(r.columns))
+	for i := range r.types {
+		r.types[i] = "string"
+		if !config.isJSON() && i < len(config.types) {
+			r.types[i] = config.types[i]
+		}
+	}
+	return r, nil
+}
+
+// Columns returns the column names
+func (r *rows) Columns() []string {
+	return r.columns
+}
+
+// ColumnTypeDatabaseTypeName returns the type of the column, JSON columns are typed by the values
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	if r.config.isJSON() {
+		return ""
+	}
+	return strings.ToUpper(r.types[index])
+}
+
+// Close closes the response body
+func (r *rows) Close() error {
+	return r.reader.Close()
+}
+
+// Next reads the next record into dest
+func (r *rows) Next(dest []driver.Value) error {
+	if !r.pending && !r.reader.Next() {
+		if err := r.reader.Err(); err != nil {
+			return err
+		}
+		return io.EOF
+	}
+	r.pending = false
+
+	for i := range dest {
+		dest[i] = nil
+	}
+	if r.config.isJSON() {
+		keys, values, err := decodeJSONRecord(r.reader.RawJSON())
+		if err != nil {
+			return err
+		}
+		for i, key := range keys {
+			for j, column := range r.columns {
+				if column == key && j < len(dest) {
+					dest[j] = values[i]
+				}
+			}
+		}
+		return nil
+	}
+
+	record := r.reader.Record()
+	for i := 0; i < len(dest) && i < len(record); i++ {
+		value, err := convertValue(record[i], r.types[i])
+		if err != nil {
+			return fmt.Errorf("osssql: column %s: %s", r.columns[i], err.Error())
+		}
+		dest[i] = value
+	}
+	return nil
+}
+
+// convertValue converts the CSV field to the column type, the empty field is NULL except for the string columns
+func convertValue(field, columnType string) (driver.Value, error) {
+	if field == "" && columnType != "string" {
+		return nil, nil
+	}
+	switch columnType {
+	case "int":
+		return strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+	case "float":
+		return strconv.ParseFloat(strings.TrimSpace(field), 64)
+	case "bool":
+		return strconv.ParseBool(strings.TrimSpace(field))
+	case "time":
+		field = strings.TrimSpace(field)
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"} {
+			if t, err := time.Parse(layout, field); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("invalid time %s", field)
+	}
+	return field, nil
+}
+
+// decodeJSONRecord decodes the keys and the values of the JSON object in order.
+// Numbers are int64 or float64, objects and arrays are kept as the raw JSON.
+func decodeJSONRecord(data []byte) ([]string, []driver.Value, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, nil, fmt.Errorf("osssql: invalid JSON record %s", string(data))
+	}
+
+	// The map loses the order of the keys, which is read by the tokens of the top level object
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if _, err := decoder.Token(); err != nil {
+		return nil, nil, err
+	}
+	keys := make([]string, 0, len(fields))
+	values := make([]driver.Value, 0, len(fields))
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		var raw json.RawMessage
+		if err = decoder.Decode(&raw); err != nil {
+			return nil, nil, err
+		}
+		value, err := jsonValue(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, token.(string))
+		values = append(values, value)
+	}
+	return keys, values, nil
+}
+
+func jsonValue(raw json.RawMessage) (driver.Value, error) {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		return []byte(trimmed), nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(trimmed))
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return nil, err
+	}
+	switch value := v.(type) {
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i, nil
+		}
+		return value.Float64()
+	case nil, bool, string:
+		return value, nil
+	}
+	return nil, fmt.Errorf("osssql: unsupported JSON value %s", string(raw))
+}
@@ -4,26 +4,22 @@
 package oss
 
 import (
+	"context"
 	"crypto/tls"
 	"net"
 	"net/http"
+	"sync"
 	"time"
 )
 
 func newTransport(conn *Conn, config *Config) *http.Transport {
 	httpTimeOut := conn.config.HTTPTimeout
 	httpMaxConns := conn.config.HTTPMaxConns
+	dial := newDialContext(config)
 	// New Transport
 	transport := &http.Transport{
-		Dial: func(netw, addr string) (net.Conn, error) {
-			d := net.Dialer{
-				Timeout:   httpTimeOut.ConnectTimeout,
-				KeepAlive: 30 * time.Second,
-			}
-			if config.LocalAddr != nil {
-				d.LocalAddr = config.LocalAddr
-			}
-			conn, err := d.Dial(netw, addr)
+		DialContext: func(ctx context.Context, netw, addr string) (net.Conn, error) {
+			conn, err := dial(ctx, netw, addr)
 			if err != nil {
 				return nil, err
 			}
@@ -35,11 +31,106 @@ func newTransport(conn *Conn, config *Config) *http.Transport {
 		IdleConnTimeout:       httpTimeOut.IdleConnTimeout,
 		ResponseHeaderTimeout: httpTimeOut.HeaderTimeout,
 	}
+	setForceAttemptHTTP2(transport, config.EnableHTTP2)
 
-	if config.InsecureSkipVerify {
+	if config.InsecureSkipVerify || config.TLSRootCAs != nil || len(config.TLSCertificates) > 0 || config.TLSMinVersion != 0 {
 		transport.TLSClientConfig = &tls.Config{
-			InsecureSkipVerify: true,
+			InsecureSkipVerify: config.InsecureSkipVerify,
+			RootCAs:            config.TLSRootCAs,
+			Certificates:       config.TLSCertificates,
+			MinVersion:         config.TLSMinVersion,
 		}
 	}
 	return transport
 }
+
+// newDialContext returns the dialer of the config, the addresses are cached if DNSCacheTTL is set
+func newDialContext(config *Config) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	dial := config.DialContext
+	if dial == nil {
+		d := &net.Dialer{
+			Timeout:   config.HTTPTimeout.ConnectTimeout,
+			KeepAlive: config.TCPKeepAlive,
+			Resolver:  config.DNSResolver,
+		}
+		if config.LocalAddr != nil {
+			d.LocalAddr = config.LocalAddr
+		}
+		dial = d.DialContext
+	}
+	if config.DNSCacheTTL > 0 {
+		dial = newDNSCache(config.DNSResolver, config.DNSCacheTTL).dialContext(dial)
+	}
+	return dial
+}
+
+// dnsCache caches the addresses of the hosts for the TTL
+type dnsCache struct {
+	ttl     time.Duration
+	lookup  func(ctx context.Context, host string) ([]string, error)
+	mu      sync.Mutex
+	entries map[string]dnsEntry
+}
+
+type dnsEntry struct {
+	addrs   []string
+	expires time.Time
+}
+
+func newDNSCache(resolver *net.Resolver, ttl time.Duration) *dnsCache {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	return &dnsCache{ttl: ttl, lookup: resolver.LookupHost, entries: map[string]dnsEntry{}}
+}
+
+// resolve returns the cached addresses of the host, they're looked up again after the TTL
+func (c *dnsCache) resolve(ctx context.Context, host string) ([]string, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[host]
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.addrs, nil
+	}
+
+	addrs, err := c.lookup(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.entries[host] = dnsEntry{addrs: addrs, expires: time.Now().Add(c.ttl)}
+	c.mu.Unlock()
+	return addrs, nil
+}
+
+// forget removes the host, so it's looked up again by the next connection
+func (c *dnsCache) forget(host string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, host)
+}
+
+// dialContext dials the cached addresses of the host in order, the host is looked up again if all of them fail
+func (c *dnsCache) dialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil || net.ParseIP(host) != nil {
+			return dial(ctx, network, addr)
+		}
+		addrs, err := c.resolve(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range addrs {
+			var conn net.Conn
+			if conn, err = dial(ctx, network, net.JoinHostPort(ip, port)); err == nil {
+				return conn, nil
+			}
+		}
+		c.forget(host)
+		if err == nil {
+			err = &net.DNSError{Err: "no such host", Name: host}
+		}
+		return nil, err
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !go1.13
+// +build !go1.13
+
+package oss
+
+import (
+	"net/http"
+)
+
+// setForceAttemptHTTP2 does nothing, http.Transport.ForceAttemptHTTP2 is added in go1.13
+func setForceAttemptHTTP2(transport *http.Transport, enabled bool) {
+}
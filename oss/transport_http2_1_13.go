@@ -0,0 +1,13 @@
+//go:build go1.13
+// +build go1.13
+
+package oss
+
+import (
+	"net/http"
+)
+
+// setForceAttemptHTTP2 sets whether the transport tries HTTP/2 for https
+func setForceAttemptHTTP2(transport *http.Transport, enabled bool) {
+	transport.ForceAttemptHTTP2 = enabled
+}
@@ -0,0 +1,128 @@
+package oss
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type OssTransportSuite struct{}
+
+var _ = Suite(&OssTransportSuite{})
+
+func (s *OssTransportSuite) TestTLS(c *C) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.ProtoMajor, Equals, 2)
+		c.Assert(len(r.TLS.PeerCertificates), Equals, 1)
+		w.Write([]byte("<LocationConstraint>oss-cn-hangzhou</LocationConstraint>"))
+	}))
+	server.EnableHTTP2 = true
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+
+	// The server certificate isn't trusted
+	client, err := New(server.URL, "ak", "sk", EnableHTTP2(true))
+	c.Assert(err, IsNil)
+	_, err = client.GetBucketLocation("bucket")
+	c.Assert(err, NotNil)
+
+	// The CA, the client certificate and HTTP/2, the connections are still wrapped by the timeout
+	var dials int32
+	client, err = New(server.URL, "ak", "sk", EnableHTTP2(true), TLSRootCAs(pool), TLSMinVersion(tls.VersionTLS12),
+		TLSClientCertificates(server.TLS.Certificates[0]),
+		DialContext(func(ctx context.Context, network, addr string) (net.Conn, error) {
+			atomic.AddInt32(&dials, 1)
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		}))
+	c.Assert(err, IsNil)
+	transport := client.Conn.client.Transport.(*http.Transport)
+	c.Assert(transport.TLSClientConfig.MinVersion, Equals, uint16(tls.VersionTLS12))
+	conn, err := transport.DialContext(context.Background(), "tcp", server.Listener.Addr().String())
+	c.Assert(err, IsNil)
+	_, ok := conn.(*timeoutConn)
+	c.Assert(ok, Equals, true)
+	conn.Close()
+
+	location, err := client.GetBucketLocation("bucket")
+	c.Assert(err, IsNil)
+	c.Assert(location, Equals, "oss-cn-hangzhou")
+	c.Assert(atomic.LoadInt32(&dials), Equals, int32(2))
+}
+
+func (s *OssTransportSuite) TestProxy(c *C) {
+	client, err := New("oss-cn-hangzhou.aliyuncs.com", "ak", "sk", ProxyFromEnvironment())
+	c.Assert(err, IsNil)
+	c.Assert(client.Conn.client.Transport.(*http.Transport).Proxy, NotNil)
+
+	client, err = New("oss-cn-hangzhou.aliyuncs.com", "ak", "sk", Proxy("socks5://127.0.0.1:1080"), ProxyFromEnvironment())
+	c.Assert(err, IsNil)
+	req, _ := http.NewRequest("GET", "http://bucket.oss-cn-hangzhou.aliyuncs.com/", nil)
+	proxyURL, err := client.Conn.client.Transport.(*http.Transport).Proxy(req)
+	c.Assert(err, IsNil)
+	c.Assert(proxyURL.String(), Equals, "socks5://127.0.0.1:1080")
+
+	client, err = New("oss-cn-hangzhou.aliyuncs.com", "ak", "sk")
+	c.Assert(err, IsNil)
+	c.Assert(client.Conn.client.Transport.(*http.Transport).Proxy, IsNil)
+}
+
+func (s *OssTransportSuite) TestDNSCache(c *C) {
+	var lookups int32
+	cache := newDNSCache(nil, 50*time.Millisecond)
+	cache.lookup = func(ctx context.Context, host string) ([]string, error) {
+		atomic.AddInt32(&lookups, 1)
+		return []string{"10.0.0.1", "10.0.0.2"}, nil
+	}
+	var dialed []string
+	failing := map[string]bool{}
+	dial := cache.dialContext(func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = append(dialed, addr)
+		if failing[addr] {
+			return nil, errors.New("refused")
+		}
+		client, server := net.Pipe()
+		server.Close()
+		return client, nil
+	})
+
+	// The addresses are cached for the TTL
+	_, err := dial(context.Background(), "tcp", "example.com:443")
+	c.Assert(err, IsNil)
+	_, err = dial(context.Background(), "tcp", "example.com:443")
+	c.Assert(err, IsNil)
+	c.Assert(atomic.LoadInt32(&lookups), Equals, int32(1))
+	time.Sleep(60 * time.Millisecond)
+	_, err = dial(context.Background(), "tcp", "example.com:443")
+	c.Assert(err, IsNil)
+	c.Assert(atomic.LoadInt32(&lookups), Equals, int32(2))
+
+	// The next address is dialed, and the host is resolved again if all of them fail
+	dialed = nil
+	failing["10.0.0.1:443"] = true
+	_, err = dial(context.Background(), "tcp", "example.com:443")
+	c.Assert(err, IsNil)
+	c.Assert(dialed, DeepEquals, []string{"10.0.0.1:443", "10.0.0.2:443"})
+	failing["10.0.0.2:443"] = true
+	_, err = dial(context.Background(), "tcp", "example.com:443")
+	c.Assert(err, NotNil)
+	_, err = dial(context.Background(), "tcp", "example.com:443")
+	c.Assert(err, NotNil)
+	c.Assert(atomic.LoadInt32(&lookups), Equals, int32(3))
+
+	// The IP isn't resolved
+	dialed = nil
+	_, err = dial(context.Background(), "tcp", "127.0.0.1:80")
+	c.Assert(err, IsNil)
+	c.Assert(dialed, DeepEquals, []string{"127.0.0.1:80"})
+}
@@ -8,6 +8,7 @@ import (
 	"hash/crc64"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"runtime"
 	"strconv"
@@ -332,6 +333,27 @@ func ChoiceTransferPartOption(options []Option) []Option {
 		outOption = append(outOption, GetResponseHeader(respHeader.(*http.Header)))
 	}
 
+	uploadLimiter, _ := FindOption(options, uploadLimiterArg, nil)
+	if uploadLimiter != nil {
+		outOption = append(outOption, UploadLimiter(uploadLimiter.(BandwidthLimiter)))
+	}
+
+	downloadLimiter, _ := FindOption(options, downloadLimiterArg, nil)
+	if downloadLimiter != nil {
+		outOption = append(outOption, DownloadLimiter(downloadLimiter.(BandwidthLimiter)))
+	}
+
+	return outOption
+}
+
+// choiceCopyPartOption choices valid option supported by UploadPartCopy, the source conditions are only sent by the copy
+func choiceCopyPartOption(options []Option) []Option {
+	outOption := ChoiceTransferPartOption(options)
+
+	copySourceIfMatch, _ := FindOption(options, HTTPHeaderOssCopySourceIfMatch, nil)
+	if copySourceIfMatch != nil {
+		outOption = append(outOption, CopySourceIfMatch(copySourceIfMatch.(string)))
+	}
 	return outOption
 }
 
@@ -590,6 +612,15 @@ func ConvertEmptyValueToNil(params map[string]interface{}, keys []string) {
 	}
 }
 
+// UnescapeObjectKey decodes the URL encoded object key in the access logs and the inventory files,
+// + is kept since the spaces are encoded as %20
+func UnescapeObjectKey(key string) (string, error) {
+	if !strings.Contains(key, "%") {
+		return key, nil
+	}
+	return url.QueryUnescape(strings.Replace(key, "+", "%2B", -1))
+}
+
 func EscapeLFString(str string) string {
 	var log bytes.Buffer
 	for i := 0; i < len(str); i++ {
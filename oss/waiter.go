@@ -0,0 +1,473 @@
+package oss
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+)
+
+// defaultWaiterMinDelay is the delay after the first poll if Waiter.MinDelay is not set
+const defaultWaiterMinDelay = time.Second
+
+// WaiterState is the state of a waiter after a poll
+type WaiterState int
+
+const (
+	// WaiterRetry the resource is not in the expected state yet, poll again
+	WaiterRetry WaiterState = iota
+
+	// WaiterSuccess the resource reached the expected state
+	WaiterSuccess
+
+	// WaiterFailure the resource reached a state from which the expected state can not be reached
+	WaiterFailure
+)
+
+// WaiterAcceptor moves the waiter to State when Matcher matches the result of a poll
+type WaiterAcceptor struct {
+	State   WaiterState                              // The state when matched
+	Matcher func(output interface{}, err error) bool // Checks the output and error of a poll
+}
+
+// Waiter polls an operation until one of its acceptors moves it to success or failure.
+// Acceptors are evaluated in order and the first match wins. If none matches,
+// the waiter fails on an error and polls again otherwise.
+type Waiter struct {
+	Name      string                                           // Waiter name used in logs and errors
+	Poll      func(ctx context.Context) (interface{}, error)   // The operation to poll
+	Acceptors []WaiterAcceptor                                 // The acceptors
+	MinDelay  time.Duration                                    // The delay after the first poll, 0 means one second
+	MaxDelay  time.Duration                                    // The max delay between two polls, at least MinDelay
+	MaxWait   time.Duration                                    // The max total wait time, 0 means no limit
+	Logger    func(level int, format string, a ...interface{}) // Optional logger, such as Config.WriteLog
+}
+
+// WaiterTimeoutError is returned when the waiter gives up after MaxWait
+type WaiterTimeoutError struct {
+	Name     string        // Waiter name
+	Attempts int           // The number of polls
+	Elapsed  time.Duration // The elapsed time
+	LastErr  error         // The error of the last poll, if any
+}
+
+// Error implements interface error
+func (e WaiterTimeoutError) Error() string {
+	msg := fmt.Sprintf("oss: waiter %s timed out after %d attempts in %v", e.Name, e.Attempts, e.Elapsed)
+	if e.LastErr != nil {
+		msg = fmt.Sprintf("%s, last error: %s", msg, e.LastErr.Error())
+	}
+	return msg
+}
+
+// WaiterFailureError is returned when a failure acceptor matches a poll without error
+type WaiterFailureError struct {
+	Name   string      // Waiter name
+	Reason string      // Why the expected state can not be reached
+	Output interface{} // The output of the last poll
+}
+
+// Error implements interface error
+func (e WaiterFailureError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("oss: waiter %s entered a failure state", e.Name)
+	}
+	return fmt.Sprintf("oss: waiter %s entered a failure state, %s", e.Name, e.Reason)
+}
+
+// Wait polls until success, failure, timeout or the context is done.
+//
+// ctx    the context to cancel the wait, nil means context.Background().
+//
+// interface{}    the output of the last poll.
+// error    it's nil when the waiter succeeds, otherwise it's an error object.
+func (w Waiter) Wait(ctx context.Context) (interface{}, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	start := time.Now()
+	var deadline <-chan time.Time
+	if w.MaxWait > 0 {
+		maxWaitTimer := time.NewTimer(w.MaxWait)
+		defer maxWaitTimer.Stop()
+		deadline = maxWaitTimer.C
+	}
+
+	delay := w.MinDelay
+	if delay <= 0 {
+		delay = defaultWaiterMinDelay
+	}
+	maxDelay := w.MaxDelay
+	if maxDelay < delay {
+		maxDelay = delay
+	}
+	for attempt := 1; ; attempt++ {
+		output, err := w.Poll(ctx)
+		if err != nil && ctx.Err() != nil {
+			return output, ctx.Err()
+		}
+		state := w.accept(output, err)
+		w.log(Debug, "Waiter %s, attempt:%d, state:%d, error:%v\n", w.Name, attempt, state, err)
+		switch state {
+		case WaiterSuccess:
+			return output, nil
+		case WaiterFailure:
+			if err == nil {
+				err = WaiterFailureError{Name: w.Name, Output: output}
+			}
+			return output, err
+		}
+
+		timer := time.NewTimer(jitterDelay(delay))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return output, ctx.Err()
+		case <-deadline:
+			timer.Stop()
+			return output, WaiterTimeoutError{Name: w.Name, Attempts: attempt, Elapsed: time.Since(start), LastErr: err}
+		case <-timer.C:
+		}
+
+		delay *= 2
+		if delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+}
+
+// accept returns the state of the first matched acceptor
+func (w Waiter) accept(output interface{}, err error) WaiterState {
+	for _, acceptor := range w.Acceptors {
+		if acceptor.Matcher(output, err) {
+			return acceptor.State
+		}
+	}
+	if err != nil {
+		return WaiterFailure
+	}
+	return WaiterRetry
+}
+
+func (w Waiter) log(level int, format string, a ...interface{}) {
+	if w.Logger != nil {
+		w.Logger(level, format, a...)
+	}
+}
+
+// jitterDelay returns a random delay in [d/2, d]
+func jitterDelay(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
+
+// matchNoError matches the polls without error
+func matchNoError(output interface{}, err error) bool {
+	return err == nil
+}
+
+// matchNotFound matches the polls failed with 404
+func matchNotFound(output interface{}, err error) bool {
+	srvErr, ok := err.(ServiceError)
+	return ok && srvErr.StatusCode == 404
+}
+
+// newWaiter builds a waiter from the waiter options with the given defaults, and returns the context in the options
+func newWaiter(name string, options []Option, minDelay, maxDelay, maxWait time.Duration, logger func(int, string, ...interface{})) (Waiter, context.Context, error) {
+	w := Waiter{
+		Name:     name,
+		MinDelay: minDelay,
+		MaxDelay: maxDelay,
+		MaxWait:  maxWait,
+		Logger:   logger,
+	}
+
+	params := map[string]optionValue{}
+	for _, option := range options {
+		if option != nil {
+			if err := option(params); err != nil {
+				return w, nil, err
+			}
+		}
+	}
+	if v, ok := params[waiterMinDelay]; ok {
+		w.MinDelay = v.Value.(time.Duration)
+	}
+	if v, ok := params[waiterMaxDelay]; ok {
+		w.MaxDelay = v.Value.(time.Duration)
+	}
+	if v, ok := params[waiterMaxWait]; ok {
+		w.MaxWait = v.Value.(time.Duration)
+	}
+
+	var ctx context.Context
+	if v, ok := params[contextArg]; ok {
+		ctx, _ = v.Value.(context.Context)
+	}
+	return w, ctx, nil
+}
+
+// WaitUntilObjectRestored waits until the restore of an archived object is completed.
+//
+// objectKey    the object key, RestoreObject must have been called on it.
+// options    the options for HeadObject, plus WithContext, WaiterDelay and WaiterMaxWait.
+//
+//	By default it polls from every 30 seconds up to every 5 minutes for at most 48 hours.
+//
+// *ObjectMeta    the object metadata after the restore is completed.
+// error    it's nil if the object is restored, otherwise it's an error object.
+func (bucket Bucket) WaitUntilObjectRestored(objectKey string, options ...Option) (*ObjectMeta, error) {
+	w, ctx, err := newWaiter("ObjectRestored", options, 30*time.Second, 5*time.Minute, 48*time.Hour, bucket.GetConfig().WriteLog)
+	if err != nil {
+		return nil, err
+	}
+	w.Poll = func(ctx context.Context) (interface{}, error) {
+		return bucket.HeadObject(objectKey, append(options, WithContext(ctx))...)
+	}
+	w.Acceptors = []WaiterAcceptor{
+		{State: WaiterSuccess, Matcher: func(output interface{}, err error) bool {
+			meta, _ := output.(*ObjectMeta)
+			return err == nil && meta.Restore != nil && !meta.Restore.OngoingRequest
+		}},
+		{State: WaiterFailure, Matcher: func(output interface{}, err error) bool {
+			meta, _ := output.(*ObjectMeta)
+			return err == nil && meta.Restore == nil
+		}},
+	}
+
+	output, err := w.Wait(ctx)
+	if failure, ok := err.(WaiterFailureError); ok {
+		failure.Reason = fmt.Sprintf("object %s is not being restored", objectKey)
+		err = failure
+	}
+	meta, _ := output.(*ObjectMeta)
+	return meta, err
+}
+
+// WaitUntilObjectExists waits until the object exists, such as the target object of AsyncProcessObject.
+//
+// objectKey    the object key.
+// options    the options for HeadObject, plus WithContext, WaiterDelay and WaiterMaxWait.
+//
+//	By default it polls from every 2 seconds up to every 20 seconds for at most 10 minutes.
+//
+// *ObjectMeta    the object metadata.
+// error    it's nil if the object exists, otherwise it's an error object.
+func (bucket Bucket) WaitUntilObjectExists(objectKey string, options ...Option) (*ObjectMeta, error) {
+	w, ctx, err := newWaiter("ObjectExists", options, 2*time.Second, 20*time.Second, 10*time.Minute, bucket.GetConfig().WriteLog)
+	if err != nil {
+		return nil, err
+	}
+	w.Poll = func(ctx context.Context) (interface{}, error) {
+		return bucket.HeadObject(objectKey, append(options, WithContext(ctx))...)
+	}
+	w.Acceptors = []WaiterAcceptor{
+		{State: WaiterSuccess, Matcher: matchNoError},
+		{State: WaiterRetry, Matcher: matchNotFound},
+	}
+
+	output, err := w.Wait(ctx)
+	meta, _ := output.(*ObjectMeta)
+	return meta, err
+}
+
+// WaitUntilObjectNotExists waits until the object is deleted.
+//
+// objectKey    the object key.
+// options    the options for HeadObject, plus WithContext, WaiterDelay and WaiterMaxWait.
+//
+//	By default it polls from every 2 seconds up to every 20 seconds for at most 10 minutes.
+//
+// error    it's nil if the object does not exist, otherwise it's an error object.
+func (bucket Bucket) WaitUntilObjectNotExists(objectKey string, options ...Option) error {
+	w, ctx, err := newWaiter("ObjectNotExists", options, 2*time.Second, 20*time.Second, 10*time.Minute, bucket.GetConfig().WriteLog)
+	if err != nil {
+		return err
+	}
+	w.Poll = func(ctx context.Context) (interface{}, error) {
+		return bucket.HeadObject(objectKey, append(options, WithContext(ctx))...)
+	}
+	w.Acceptors = []WaiterAcceptor{
+		{State: WaiterSuccess, Matcher: matchNotFound},
+	}
+
+	_, err = w.Wait(ctx)
+	return err
+}
+
+// WaitUntilAsyncFetchDone waits until the task started by SetBucketAsyncTask finishes.
+//
+// bucketName    the bucket name.
+// taskID    the task id returned by SetBucketAsyncTask.
+// options    the options for GetBucketAsyncTask, plus WithContext, WaiterDelay and WaiterMaxWait.
+//
+//	By default it polls from every 2 seconds up to every 30 seconds for at most 1 hour.
+//
+// AsynFetchTaskInfo    the task information of the last poll.
+// error    it's nil if the task succeeds, otherwise it's an error object.
+func (client Client) WaitUntilAsyncFetchDone(bucketName, taskID string, options ...Option) (AsynFetchTaskInfo, error) {
+	w, ctx, err := newWaiter("AsyncFetchDone", options, 2*time.Second, 30*time.Second, time.Hour, client.Config.WriteLog)
+	if err != nil {
+		return AsynFetchTaskInfo{}, err
+	}
+	w.Poll = func(ctx context.Context) (interface{}, error) {
+		return client.GetBucketAsyncTask(bucketName, taskID, append(options, WithContext(ctx))...)
+	}
+	w.Acceptors = []WaiterAcceptor{
+		{State: WaiterSuccess, Matcher: func(output interface{}, err error) bool {
+			info, _ := output.(AsynFetchTaskInfo)
+			return err == nil && (info.State == "Success" || info.State == "Fetch_Success_Callback_Failed")
+		}},
+		{State: WaiterFailure, Matcher: func(output interface{}, err error) bool {
+			info, _ := output.(AsynFetchTaskInfo)
+			return err == nil && info.State == "Failed"
+		}},
+	}
+
+	output, err := w.Wait(ctx)
+	info, _ := output.(AsynFetchTaskInfo)
+	if failure, ok := err.(WaiterFailureError); ok {
+		failure.Reason = fmt.Sprintf("task %s failed, %s", taskID, info.ErrorMsg)
+		err = failure
+	}
+	return info, err
+}
+
+// WaitUntilMetaQueryReady waits until the metadata index library opened by OpenMetaQuery finishes the full scan.
+//
+// bucketName    the bucket name.
+// options    the options for GetMetaQueryStatus, plus WithContext, WaiterDelay and WaiterMaxWait.
+//
+//	By default it polls from every 10 seconds up to every 2 minutes for at most 24 hours.
+//
+// GetMetaQueryStatusResult    the status of the last poll.
+// error    it's nil if DoMetaQuery is ready to use, otherwise it's an error object.
+func (client Client) WaitUntilMetaQueryReady(bucketName string, options ...Option) (GetMetaQueryStatusResult, error) {
+	w, ctx, err := newWaiter("MetaQueryReady", options, 10*time.Second, 2*time.Minute, 24*time.Hour, client.Config.WriteLog)
+	if err != nil {
+		return GetMetaQueryStatusResult{}, err
+	}
+	w.Poll = func(ctx context.Context) (interface{}, error) {
+		return client.GetMetaQueryStatus(bucketName, append(options, WithContext(ctx))...)
+	}
+	w.Acceptors = []WaiterAcceptor{
+		{State: WaiterFailure, Matcher: func(output interface{}, err error) bool {
+			status, _ := output.(GetMetaQueryStatusResult)
+			return err == nil && (status.State == "Failed" || status.State == "Deleted" || status.State == "Stop")
+		}},
+		{State: WaiterSuccess, Matcher: func(output interface{}, err error) bool {
+			status, _ := output.(GetMetaQueryStatusResult)
+			return err == nil && status.Phase == "IncrementalScanning"
+		}},
+	}
+
+	output, err := w.Wait(ctx)
+	status, _ := output.(GetMetaQueryStatusResult)
+	if failure, ok := err.(WaiterFailureError); ok {
+		failure.Reason = fmt.Sprintf("meta query state is %s", status.State)
+		err = failure
+	}
+	return status, err
+}
+
+// WaitUntilReplicationHistoricalDone waits until the historical objects of a replication rule are replicated.
+//
+// bucketName    the bucket name.
+// ruleId    the replication rule id.
+// options    the options for GetBucketReplicationProgress, plus WithContext, WaiterDelay and WaiterMaxWait.
+//
+//	By default it polls from every 30 seconds up to every 5 minutes for at most 48 hours.
+//
+// GetBucketReplicationProgressResult    the progress of the last poll.
+// error    it's nil if the historical objects are replicated, otherwise it's an error object.
+func (client Client) WaitUntilReplicationHistoricalDone(bucketName, ruleId string, options ...Option) (GetBucketReplicationProgressResult, error) {
+	w, ctx, err := newWaiter("ReplicationHistoricalDone", options, 30*time.Second, 5*time.Minute, 48*time.Hour, client.Config.WriteLog)
+	if err != nil {
+		return GetBucketReplicationProgressResult{}, err
+	}
+	w.Poll = func(ctx context.Context) (interface{}, error) {
+		var out GetBucketReplicationProgressResult
+		data, err := client.GetBucketReplicationProgress(bucketName, ruleId, append(options, WithContext(ctx))...)
+		if err != nil {
+			return out, err
+		}
+		err = xml.Unmarshal([]byte(data), &out)
+		return out, err
+	}
+	w.Acceptors = []WaiterAcceptor{
+		{State: WaiterSuccess, Matcher: func(output interface{}, err error) bool {
+			progress, _ := output.(GetBucketReplicationProgressResult)
+			if err != nil || len(progress.Rule) == 0 {
+				return false
+			}
+			rule := progress.Rule[0]
+			if rule.HistoricalObjectReplication == "disabled" {
+				return true
+			}
+			if rule.Progress == nil {
+				return false
+			}
+			done, err := strconv.ParseFloat(rule.Progress.HistoricalObject, 64)
+			return err == nil && done >= 1
+		}},
+	}
+
+	output, err := w.Wait(ctx)
+	progress, _ := output.(GetBucketReplicationProgressResult)
+	return progress, err
+}
+
+// WaitUntilBucketExists waits until a newly created bucket is visible.
+//
+// bucketName    the bucket name.
+// options    the options for GetBucketInfo, plus WithContext, WaiterDelay and WaiterMaxWait.
+//
+//	By default it polls from every 1 second up to every 10 seconds for at most 5 minutes.
+//
+// error    it's nil if the bucket exists, otherwise it's an error object.
+func (client Client) WaitUntilBucketExists(bucketName string, options ...Option) error {
+	w, ctx, err := newWaiter("BucketExists", options, time.Second, 10*time.Second, 5*time.Minute, client.Config.WriteLog)
+	if err != nil {
+		return err
+	}
+	w.Poll = func(ctx context.Context) (interface{}, error) {
+		return client.GetBucketInfo(bucketName, append(options, WithContext(ctx))...)
+	}
+	w.Acceptors = []WaiterAcceptor{
+		{State: WaiterSuccess, Matcher: matchNoError},
+		{State: WaiterRetry, Matcher: matchNotFound},
+	}
+
+	_, err = w.Wait(ctx)
+	return err
+}
+
+// WaitUntilBucketNotExists waits until a deleted bucket is not visible any more.
+//
+// bucketName    the bucket name.
+// options    the options for GetBucketInfo, plus WithContext, WaiterDelay and WaiterMaxWait.
+//
+//	By default it polls from every 1 second up to every 10 seconds for at most 5 minutes.
+//
+// error    it's nil if the bucket does not exist, otherwise it's an error object.
+func (client Client) WaitUntilBucketNotExists(bucketName string, options ...Option) error {
+	w, ctx, err := newWaiter("BucketNotExists", options, time.Second, 10*time.Second, 5*time.Minute, client.Config.WriteLog)
+	if err != nil {
+		return err
+	}
+	w.Poll = func(ctx context.Context) (interface{}, error) {
+		return client.GetBucketInfo(bucketName, append(options, WithContext(ctx))...)
+	}
+	w.Acceptors = []WaiterAcceptor{
+		{State: WaiterSuccess, Matcher: matchNotFound},
+	}
+
+	_, err = w.Wait(ctx)
+	return err
+}
@@ -0,0 +1,254 @@
+package oss
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type OssWaiterSuite struct{}
+
+var _ = Suite(&OssWaiterSuite{})
+
+func (s *OssWaiterSuite) TestWaiterStates(c *C) {
+	var polls int32
+	w := Waiter{
+		Name: "Test",
+		Poll: func(ctx context.Context) (interface{}, error) {
+			return int(atomic.AddInt32(&polls, 1)), nil
+		},
+		Acceptors: []WaiterAcceptor{
+			{State: WaiterSuccess, Matcher: func(output interface{}, err error) bool { return output.(int) == 3 }},
+		},
+		MinDelay: time.Millisecond,
+		MaxDelay: 2 * time.Millisecond,
+	}
+	output, err := w.Wait(nil)
+	c.Assert(err, IsNil)
+	c.Assert(output, Equals, 3)
+
+	// failure acceptor
+	atomic.StoreInt32(&polls, 0)
+	w.Acceptors = []WaiterAcceptor{
+		{State: WaiterFailure, Matcher: func(output interface{}, err error) bool { return output.(int) == 2 }},
+	}
+	output, err = w.Wait(context.Background())
+	c.Assert(output, Equals, 2)
+	_, ok := err.(WaiterFailureError)
+	c.Assert(ok, Equals, true)
+
+	// unmatched errors fail the waiter
+	pollErr := errors.New("poll error")
+	w.Poll = func(ctx context.Context) (interface{}, error) {
+		return 0, pollErr
+	}
+	_, err = w.Wait(context.Background())
+	c.Assert(err, Equals, pollErr)
+}
+
+func (s *OssWaiterSuite) TestWaiterTimeoutAndCancel(c *C) {
+	w := Waiter{
+		Name: "Test",
+		Poll: func(ctx context.Context) (interface{}, error) {
+			return nil, nil
+		},
+		MinDelay: time.Millisecond,
+		MaxDelay: 5 * time.Millisecond,
+		MaxWait:  50 * time.Millisecond,
+	}
+	_, err := w.Wait(context.Background())
+	timeoutErr, ok := err.(WaiterTimeoutError)
+	c.Assert(ok, Equals, true)
+	c.Assert(timeoutErr.Attempts > 1, Equals, true)
+
+	w.MaxWait = 0
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = w.Wait(ctx)
+	c.Assert(err, Equals, context.DeadlineExceeded)
+
+	// the delays are not set, the waiter does not busy poll
+	w.MinDelay, w.MaxDelay, w.MaxWait = 0, 0, 50*time.Millisecond
+	_, err = w.Wait(context.Background())
+	c.Assert(err.(WaiterTimeoutError).Attempts, Equals, 1)
+
+	w.MinDelay = 10 * time.Millisecond
+	_, err = w.Wait(context.Background())
+	c.Assert(err.(WaiterTimeoutError).Attempts <= 11, Equals, true)
+}
+
+func (s *OssWaiterSuite) TestWaiterOptions(c *C) {
+	ctx := context.Background()
+	w, waitCtx, err := newWaiter("Test", []Option{WithContext(ctx), WaiterDelay(time.Millisecond, time.Second), WaiterMaxWait(time.Minute)},
+		time.Second, time.Minute, time.Hour, nil)
+	c.Assert(err, IsNil)
+	c.Assert(waitCtx, Equals, ctx)
+	c.Assert(w.MinDelay, Equals, time.Millisecond)
+	c.Assert(w.MaxDelay, Equals, time.Second)
+	c.Assert(w.MaxWait, Equals, time.Minute)
+
+	_, _, err = newWaiter("Test", []Option{WaiterDelay(time.Second, time.Millisecond)}, time.Second, time.Minute, time.Hour, nil)
+	c.Assert(err, NotNil)
+
+	for i := 0; i < 100; i++ {
+		d := jitterDelay(10 * time.Millisecond)
+		c.Assert(d >= 5*time.Millisecond && d <= 10*time.Millisecond, Equals, true)
+	}
+}
+
+func (s *OssWaiterSuite) TestWaitUntilObjectRestored(c *C) {
+	var polls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Method, Equals, "HEAD")
+		switch r.URL.Path {
+		case "/bucket/restored":
+			if atomic.AddInt32(&polls, 1) < 3 {
+				w.Header().Set(HTTPHeaderOssRestore, "ongoing-request=\"true\"")
+			} else {
+				w.Header().Set(HTTPHeaderOssRestore, "ongoing-request=\"false\", expiry-date=\"Sun, 16 Apr 2017 08:12:33 GMT\"")
+			}
+			w.WriteHeader(http.StatusOK)
+		case "/bucket/normal":
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := New(server.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("bucket")
+	c.Assert(err, IsNil)
+
+	meta, err := bucket.WaitUntilObjectRestored("restored", WaiterDelay(time.Millisecond, time.Millisecond))
+	c.Assert(err, IsNil)
+	c.Assert(atomic.LoadInt32(&polls), Equals, int32(3))
+	c.Assert(meta.Restore.OngoingRequest, Equals, false)
+
+	_, err = bucket.WaitUntilObjectRestored("normal", WaiterDelay(time.Millisecond, time.Millisecond))
+	failure, ok := err.(WaiterFailureError)
+	c.Assert(ok, Equals, true)
+	c.Assert(failure.Reason, Equals, "object normal is not being restored")
+
+	_, err = bucket.WaitUntilObjectRestored("missing", WaiterDelay(time.Millisecond, time.Millisecond))
+	srvErr, ok := err.(ServiceError)
+	c.Assert(ok, Equals, true)
+	c.Assert(srvErr.StatusCode, Equals, 404)
+}
+
+func (s *OssWaiterSuite) TestWaitUntilObjectExists(c *C) {
+	var polls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&polls, 1) < 3 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := New(server.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+	bucket, err := client.Bucket("bucket")
+	c.Assert(err, IsNil)
+
+	_, err = bucket.WaitUntilObjectExists("object", WaiterDelay(time.Millisecond, time.Millisecond))
+	c.Assert(err, IsNil)
+	c.Assert(atomic.LoadInt32(&polls), Equals, int32(3))
+
+	err = bucket.WaitUntilObjectNotExists("object", WaiterDelay(time.Millisecond, time.Millisecond), WaiterMaxWait(20*time.Millisecond))
+	_, ok := err.(WaiterTimeoutError)
+	c.Assert(ok, Equals, true)
+}
+
+func (s *OssWaiterSuite) TestWaitUntilAsyncFetchDone(c *C) {
+	var polls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.Header.Get(HTTPHeaderOssTaskID), Equals, "task-id")
+		state := "Running"
+		if atomic.AddInt32(&polls, 1) == 2 {
+			state = "Failed"
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("<AsyncFetchTaskInfo><TaskId>task-id</TaskId><State>" + state + "</State><ErrorMsg>source not found</ErrorMsg></AsyncFetchTaskInfo>"))
+	}))
+	defer server.Close()
+
+	client, err := New(server.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+
+	info, err := client.WaitUntilAsyncFetchDone("bucket", "task-id", WaiterDelay(time.Millisecond, time.Millisecond))
+	c.Assert(info.State, Equals, "Failed")
+	failure, ok := err.(WaiterFailureError)
+	c.Assert(ok, Equals, true)
+	c.Assert(failure.Reason, Equals, "task task-id failed, source not found")
+}
+
+func (s *OssWaiterSuite) TestWaitUntilMetaQueryReady(c *C) {
+	var polls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		phase := "FullScanning"
+		if atomic.AddInt32(&polls, 1) == 2 {
+			phase = "IncrementalScanning"
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("<MetaQueryStatus><State>Running</State><Phase>" + phase + "</Phase></MetaQueryStatus>"))
+	}))
+	defer server.Close()
+
+	client, err := New(server.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+
+	status, err := client.WaitUntilMetaQueryReady("bucket", WaiterDelay(time.Millisecond, time.Millisecond))
+	c.Assert(err, IsNil)
+	c.Assert(status.Phase, Equals, "IncrementalScanning")
+}
+
+func (s *OssWaiterSuite) TestWaitUntilReplicationHistoricalDone(c *C) {
+	var polls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.URL.Query().Get("rule-id"), Equals, "rule1")
+		progress := "0.85"
+		if atomic.AddInt32(&polls, 1) == 2 {
+			progress = "1.00"
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("<ReplicationProgress><Rule><ID>rule1</ID><HistoricalObjectReplication>enabled</HistoricalObjectReplication>" +
+			"<Progress><HistoricalObject>" + progress + "</HistoricalObject><NewObject>2015-09-24T15:28:14.000Z</NewObject></Progress></Rule></ReplicationProgress>"))
+	}))
+	defer server.Close()
+
+	client, err := New(server.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+
+	progress, err := client.WaitUntilReplicationHistoricalDone("bucket", "rule1", WaiterDelay(time.Millisecond, time.Millisecond))
+	c.Assert(err, IsNil)
+	c.Assert(progress.Rule[0].Progress.HistoricalObject, Equals, "1.00")
+}
+
+func (s *OssWaiterSuite) TestWaitUntilBucketNotExists(c *C) {
+	var polls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&polls, 1) < 2 {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("<BucketInfo><Bucket><Name>bucket</Name></Bucket></BucketInfo>"))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("<Error><Code>NoSuchBucket</Code></Error>"))
+	}))
+	defer server.Close()
+
+	client, err := New(server.URL, "ak", "sk")
+	c.Assert(err, IsNil)
+
+	err = client.WaitUntilBucketNotExists("bucket", WaiterDelay(time.Millisecond, time.Millisecond))
+	c.Assert(err, IsNil)
+	c.Assert(atomic.LoadInt32(&polls), Equals, int32(2))
+}